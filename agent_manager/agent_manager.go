@@ -22,6 +22,7 @@ var (
 )
 
 type HookAutoOfflineAgent func(agent AgentObject)
+type HookAgentStatus func(agent AgentObject, status string)
 
 type agentManager struct {
 	store                store.Store
@@ -31,6 +32,7 @@ type agentManager struct {
 	startOnce            sync.Once
 	agentsCache          utils.ObjectCache
 	hookAutoOfflineAgent HookAutoOfflineAgent
+	hookAgentStatus      HookAgentStatus
 	log                  *wlog.Logger
 	sync.Mutex
 }
@@ -54,6 +56,17 @@ func (am *agentManager) SetHookAutoOfflineAgent(hook HookAutoOfflineAgent) {
 	am.hookAutoOfflineAgent = hook
 }
 
+func (am *agentManager) SetHookAgentStatus(hook HookAgentStatus) {
+	am.hookAgentStatus = hook
+}
+
+func (am *agentManager) storeStatus(agent AgentObject, status model.AgentStatus) {
+	agent.StoreStatus(status)
+	if am.hookAgentStatus != nil {
+		am.hookAgentStatus(agent, status.Status)
+	}
+}
+
 func (am *agentManager) Start() {
 	am.log.Debug("starting agent service")
 	am.watcher = utils.MakeWatcher("AgentManager", watcherPollingInterval, am.changeDeadlineState)
@@ -100,7 +113,7 @@ func (am *agentManager) SetOnline(agent AgentObject, onDemand bool) (*model.Agen
 	}
 
 	agent.SetOnDemand(onDemand)
-	agent.StoreStatus(model.AgentStatus{
+	am.storeStatus(agent, model.AgentStatus{
 		Status: model.AgentStatusOnline,
 	})
 	//FIXME add pool send event
@@ -138,7 +151,7 @@ func (am *agentManager) SetOffline(agent AgentObject, sys *string) *model.AppErr
 	if err != nil {
 		return err
 	}
-	agent.StoreStatus(event.AgentStatus)
+	am.storeStatus(agent, event.AgentStatus)
 	//add channel queue
 	return am.mq.AgentChangeStatus(agent.DomainId(), agent.UserId(), NewAgentEventStatus(agent, event))
 }
@@ -162,7 +175,7 @@ func (am *agentManager) SetPause(agent AgentObject, payload *string, timeout *in
 	if err != nil {
 		return err
 	}
-	agent.StoreStatus(event.AgentStatus)
+	am.storeStatus(agent, event.AgentStatus)
 	//add channel queue
 	return am.mq.AgentChangeStatus(agent.DomainId(), agent.UserId(), NewAgentEventStatus(agent, event))
 }
@@ -185,7 +198,7 @@ func (am *agentManager) SetBreakOut(agent AgentObject) *model.AppError {
 	if err != nil {
 		return err
 	}
-	agent.StoreStatus(event.AgentStatus)
+	am.storeStatus(agent, event.AgentStatus)
 	//add channel queue
 	return am.mq.AgentChangeStatus(agent.DomainId(), agent.UserId(), NewAgentEventStatus(agent, event))
 }
@@ -19,6 +19,7 @@ type AgentManager interface {
 	SetAgentOnBreak(agentId int) *model.AppError
 	MissedAttempt(agentId int, attemptId int64, cause string) *model.AppError
 	SetHookAutoOfflineAgent(hook HookAutoOfflineAgent)
+	SetHookAgentStatus(hook HookAgentStatus)
 }
 
 type AgentObject interface {
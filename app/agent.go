@@ -200,6 +200,10 @@ func (app *App) hookAutoOfflineAgent(agent agent_manager.AgentObject) {
 	return
 }
 
+func (app *App) hookAgentStatus(agent agent_manager.AgentObject, status string) {
+	app.Queue().Manager().Wallboard().AgentStatus(agent.Id(), agent.TeamId(), status)
+}
+
 func getString(p *string) string {
 	if p == nil {
 		return ""
@@ -16,6 +16,8 @@ import (
 	"github.com/webitel/call_center/store"
 	"github.com/webitel/call_center/store/sqlstore"
 	"github.com/webitel/call_center/trigger"
+	"github.com/webitel/call_center/utils"
+	"github.com/webitel/engine/auth_manager"
 	"github.com/webitel/flow_manager/client"
 	"github.com/webitel/wlog"
 	"sync/atomic"
@@ -51,6 +53,8 @@ type App struct {
 	flowManager    client.FlowManager
 	chatManager    *chat.ChatManager
 	triggerManager *trigger.Manager
+	fileBackend    utils.FileBackend
+	authManager    auth_manager.AuthManager
 
 	ctx              context.Context
 	otelShutdownFunc otelsdk.ShutdownFunc
@@ -118,6 +122,10 @@ func New(options ...string) (outApp *App, outErr error) {
 	}
 
 	app.Store = app.newStore()
+
+	if err := app.initFileBackend(); err != nil {
+		return nil, err
+	}
 	app.MQ = mq.NewMQ(rabbit.NewRabbitMQ(app.Config().MessageQueueSettings, app.GetInstanceId(), app.Log))
 
 	if cl, err := cluster.NewCluster(*app.id, app.Config().DiscoverySettings.Url, app.Store.Cluster(), app.Log); err != nil {
@@ -136,6 +144,11 @@ func New(options ...string) (outApp *App, outErr error) {
 		return nil, err
 	}
 
+	app.authManager = auth_manager.NewAuthManager(authCacheSize, authCacheTimeSec, app.Cluster().ServiceDiscovery(), app.Log)
+	if err := app.authManager.Start(); err != nil {
+		return nil, err
+	}
+
 	app.callManager = call_manager.NewCallManager(app.GetInstanceId(), app.Cluster().ServiceDiscovery(), app.MQ, app.Log)
 	app.callManager.Start()
 
@@ -152,12 +165,13 @@ func New(options ...string) (outApp *App, outErr error) {
 		return nil, err
 	}
 
-	app.chatManager = chat.NewChatManager(app.Cluster().ServiceDiscovery(), app.MQ, app.Log)
+	app.chatManager = chat.NewChatManager(app.Cluster().ServiceDiscovery(), app.MQ, app.Store.ChatTranscript(), app.fileBackend, app.Log)
 	if err := app.chatManager.Start(); err != nil {
 		return nil, err
 	}
 
 	app.dialing = queue.NewDialing(app, app.MQ, app.callManager, app.agentManager, app.Store, app.Config().QueueSettings.BridgeSleep)
+	app.agentManager.SetHookAgentStatus(app.hookAgentStatus)
 	app.dialing.Start()
 
 	app.triggerManager = trigger.NewManager(*app.id, app.Store, app.flowManager, app.Log)
@@ -224,6 +238,10 @@ func (app *App) Shutdown() {
 		app.triggerManager.Stop()
 	}
 
+	if app.authManager != nil {
+		app.authManager.Stop()
+	}
+
 	if app.MQ != nil {
 		app.MQ.Close()
 	}
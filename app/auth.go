@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authCacheSize    = 10000
+	authCacheTimeSec = 60
+
+	accessTokenHeader = "x-webitel-access"
+)
+
+// GetSessionFromCtx the session of the access token of the grpc request
+func (a *App) GetSessionFromCtx(ctx context.Context) (*auth_manager.Session, *model.AppError) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(accessTokenHeader); len(v) > 0 {
+			token = v[0]
+		}
+	}
+
+	if token == "" {
+		return nil, model.NewAppError("App.GetSessionFromCtx", "app.auth.token.required", nil,
+			"access token is required", http.StatusUnauthorized)
+	}
+
+	session, err := a.authManager.GetSession(ctx, token)
+	if err != nil {
+		return nil, model.NewAppError("App.GetSessionFromCtx", "app.auth.session.app_error", nil,
+			err.Error(), http.StatusUnauthorized)
+	}
+
+	return &session, nil
+}
+
+// AuthorizeDomain the domain of the session with the access to the scope, domainId of the request (if set)
+// must be the domain of the session
+func (a *App) AuthorizeDomain(ctx context.Context, domainId int64, scope string, access auth_manager.PermissionAccess) (*auth_manager.Session, *model.AppError) {
+	session, err := a.GetSessionFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSessionAccess(session, domainId, scope, access); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// CheckAccess the access of the authorized session to the scope
+func (a *App) CheckAccess(session *auth_manager.Session, scope string, access auth_manager.PermissionAccess) *model.AppError {
+	return checkSessionAccess(session, 0, scope, access)
+}
+
+func checkSessionAccess(session *auth_manager.Session, domainId int64, scope string, access auth_manager.PermissionAccess) *model.AppError {
+	if session.IsExpired() {
+		return model.NewAppError("App.AuthorizeDomain", "app.auth.session.expired", nil,
+			"session is expired", http.StatusUnauthorized)
+	}
+
+	if domainId != 0 && domainId != session.GetDomainId() {
+		return model.NewAppError("App.AuthorizeDomain", "app.auth.domain.forbidden", nil,
+			fmt.Sprintf("domain_id=%d is not the domain of the session", domainId), http.StatusForbidden)
+	}
+
+	if scope == "" {
+		return nil
+	}
+
+	perm := session.GetPermission(scope)
+	var ok bool
+	switch access {
+	case auth_manager.PERMISSION_ACCESS_CREATE:
+		ok = perm.CanCreate()
+	case auth_manager.PERMISSION_ACCESS_UPDATE:
+		ok = perm.CanUpdate()
+	case auth_manager.PERMISSION_ACCESS_DELETE:
+		ok = perm.CanDelete()
+	default:
+		ok = perm.CanRead()
+	}
+
+	if !ok {
+		return model.NewAppError("App.AuthorizeDomain", "app.auth.permission.forbidden", nil,
+			fmt.Sprintf("%s access to %s is denied", access.Name(), scope), http.StatusForbidden)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+)
+
+func TestCheckSessionAccess(t *testing.T) {
+	t.Log("CheckSessionAccess")
+
+	session := &auth_manager.Session{
+		DomainId: 1,
+		Expire:   time.Now().Add(time.Hour).Unix(),
+		Scopes: []auth_manager.SessionPermission{
+			{Name: model.PermissionScopeQueue, Obac: true, Access: auth_manager.PERMISSION_ACCESS_READ.Value()},
+		},
+	}
+
+	cases := []struct {
+		domainId int64
+		scope    string
+		access   auth_manager.PermissionAccess
+		status   int
+	}{
+		{0, "", auth_manager.PERMISSION_ACCESS_READ, 0},
+		{1, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ, 0},
+		{2, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ, http.StatusForbidden},
+		{1, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE, http.StatusForbidden},
+		{1, model.PermissionScopeTeam, auth_manager.PERMISSION_ACCESS_READ, http.StatusForbidden},
+	}
+
+	for i, c := range cases {
+		err := checkSessionAccess(session, c.domainId, c.scope, c.access)
+		if c.status == 0 && err != nil {
+			t.Errorf("case %d: unexpected error %s", i, err.Error())
+		} else if c.status != 0 && (err == nil || err.StatusCode != c.status) {
+			t.Errorf("case %d: expected status %d, got %v", i, c.status, err)
+		}
+	}
+
+	session.Expire = time.Now().Add(-time.Minute).Unix()
+	if err := checkSessionAccess(session, 1, "", auth_manager.PERMISSION_ACCESS_READ); err == nil || err.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expired session: got %v", err)
+	}
+}
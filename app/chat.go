@@ -1,8 +1,10 @@
 package app
 
 import (
+	"context"
 	"github.com/webitel/call_center/chat"
 	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
 )
 
 func (app *App) ChatManager() *chat.ChatManager {
@@ -12,3 +14,59 @@ func (app *App) ChatManager() *chat.ChatManager {
 func (app *App) GetChat(conversationId string) (*chat.Conversation, *model.AppError) {
 	return app.chatManager.GetConversation(conversationId)
 }
+
+func (app *App) ChatTranscript(domainId int64, attemptId int64) (*model.ChatTranscript, *model.AppError) {
+	return app.chatManager.Transcript(domainId, attemptId)
+}
+
+// conferenceChat the conversation of the domain of the session, the caller is the agent of the conversation
+// or has the update access to the queues
+func (app *App) conferenceChat(session *auth_manager.Session, conversationId string) (*chat.Conversation, *model.AppError) {
+	conv, err := app.chatManager.GetConversation(conversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if conv.DomainId != session.GetDomainId() {
+		return nil, chat.ErrNotFound
+	}
+
+	if agent := conv.AgentSession(); agent != nil && agent.GetRole() == chat.ChatRoleAgent && agent.StopAt() == 0 &&
+		agent.UserId == session.GetUserId() {
+		return conv, nil
+	}
+
+	if err = app.CheckAccess(session, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE); err != nil {
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+func (app *App) ChatInviteParticipant(ctx context.Context, session *auth_manager.Session, conversationId string, fromUserId, userId int64,
+	role chat.ChatSessionRole, timeout uint16, title string) *model.AppError {
+	conv, err := app.conferenceChat(session, conversationId)
+	if err != nil {
+		return err
+	}
+
+	return conv.InviteParticipant(ctx, fromUserId, userId, role, timeout, title)
+}
+
+func (app *App) ChatHandover(session *auth_manager.Session, conversationId string, fromUserId, userId int64) *model.AppError {
+	conv, err := app.conferenceChat(session, conversationId)
+	if err != nil {
+		return err
+	}
+
+	return conv.Handover(fromUserId, userId)
+}
+
+func (app *App) ChatLeaveParticipant(session *auth_manager.Session, conversationId string, userId int64) *model.AppError {
+	conv, err := app.conferenceChat(session, conversationId)
+	if err != nil {
+		return err
+	}
+
+	return conv.LeaveParticipant(userId)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/utils"
+	"io"
+	"net/http"
+)
+
+func (app *App) initFileBackend() *model.AppError {
+	settings := app.Config().FileSettings
+	if settings.Driver == "" {
+		return nil
+	}
+
+	b, err := utils.NewFileBackend(&settings)
+	if err != nil {
+		return err
+	}
+
+	if err = b.TestConnection(); err != nil {
+		return err
+	}
+
+	app.fileBackend = b
+	app.Log.Info("file storage: " + settings.Driver)
+
+	return nil
+}
+
+func (app *App) FileBackend() utils.FileBackend {
+	return app.fileBackend
+}
+
+func (app *App) FileSettings() model.FileSettings {
+	return app.Config().FileSettings
+}
+
+// StoreFile writes the file to the configured storage and returns the key of the file in the storage
+func (app *App) StoreFile(path string, data []byte) (string, *model.AppError) {
+	return app.StoreFileReader(path, bytes.NewReader(data))
+}
+
+// StoreFileReader writes the file from the reader to the configured storage and returns the key of the file in the storage,
+// the key is relative to the root of the storage, so the server path or the bucket is not exposed
+func (app *App) StoreFileReader(path string, src io.Reader) (string, *model.AppError) {
+	if app.fileBackend == nil {
+		return "", model.NewAppError("StoreFile", "app.file.no_driver.app_error", nil, "file storage is not configured",
+			http.StatusNotImplemented)
+	}
+
+	if _, err := app.fileBackend.WriteFile(src, path); err != nil {
+		return "", err
+	}
+
+	return utils.FileKey(path), nil
+}
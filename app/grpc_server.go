@@ -6,6 +6,7 @@ import (
 	"github.com/webitel/call_center/model"
 	"github.com/webitel/engine/utils"
 	"github.com/webitel/wlog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -50,8 +51,7 @@ func GetUnaryInterceptor(log *wlog.Logger) grpc.UnaryServerInterceptor {
 
 			switch err.(type) {
 			case *model.AppError:
-				e := err.(*model.AppError)
-				return h, status.Error(httpCodeToGrpc(e.StatusCode), e.ToJson())
+				return h, appErrorToGrpc(err.(*model.AppError))
 			default:
 				return h, err
 			}
@@ -66,6 +66,55 @@ func GetUnaryInterceptor(log *wlog.Logger) grpc.UnaryServerInterceptor {
 	}
 }
 
+func GetStreamInterceptor(log *wlog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		since := time.Since(start)
+
+		if err != nil {
+			log.Error(fmt.Sprintf("stream %s duration %s, error: %v", info.FullMethod, since, err.Error()),
+				wlog.Err(err),
+				wlog.Duration("duration", since),
+				wlog.String("method", info.FullMethod),
+			)
+
+			switch err.(type) {
+			case *model.AppError:
+				return appErrorToGrpc(err.(*model.AppError))
+			default:
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// appErrorToGrpc the errors of the fields are sent as the field violations of the bad request
+func appErrorToGrpc(e *model.AppError) error {
+	st := status.New(httpCodeToGrpc(e.StatusCode), e.ToJson())
+	if len(e.Fields) == 0 {
+		return st.Err()
+	}
+
+	br := &errdetails.BadRequest{}
+	for _, v := range e.Fields {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Code + ": " + v.Message,
+		})
+	}
+
+	if d, err := st.WithDetails(br); err == nil {
+		st = d
+	}
+
+	return st.Err()
+}
+
 func httpCodeToGrpc(c int) codes.Code {
 	switch c {
 	case http.StatusBadRequest:
@@ -98,6 +147,7 @@ func NewGrpcServer(settings model.ServerSettings, log *wlog.Logger) *GrpcServer
 		lis: lis,
 		srv: grpc.NewServer(
 			grpc.UnaryInterceptor(GetUnaryInterceptor(grpcLog)),
+			grpc.StreamInterceptor(GetStreamInterceptor(grpcLog)),
 		),
 		log: grpcLog,
 	}
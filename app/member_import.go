@@ -0,0 +1,323 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+type memberImport struct {
+	app  *App
+	req  *model.MemberImportRequest
+	res  *model.MemberImportResult
+	seen map[string]struct{}
+}
+
+// ImportMembers reads the rows of the file and inserts the members to the outbound queue by the batches,
+// the file is the src or the Location of the request in the file storage, it's read by the rows and never kept in the memory
+func (a *App) ImportMembers(ctx context.Context, req *model.MemberImportRequest, src io.Reader) (*model.MemberImportResult, *model.AppError) {
+	if err := req.IsValid(); err != nil {
+		return nil, err
+	}
+
+	if err := a.importQueue(req.DomainId, req.QueueId); err != nil {
+		return nil, err
+	}
+
+	if src == nil {
+		if req.Location == "" {
+			return nil, model.NewAppError("ImportMembers", "model.member_import.is_valid.content", nil,
+				"content or location is required", http.StatusBadRequest)
+		}
+		if a.fileBackend == nil {
+			return nil, model.NewAppError("ImportMembers", "app.file.no_driver.app_error", nil, "file storage is not configured",
+				http.StatusNotImplemented)
+		}
+		f, err := a.fileBackend.Reader(req.Location)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		src = f
+	}
+
+	reader, err := model.NewMemberImportReader(src, req)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &memberImport{
+		app:  a,
+		req:  req,
+		res:  &model.MemberImportResult{},
+		seen: make(map[string]struct{}),
+	}
+
+	batch := make([]*model.MemberImportRow, 0, model.MemberImportBatchSize)
+	for {
+		row, errs, readErr := reader.Next()
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return nil, model.NewAppError("ImportMembers", "app.member_import.read.app_error", nil,
+				readErr.Error(), http.StatusBadRequest)
+		}
+
+		imp.res.Total++
+		for _, e := range errs {
+			imp.res.AddError(e.Line, e.Field, e.Code, e.Message)
+		}
+		if row == nil {
+			imp.res.Rejected++
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == model.MemberImportBatchSize {
+			if err = imp.flush(batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+
+			if ctx.Err() != nil {
+				return nil, model.NewAppError("ImportMembers", "app.member_import.canceled", nil,
+					ctx.Err().Error(), http.StatusRequestTimeout)
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err = imp.flush(batch); err != nil {
+			return nil, err
+		}
+	}
+
+	a.Log.Info(fmt.Sprintf("import members to queue %d: total %d, inserted %d, duplicates %d, rejected %d", req.QueueId,
+		imp.res.Total, imp.res.Inserted, imp.res.Duplicates, imp.res.Rejected),
+		wlog.Int("queue_id", req.QueueId),
+		wlog.Int64("domain_id", req.DomainId),
+	)
+
+	return imp.res, nil
+}
+
+// ExportMembers writes the members of the queue with the results in the format of the import to the dst by the batches
+func (a *App) ExportMembers(ctx context.Context, req *model.MemberExportRequest, dst io.Writer) (*model.MemberExportResponse, *model.AppError) {
+	if err := a.importQueue(req.DomainId, req.QueueId); err != nil {
+		return nil, err
+	}
+	if req.Format == "" {
+		req.Format = model.MemberImportFormatCsv
+	}
+
+	w, contentType, err := model.NewMemberExportWriter(dst, req.Format, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.MemberExportResponse{
+		QueueId:     req.QueueId,
+		Format:      req.Format,
+		ContentType: contentType,
+	}
+
+	var afterId int64
+	for {
+		list, err := a.Store.Member().Export(ctx, req.DomainId, req.QueueId, afterId, model.MemberExportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range list {
+			if wErr := w.Write(m); wErr != nil {
+				return nil, model.NewAppError("ExportMembers", "app.member_export.write.app_error", nil,
+					wErr.Error(), http.StatusInternalServerError)
+			}
+			afterId = m.Id
+		}
+		res.Count += len(list)
+
+		if len(list) < model.MemberExportBatchSize {
+			break
+		}
+	}
+
+	if wErr := w.Flush(); wErr != nil {
+		return nil, model.NewAppError("ExportMembers", "app.member_export.write.app_error", nil,
+			wErr.Error(), http.StatusInternalServerError)
+	}
+
+	return res, nil
+}
+
+// StoreMembersExport writes the export to the file storage while the members are read
+func (a *App) StoreMembersExport(ctx context.Context, req *model.MemberExportRequest, path string) (*model.MemberExportResponse, *model.AppError) {
+	if a.fileBackend == nil {
+		return nil, model.NewAppError("StoreMembersExport", "app.file.no_driver.app_error", nil, "file storage is not configured",
+			http.StatusNotImplemented)
+	}
+
+	pr, pw := io.Pipe()
+	var res *model.MemberExportResponse
+	exported := make(chan *model.AppError, 1)
+	go func() {
+		var err *model.AppError
+		if res, err = a.ExportMembers(ctx, req, pw); err != nil {
+			pw.CloseWithError(err)
+		} else {
+			pw.Close()
+		}
+		exported <- err
+	}()
+
+	location, err := a.StoreFileReader(path, pr)
+	// the export is stopped when the storage failed before the end
+	pr.Close()
+	if exportErr := <-exported; exportErr != nil {
+		return nil, exportErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Location = location
+
+	return res, nil
+}
+
+// importQueue the members are imported to the outbound queues of the domain only
+func (a *App) importQueue(domainId int64, queueId int) *model.AppError {
+	q, err := a.Store.Queue().GetById(int64(queueId))
+	if err != nil {
+		return err
+	}
+
+	if q.DomainId != domainId {
+		return model.NewAppError("ImportMembers", "app.member_import.queue.not_found", nil,
+			fmt.Sprintf("queue_id=%d not found", queueId), http.StatusNotFound)
+	}
+
+	switch q.Type {
+	case model.QueueTypeInboundCall, model.QueueTypeInboundChat:
+		return model.NewAppError("ImportMembers", "app.member_import.queue.type", nil,
+			fmt.Sprintf("queue_id=%d is not an outbound queue", queueId), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// flush removes the dnc communications and the duplicates of the batch and inserts the members,
+// the destinations of the inserted members are the duplicates of the next rows of the file
+func (imp *memberImport) flush(batch []*model.MemberImportRow) *model.AppError {
+	var destinations, dncDestinations, dncNumbers []string
+	country := strings.TrimPrefix(imp.req.DefaultCountry, "+")
+	for _, row := range batch {
+		for _, c := range row.Communications {
+			destinations = append(destinations, c.Destination)
+
+			// the list may keep the numbers in the national format of the default country
+			n := model.NormalizeNumber(c.Destination)
+			dncDestinations = append(dncDestinations, c.Destination)
+			dncNumbers = append(dncNumbers, n)
+			if country != "" && strings.HasPrefix(n, country) {
+				dncDestinations = append(dncDestinations, c.Destination)
+				dncNumbers = append(dncNumbers, strings.TrimLeft(n[len(country):], "0"))
+			}
+		}
+	}
+
+	dnc, err := imp.app.Store.Member().ImportDnc(imp.req.QueueId, dncDestinations, dncNumbers)
+	if err != nil {
+		return err
+	}
+	banned := toSet(dnc)
+
+	existing := map[string]struct{}{}
+	if imp.req.Dedup {
+		var list []string
+		if list, err = imp.app.Store.Member().ImportExisting(imp.req.DomainId, imp.req.QueueId, destinations); err != nil {
+			return err
+		}
+		existing = toSet(list)
+	}
+
+	rows := make([]*model.MemberImportRow, 0, len(batch))
+	inBatch := make(map[string]struct{})
+	for _, row := range batch {
+		comm := row.Communications[:0]
+		for _, c := range row.Communications {
+			if _, ok := banned[c.Destination]; ok {
+				imp.res.AddError(row.Line, "", model.MemberImportErrorDnc, fmt.Sprintf("number %s is in the dnc list", c.Destination))
+				continue
+			}
+			comm = append(comm, c)
+		}
+		row.Communications = comm
+
+		if len(row.Communications) == 0 {
+			imp.res.Rejected++
+			imp.res.AddError(row.Line, "", model.MemberImportErrorNoCommunications, "no valid communications")
+			continue
+		}
+
+		if imp.req.Dedup {
+			if imp.duplicate(row, existing, inBatch) {
+				imp.res.Duplicates++
+				continue
+			}
+			for _, c := range row.Communications {
+				inBatch[c.Destination] = struct{}{}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	cnt, err := imp.app.Store.Member().Import(imp.req.DomainId, imp.req.QueueId, rows)
+	if err != nil {
+		imp.res.Rejected += len(rows)
+		for _, row := range rows {
+			imp.res.AddError(row.Line, "", model.MemberImportErrorStore, err.Error())
+		}
+		return nil
+	}
+	imp.res.Inserted += int(cnt)
+
+	for d := range inBatch {
+		imp.seen[d] = struct{}{}
+	}
+
+	return nil
+}
+
+// duplicate the destination of the row is in the queue, in the inserted rows of the file or in the previous rows of the batch
+func (imp *memberImport) duplicate(row *model.MemberImportRow, existing, inBatch map[string]struct{}) bool {
+	for _, c := range row.Communications {
+		_, inQueue := existing[c.Destination]
+		_, inFile := imp.seen[c.Destination]
+		_, inRows := inBatch[c.Destination]
+		if inQueue || inFile || inRows {
+			imp.res.AddError(row.Line, "", model.MemberImportErrorDuplicate, fmt.Sprintf("number %s already exists", c.Destination))
+			return true
+		}
+	}
+
+	return false
+}
+
+func toSet(list []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(list))
+	for _, v := range list {
+		res[v] = struct{}{}
+	}
+
+	return res
+}
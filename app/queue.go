@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"github.com/webitel/call_center/model"
 	"golang.org/x/sync/singleflight"
@@ -110,3 +111,89 @@ func (a *App) NotificationWaitingList(e *model.MemberWaitingByUsers) *model.AppE
 		},
 	})
 }
+
+func (a *App) NotificationChatAlert(alert *model.ChatAlert) *model.AppError {
+	ids, err := a.Store.Queue().SupervisorIds(alert.QueueId)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return a.MQ.SendNotification(alert.DomainId, &model.Notification{
+		Id:        0,
+		DomainId:  alert.DomainId,
+		Action:    model.NotificationChatAlert,
+		CreatedAt: model.GetMillis(),
+		ForUsers:  ids,
+		Body:      alert,
+	})
+}
+
+func (a *App) SearchAttemptJournal(search *model.SearchAttemptJournal) ([]*model.AttemptJournal, *model.AppError) {
+	return a.Store.Member().SearchJournal(search)
+}
+
+func (a *App) ResourceHealth(domainId int64) ([]model.ResourceHealth, []model.GatewayHealth) {
+	h := a.Queue().Manager().ResourceHealth()
+	return h.Resources(domainId), h.Gateways(domainId)
+}
+
+func (a *App) ResetResourceHealth(domainId int64, resourceId int) *model.AppError {
+	ok, err := a.Queue().Manager().ResourceHealth().Reset(domainId, resourceId)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return model.NewAppError("App.ResetResourceHealth", "app.resource_health.reset.not_found", nil,
+			fmt.Sprintf("resource %d not found", resourceId), http.StatusNotFound)
+	}
+
+	return nil
+}
+
+func (a *App) AmdReport(search *model.SearchAmdReport) ([]*model.AmdReport, *model.AppError) {
+	return a.Store.Amd().Report(search)
+}
+
+func (a *App) CorrectAmd(correction *model.AmdCorrection) *model.AppError {
+	return a.Queue().Manager().CorrectAmd(correction)
+}
+
+func (a *App) LeadScore(search *model.SearchLeadScore) (*model.LeadScore, *model.AppError) {
+	return a.Store.Lead().Score(search.DomainId, search.MemberId)
+}
+
+func (a *App) DropVoicemail(req *model.VoicemailDropRequest) *model.AppError {
+	return a.Queue().Manager().DropVoicemail(req)
+}
+
+func (a *App) ManualAttempts(ctx context.Context, search *model.SearchManualAttempt) ([]*model.ManualAttempt, *model.AppError) {
+	return a.Queue().Manager().ManualAttempts(ctx, search)
+}
+
+func (a *App) ManualReserve(ctx context.Context, r *model.ManualReservation) (*model.ManualReserved, *model.AppError) {
+	return a.Queue().Manager().ManualReserve(ctx, r)
+}
+
+func (a *App) ManualRelease(ctx context.Context, r *model.ManualReservation) *model.AppError {
+	return a.Queue().Manager().ManualRelease(ctx, r)
+}
+
+// QueueEstimate the expected wait of the caller before the join to the inbound queue of the domain
+func (a *App) QueueEstimate(domainId int64, queueId int, priority int) (*model.QueueEstimate, *model.AppError) {
+	q, err := a.GetQueueById(int64(queueId))
+	if err != nil {
+		return nil, err
+	}
+
+	if q.DomainId != domainId {
+		return nil, model.NewAppError("App.QueueEstimate", "app.queue.estimate.not_found", nil,
+			fmt.Sprintf("queue_id=%d not found", queueId), http.StatusNotFound)
+	}
+
+	return a.Queue().Manager().EstimateJoin(queueId, priority)
+}
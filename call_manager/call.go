@@ -23,6 +23,7 @@ type Call interface {
 
 	Invite() *model.AppError
 	State() <-chan CallState
+	Dtmf() <-chan rune
 
 	HangupCause() string
 	HangupCauseCode() int
@@ -35,6 +36,8 @@ type Call interface {
 	BridgeId() *string
 	Answered() bool
 
+	InviteAt() int64
+	RingingAt() int64
 	AcceptAt() int64
 	BridgeAt() int64
 	HangupAt() int64
@@ -55,6 +58,8 @@ type Call interface {
 	AmdResult() string
 	HasAmdError() bool
 	IsHuman() bool
+	AmdCause() string
+	AmdAt() int64
 
 	WaitForHangup()
 	HangupChan() <-chan struct{}
@@ -66,6 +71,7 @@ type Call interface {
 	DTMF(val rune) *model.AppError
 	Bridge(other Call) *model.AppError
 	BroadcastPlaybackFile(domainId int64, file *model.RingtoneFile, leg string) *model.AppError
+	BroadcastPlaybackTts(domainId int64, tts *model.TtsSettings, text string, leg string) *model.AppError
 	ParkPlaybackFile(domainId int64, file *model.RingtoneFile, leg string) *model.AppError
 	BroadcastTone(tone *string, leg string) *model.AppError
 	BroadcastPlaybackSilenceBeforeFile(domainId int64, silence uint, file *model.RingtoneFile, leg string) *model.AppError
@@ -101,6 +107,7 @@ type CallImpl struct {
 	cancel      string
 
 	chState chan CallState
+	chDtmf  chan rune
 
 	info   model.CallActionInfo
 	hangup *model.CallActionHangup
@@ -108,6 +115,7 @@ type CallImpl struct {
 
 	bridgedId *string
 
+	inviteAt    int64
 	ringingAt   int64
 	acceptAt    int64
 	bridgeAt    int64
@@ -124,6 +132,7 @@ type CallImpl struct {
 
 	amdResult string
 	amdCause  string
+	amdAt     int64
 
 	amdAiResult model.AmdAiResult
 
@@ -195,6 +204,7 @@ func NewCall(direction CallDirection, callRequest *model.CallRequest, cm *CallMa
 		cm:          cm,
 		hangupCh:    make(chan struct{}),
 		chState:     make(chan CallState, 5), // FIXME
+		chDtmf:      make(chan rune, 10),
 		state:       CALL_STATE_NEW,
 		log: cm.log.With(
 			wlog.String("call_id", id),
@@ -275,6 +285,10 @@ func (call *CallImpl) setAmd(e *model.CallActionAMD) {
 	call.Lock()
 	call.amdResult = e.Result
 	call.amdCause = e.Cause
+	call.amdAt = e.Timestamp
+	if call.amdAt == 0 {
+		call.amdAt = model.GetMillis()
+	}
 
 	call.amdAiResult = e.AmdAiResult
 
@@ -283,6 +297,24 @@ func (call *CallImpl) setAmd(e *model.CallActionAMD) {
 	call.setState(CALL_STATE_DETECT_AMD)
 }
 
+// setDtmf not blocking, digits without reader are dropped
+func (call *CallImpl) setDtmf(e *model.CallActionDtmf) {
+	d := e.Rune()
+	if d == 0 {
+		return
+	}
+
+	select {
+	case call.chDtmf <- d:
+	default:
+		call.log.Debug(fmt.Sprintf("[%s] call %s skip dtmf \"%s\"", call.NodeName(), call.Id(), e.Digit))
+	}
+}
+
+func (call *CallImpl) Dtmf() <-chan rune {
+	return call.chDtmf
+}
+
 func (call *CallImpl) AiResult() model.AmdAiResult {
 	call.RLock()
 	res := call.amdAiResult
@@ -295,6 +327,18 @@ func (call *CallImpl) StopPlayback() *model.AppError {
 	return call.api.StopPlayback(call.id)
 }
 
+func (call *CallImpl) AmdCause() string {
+	call.RLock()
+	defer call.RUnlock()
+	return call.amdCause
+}
+
+func (call *CallImpl) AmdAt() int64 {
+	call.RLock()
+	defer call.RUnlock()
+	return call.amdAt
+}
+
 func (call *CallImpl) AmdResult() string {
 	call.RLock()
 	defer call.RUnlock()
@@ -374,6 +418,7 @@ func (call *CallImpl) Invite() *model.AppError {
 	}
 
 	call.state = CALL_STATE_INVITE
+	call.inviteAt = model.GetMillis()
 
 	call.log.Debug(fmt.Sprintf("[%s] call %s send invite", call.NodeName(), call.Id()))
 
@@ -523,6 +568,17 @@ func (call *CallImpl) TransferToAttemptId() *int64 {
 	return call.transferToAttemptId
 }
 
+func (call *CallImpl) InviteAt() int64 {
+	return call.inviteAt
+}
+
+func (call *CallImpl) RingingAt() int64 {
+	call.RLock()
+	defer call.RUnlock()
+
+	return call.ringingAt
+}
+
 func (call *CallImpl) AcceptAt() int64 {
 	return call.acceptAt
 }
@@ -711,6 +767,14 @@ func (call *CallImpl) BroadcastPlaybackFile(domainId int64, file *model.Ringtone
 	return call.api.BroadcastPlaybackFile(call.id, model.RingtoneUri(domainId, file.Id, file.Type), leg)
 }
 
+func (call *CallImpl) BroadcastPlaybackTts(domainId int64, tts *model.TtsSettings, text string, leg string) *model.AppError {
+	if tts == nil || text == "" {
+
+		return nil
+	}
+	return call.api.BroadcastPlaybackFile(call.id, model.TtsUri(domainId, tts, text), leg)
+}
+
 func (call *CallImpl) ParkPlaybackFile(domainId int64, file *model.RingtoneFile, leg string) *model.AppError {
 	if file == nil {
 
@@ -52,6 +52,12 @@ func (cm *CallManagerImpl) handleCallAction(data model.CallActionData) {
 		}
 		call.setAmd(action.(*model.CallActionAMD))
 
+	case *model.CallActionDtmf:
+		if call == nil {
+			return
+		}
+		call.setDtmf(action.(*model.CallActionDtmf))
+
 	default:
 		cm.log.Warn(fmt.Sprintf("call %s not have handler action %s", data.Id, data.Event))
 	}
@@ -191,6 +191,7 @@ func (cm *CallManagerImpl) InboundCallQueue(call *model.Call, ringtone string, v
 		cm:          cm,
 		hangupCh:    make(chan struct{}),
 		chState:     make(chan CallState, 5),
+		chDtmf:      make(chan rune, 10),
 		acceptAt:    call.AnsweredAt,
 		ringingAt:   call.CreatedAt,
 		state:       CALL_STATE_ACCEPT, //FIXME
@@ -264,6 +265,7 @@ func (cm *CallManagerImpl) ConnectCall(call *model.Call, ringtone string) (Call,
 		cm:          cm,
 		hangupCh:    make(chan struct{}),
 		chState:     make(chan CallState, 5),
+		chDtmf:      make(chan rune, 10),
 		acceptAt:    call.AnsweredAt,
 		ringingAt:   call.CreatedAt,
 		state:       CALL_STATE_ACCEPT, //FIXME
@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	AnalyzerLexicon = "lexicon"
+
+	defaultTrendMessages = 3
+	defaultTrendScore    = -0.3
+	negativeTrendRule    = "negative_trend"
+)
+
+// SentimentAnalyzer scores the text from -1 (negative) to 1 (positive)
+type SentimentAnalyzer interface {
+	Name() string
+	Score(text string) float64
+}
+
+var (
+	analyzers   = map[string]SentimentAnalyzer{}
+	analyzersMx sync.RWMutex
+)
+
+func init() {
+	RegisterAnalyzer(NewLexiconAnalyzer(nil, nil))
+}
+
+func RegisterAnalyzer(a SentimentAnalyzer) {
+	analyzersMx.Lock()
+	analyzers[a.Name()] = a
+	analyzersMx.Unlock()
+}
+
+func getAnalyzer(name string) (SentimentAnalyzer, bool) {
+	if name == "" {
+		name = AnalyzerLexicon
+	}
+
+	analyzersMx.RLock()
+	a, ok := analyzers[name]
+	analyzersMx.RUnlock()
+
+	return a, ok
+}
+
+type alertRule struct {
+	model.ChatAlertRule
+	re *regexp.Regexp
+}
+
+// AlertRules compiled alert settings of the queue
+type AlertRules struct {
+	analyzer SentimentAnalyzer
+	rules    []alertRule
+	trend    model.ChatAlertTrend
+	useTrend bool
+}
+
+// Monitor keeps the sentiment of one conversation, every rule fires once
+type Monitor struct {
+	rules  *AlertRules
+	scores []float64
+	sum    float64
+	count  int
+	last   float64
+	fired  map[string]struct{}
+	sync.Mutex
+}
+
+func NewAlertRules(settings *model.ChatAlertSettings) (*AlertRules, *model.AppError) {
+	a, ok := getAnalyzer(settings.Analyzer)
+	if !ok {
+		return nil, model.NewAppError("Chat.NewAlertRules", "chat.alert.valid.analyzer", nil,
+			fmt.Sprintf("unknown analyzer \"%s\"", settings.Analyzer), http.StatusBadRequest)
+	}
+
+	if l := settings.Lexicon; l != nil && (settings.Analyzer == "" || settings.Analyzer == AnalyzerLexicon) {
+		a = newLexiconAnalyzer(l.Positive, l.Negative, l.Negators)
+	}
+
+	res := &AlertRules{
+		analyzer: a,
+	}
+
+	for _, r := range settings.Rules {
+		if r.Name == "" {
+			return nil, model.NewAppError("Chat.NewAlertRules", "chat.alert.valid.name", nil, "rule name is required", http.StatusBadRequest)
+		}
+
+		rule := alertRule{ChatAlertRule: r}
+		pattern := r.Pattern
+		if len(r.Keywords) != 0 {
+			words := make([]string, 0, len(r.Keywords))
+			for _, k := range r.Keywords {
+				words = append(words, regexp.QuoteMeta(strings.ToLower(k)))
+			}
+			if pattern != "" {
+				pattern += "|"
+			}
+			// \b matches only the ascii words
+			pattern += `(?:^|[^\p{L}\p{N}_])(` + strings.Join(words, "|") + `)(?:$|[^\p{L}\p{N}_])`
+		}
+
+		if pattern != "" {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, model.NewAppError("Chat.NewAlertRules", "chat.alert.valid.pattern", nil,
+					fmt.Sprintf("rule \"%s\": %s", r.Name, err.Error()), http.StatusBadRequest)
+			}
+			rule.re = re
+		} else if r.SentimentBelow == nil {
+			return nil, model.NewAppError("Chat.NewAlertRules", "chat.alert.valid.rule", nil,
+				fmt.Sprintf("rule \"%s\" has no condition", r.Name), http.StatusBadRequest)
+		}
+
+		res.rules = append(res.rules, rule)
+	}
+
+	if settings.NegativeTrend != nil {
+		res.useTrend = true
+		res.trend = *settings.NegativeTrend
+		if res.trend.Messages == 0 {
+			res.trend.Messages = defaultTrendMessages
+		}
+		if res.trend.Threshold == 0 {
+			res.trend.Threshold = defaultTrendScore
+		}
+	}
+
+	return res, nil
+}
+
+func (r *AlertRules) NewMonitor() *Monitor {
+	return &Monitor{
+		rules: r,
+		fired: make(map[string]struct{}),
+	}
+}
+
+// Analyze scores the message and returns names of the fired rules
+func (m *Monitor) Analyze(sender, text string) (float64, []string) {
+	score := m.rules.analyzer.Score(text)
+	var res []string
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.last = score
+	if sender == model.ChatTranscriptSenderClient {
+		m.sum += score
+		m.count++
+		m.scores = append(m.scores, score)
+		if len(m.scores) > m.rules.trend.Messages {
+			m.scores = m.scores[1:]
+		}
+	}
+
+	for _, r := range m.rules.rules {
+		if r.Sender != "" && r.Sender != sender {
+			continue
+		}
+		if r.re != nil && !r.re.MatchString(text) {
+			continue
+		}
+		if r.SentimentBelow != nil && score > *r.SentimentBelow {
+			continue
+		}
+		if m.fire(r.Name) {
+			res = append(res, r.Name)
+		}
+	}
+
+	if m.rules.useTrend && len(m.scores) >= m.rules.trend.Messages && average(m.scores) <= m.rules.trend.Threshold {
+		if m.fire(negativeTrendRule) {
+			res = append(res, negativeTrendRule)
+		}
+	}
+
+	return score, res
+}
+
+// Variables result of the analysis for the attempt
+func (m *Monitor) Variables() map[string]string {
+	m.Lock()
+	defer m.Unlock()
+
+	vars := map[string]string{
+		model.ChatAlertSentimentLastVariable: fmt.Sprintf("%.2f", m.last),
+	}
+	if m.count > 0 {
+		vars[model.ChatAlertSentimentScoreVariable] = fmt.Sprintf("%.2f", m.sum/float64(m.count))
+	}
+	if len(m.fired) != 0 {
+		names := make([]string, 0, len(m.fired))
+		for k := range m.fired {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		vars[model.ChatAlertRulesVariable] = strings.Join(names, ",")
+	}
+
+	return vars
+}
+
+func (m *Monitor) fire(name string) bool {
+	if _, ok := m.fired[name]; ok {
+		return false
+	}
+	m.fired[name] = struct{}{}
+	return true
+}
+
+func average(v []float64) float64 {
+	var sum float64
+	for _, i := range v {
+		sum += i
+	}
+
+	return sum / float64(len(v))
+}
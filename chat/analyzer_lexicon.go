@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"strings"
+	"unicode"
+)
+
+// the default words are english, ukrainian and russian
+var (
+	lexiconPositive = []string{
+		"good", "great", "excellent", "perfect", "thanks", "thank", "love", "happy", "awesome", "nice", "helpful",
+		"resolved", "fine", "glad", "amazing", "appreciate", "wonderful", "pleased", "cool", "ok",
+		"дякую", "добре", "чудово", "відмінно", "супер", "допомогли", "вирішено", "радий", "рада",
+		"спасибо", "спасибі", "хорошо", "отлично", "прекрасно", "помогли", "решено", "рад",
+	}
+	lexiconNegative = []string{
+		"bad", "terrible", "awful", "horrible", "worst", "hate", "angry", "annoyed", "useless", "broken", "problem",
+		"complaint", "cancel", "refund", "disappointed", "frustrated", "unacceptable", "ridiculous", "never", "slow",
+		"wrong", "fail", "failed", "scam", "lawyer", "stupid", "rude",
+		"погано", "жахливо", "жах", "проблема", "скарга", "повернення", "скасувати", "обман", "шахраї", "ненавиджу",
+		"плохо", "ужасно", "ужас", "жалоба", "возврат", "отменить", "мошенники", "ненавижу", "медленно",
+	}
+	lexiconNegators = []string{
+		"not", "no", "don't", "dont", "isn't", "isnt", "wasn't", "wasnt", "never", "without",
+		"не", "ні", "ніколи", "без",
+		"нет", "никогда",
+	}
+)
+
+// lexiconAnalyzer counts positive and negative words, a negator inverts the next word
+type lexiconAnalyzer struct {
+	positive map[string]struct{}
+	negative map[string]struct{}
+	negators map[string]struct{}
+}
+
+func NewLexiconAnalyzer(positive, negative []string) SentimentAnalyzer {
+	if positive == nil {
+		positive = lexiconPositive
+	}
+	if negative == nil {
+		negative = lexiconNegative
+	}
+
+	return &lexiconAnalyzer{
+		positive: wordSet(positive),
+		negative: wordSet(negative),
+		negators: wordSet(lexiconNegators),
+	}
+}
+
+// newLexiconAnalyzer the default analyzer with the words of the queue
+func newLexiconAnalyzer(positive, negative, negators []string) SentimentAnalyzer {
+	return &lexiconAnalyzer{
+		positive: wordSet(append(append([]string{}, lexiconPositive...), positive...)),
+		negative: wordSet(append(append([]string{}, lexiconNegative...), negative...)),
+		negators: wordSet(append(append([]string{}, lexiconNegators...), negators...)),
+	}
+}
+
+func (l *lexiconAnalyzer) Name() string {
+	return AnalyzerLexicon
+}
+
+func (l *lexiconAnalyzer) Score(text string) float64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	var pos, neg int
+	negate := false
+	for _, w := range words {
+		_, isPos := l.positive[w]
+		_, isNeg := l.negative[w]
+
+		switch {
+		case isPos && negate, isNeg && !negate:
+			neg++
+		case isPos, isNeg:
+			pos++
+		}
+
+		_, negate = l.negators[w]
+	}
+
+	if pos+neg == 0 {
+		return 0
+	}
+
+	return float64(pos-neg) / float64(pos+neg)
+}
+
+func wordSet(words []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		res[strings.ToLower(w)] = struct{}{}
+	}
+
+	return res
+}
@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestLexiconAnalyzer(t *testing.T) {
+	a := NewLexiconAnalyzer(nil, nil)
+
+	if s := a.Score("thanks, great help"); s <= 0 {
+		t.Errorf("expected positive score, got %f", s)
+	}
+	if s := a.Score("this is terrible, I want a refund"); s >= 0 {
+		t.Errorf("expected negative score, got %f", s)
+	}
+	if s := a.Score("not good"); s >= 0 {
+		t.Errorf("expected negated score, got %f", s)
+	}
+	if s := a.Score("hello"); s != 0 {
+		t.Errorf("expected neutral score, got %f", s)
+	}
+	if s := a.Score("Дякую, все добре"); s <= 0 {
+		t.Errorf("expected positive score, got %f", s)
+	}
+	if s := a.Score("не добре"); s >= 0 {
+		t.Errorf("expected negated score, got %f", s)
+	}
+
+	q := newLexiconAnalyzer(nil, []string{"kaputt"}, nil)
+	if s := q.Score("alles kaputt"); s >= 0 {
+		t.Errorf("expected negative score of the queue word, got %f", s)
+	}
+	if s := q.Score("thanks"); s <= 0 {
+		t.Errorf("expected positive score of the default word, got %f", s)
+	}
+}
+
+func TestAlertKeywordsUnicode(t *testing.T) {
+	rules, err := NewAlertRules(&model.ChatAlertSettings{
+		Enabled: true,
+		Rules: []model.ChatAlertRule{
+			{Name: "cancel", Keywords: []string{"скасувати"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	m := rules.NewMonitor()
+	if _, fired := m.Analyze(model.ChatTranscriptSenderClient, "скасуватиме"); len(fired) != 0 {
+		t.Errorf("keyword must match the whole word: %v", fired)
+	}
+	if _, fired := m.Analyze(model.ChatTranscriptSenderClient, "Хочу СКАСУВАТИ підписку"); len(fired) != 1 || fired[0] != "cancel" {
+		t.Errorf("expected cancel rule, got %v", fired)
+	}
+}
+
+func TestMonitorRules(t *testing.T) {
+	rules, err := NewAlertRules(&model.ChatAlertSettings{
+		Enabled: true,
+		Rules: []model.ChatAlertRule{
+			{Name: "cancel", Keywords: []string{"cancel my account"}, Sender: model.ChatTranscriptSenderClient},
+		},
+		NegativeTrend: &model.ChatAlertTrend{Messages: 3, Threshold: -0.5},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	m := rules.NewMonitor()
+	if _, fired := m.Analyze(model.ChatTranscriptSenderAgent, "cancel my account?"); len(fired) != 0 {
+		t.Errorf("rule must skip agent messages: %v", fired)
+	}
+	if _, fired := m.Analyze(model.ChatTranscriptSenderClient, "Please CANCEL MY ACCOUNT"); len(fired) != 1 || fired[0] != "cancel" {
+		t.Errorf("expected cancel rule, got %v", fired)
+	}
+	if _, fired := m.Analyze(model.ChatTranscriptSenderClient, "cancel my account now"); len(fired) != 0 {
+		t.Errorf("rule must fire once: %v", fired)
+	}
+	if _, fired := m.Analyze(model.ChatTranscriptSenderClient, "terrible, awful"); len(fired) != 1 || fired[0] != negativeTrendRule {
+		t.Errorf("expected negative trend, got %v", fired)
+	}
+
+	if v := m.Variables()[model.ChatAlertRulesVariable]; v != "cancel,negative_trend" {
+		t.Errorf("bad alerts variable: %s", v)
+	}
+}
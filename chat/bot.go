@@ -0,0 +1,147 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"net/http"
+	"strings"
+)
+
+const (
+	BotTypeHttp  = "http"
+	BotTypeGrpc  = "grpc"
+	BotTypeRules = "rules"
+	BotTypeEcho  = "echo"
+)
+
+const (
+	BotMessageFromClient = "client"
+	BotMessageFromBot    = "bot"
+)
+
+const (
+	botDefaultTimeout     = 10
+	botDefaultMaxDuration = 300
+	botDefaultMaxTurns    = 20
+	botSummaryMessages    = 10
+)
+
+type BotRule struct {
+	Pattern   string            `json:"pattern"`
+	Answer    string            `json:"answer"`
+	Variables map[string]string `json:"variables"`
+	Escalate  bool              `json:"escalate"`
+	Close     bool              `json:"close"`
+}
+
+type BotField struct {
+	Name     string `json:"name"`
+	Question string `json:"question"`
+	Pattern  string `json:"pattern"`
+	Retry    string `json:"retry"`
+}
+
+type BotSettings struct {
+	Enabled     bool              `json:"enabled"`
+	Type        string            `json:"type"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Timeout     uint16            `json:"timeout"`
+	MaxDuration uint32            `json:"max_duration"`
+	MaxTurns    uint16            `json:"max_turns"`
+	Greeting    string            `json:"greeting"`
+	Escalation  string            `json:"escalation"`
+	Rules       []BotRule         `json:"rules"`
+	Fields      []BotField        `json:"fields"`
+}
+
+type BotMessage struct {
+	From      string `json:"from"`
+	Text      string `json:"text"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type BotRequest struct {
+	ConversationId string            `json:"conversation_id"`
+	DomainId       int64             `json:"domain_id"`
+	QueueId        int               `json:"queue_id"`
+	AttemptId      int64             `json:"attempt_id"`
+	Start          bool              `json:"start"`
+	Text           string            `json:"text"`
+	Variables      map[string]string `json:"variables"`
+	Transcript     []BotMessage      `json:"transcript"`
+}
+
+type BotResponse struct {
+	Messages  []string          `json:"messages"`
+	Variables map[string]string `json:"variables"`
+	Escalate  bool              `json:"escalate"`
+	Close     bool              `json:"close"`
+	Summary   string            `json:"summary"`
+}
+
+// Bot answers the client before the conversation is distributed to an agent
+type Bot interface {
+	Name() string
+	Handle(ctx context.Context, req *BotRequest) (*BotResponse, *model.AppError)
+}
+
+func (s *BotSettings) Allow() bool {
+	return s != nil && s.Enabled
+}
+
+func (s *BotSettings) TimeoutSec() uint16 {
+	if s.Timeout == 0 {
+		return botDefaultTimeout
+	}
+
+	return s.Timeout
+}
+
+func (s *BotSettings) MaxDurationSec() uint32 {
+	if s.MaxDuration == 0 {
+		return botDefaultMaxDuration
+	}
+
+	return s.MaxDuration
+}
+
+func (s *BotSettings) MaxTurnsCount() uint16 {
+	if s.MaxTurns == 0 {
+		return botDefaultMaxTurns
+	}
+
+	return s.MaxTurns
+}
+
+func NewBot(settings *BotSettings) (Bot, *model.AppError) {
+	switch settings.Type {
+	case BotTypeHttp:
+		return NewHttpBot(settings)
+	case BotTypeGrpc:
+		return NewGrpcBot(settings)
+	case BotTypeRules, "":
+		return NewRulesBot(settings)
+	case BotTypeEcho:
+		return NewEchoBot(), nil
+	default:
+		return nil, model.NewAppError("Chat.NewBot", "chat.bot.type.app_error", nil,
+			fmt.Sprintf("unknown bot type \"%s\"", settings.Type), http.StatusBadRequest)
+	}
+}
+
+// BotSummary builds a short transcript of the last client and bot messages
+func BotSummary(transcript []BotMessage) string {
+	if len(transcript) > botSummaryMessages {
+		transcript = transcript[len(transcript)-botSummaryMessages:]
+	}
+
+	lines := make([]string, 0, len(transcript))
+	for _, m := range transcript {
+		lines = append(lines, fmt.Sprintf("%s: %s", m.From, m.Text))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/webitel/call_center/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"net/http"
+	"sync"
+)
+
+var (
+	botConnections   = make(map[string]*grpc.ClientConn)
+	botConnectionsMx sync.Mutex
+)
+
+// grpcBot calls the unary method with google.protobuf.Struct request and response
+type grpcBot struct {
+	method string
+	conn   *grpc.ClientConn
+}
+
+func NewGrpcBot(settings *BotSettings) (Bot, *model.AppError) {
+	if settings.Url == "" || settings.Method == "" {
+		return nil, model.NewAppError("Chat.NewGrpcBot", "chat.bot.grpc.valid.url", nil, "url and method are required", http.StatusBadRequest)
+	}
+
+	conn, err := botConnection(settings.Url)
+	if err != nil {
+		return nil, model.NewAppError("Chat.NewGrpcBot", "chat.bot.grpc.connect.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return &grpcBot{
+		method: settings.Method,
+		conn:   conn,
+	}, nil
+}
+
+func botConnection(url string) (*grpc.ClientConn, error) {
+	botConnectionsMx.Lock()
+	defer botConnectionsMx.Unlock()
+
+	if conn, ok := botConnections[url]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	botConnections[url] = conn
+
+	return conn, nil
+}
+
+// CloseBotConnections closes the cached connections of the grpc bots
+func CloseBotConnections() {
+	botConnectionsMx.Lock()
+	defer botConnectionsMx.Unlock()
+
+	for url, conn := range botConnections {
+		conn.Close()
+		delete(botConnections, url)
+	}
+}
+
+func (b *grpcBot) Name() string {
+	return BotTypeGrpc
+}
+
+func (b *grpcBot) Handle(ctx context.Context, req *BotRequest) (*BotResponse, *model.AppError) {
+	in := &structpb.Struct{}
+	data, err := json.Marshal(req)
+	if err == nil {
+		err = protojson.Unmarshal(data, in)
+	}
+	if err != nil {
+		return nil, model.NewAppError("Chat.GrpcBot", "chat.bot.grpc.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	out := &structpb.Struct{}
+	if err = b.conn.Invoke(ctx, b.method, in, out); err != nil {
+		return nil, model.NewAppError("Chat.GrpcBot", "chat.bot.grpc.send.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	var res BotResponse
+	data, err = protojson.Marshal(out)
+	if err == nil {
+		err = json.Unmarshal(data, &res)
+	}
+	if err != nil {
+		return nil, model.NewAppError("Chat.GrpcBot", "chat.bot.grpc.parse.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"io"
+	"net/http"
+)
+
+type httpBot struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewHttpBot(settings *BotSettings) (Bot, *model.AppError) {
+	if settings.Url == "" {
+		return nil, model.NewAppError("Chat.NewHttpBot", "chat.bot.http.valid.url", nil, "url is required", http.StatusBadRequest)
+	}
+
+	return &httpBot{
+		url:     settings.Url,
+		headers: settings.Headers,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (b *httpBot) Name() string {
+	return BotTypeHttp
+}
+
+func (b *httpBot) Handle(ctx context.Context, req *BotRequest) (*BotResponse, *model.AppError) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range b.headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := b.client.Do(r)
+	if err != nil {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.send.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.read.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.status.app_error", nil,
+			fmt.Sprintf("status %d: %s", resp.StatusCode, string(data)), resp.StatusCode)
+	}
+
+	var res BotResponse
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, model.NewAppError("Chat.HttpBot", "chat.bot.http.parse.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return &res, nil
+}
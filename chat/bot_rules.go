@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type rulesBotRule struct {
+	BotRule
+	re *regexp.Regexp
+}
+
+type rulesBotField struct {
+	BotField
+	re *regexp.Regexp
+}
+
+// rulesBot answers by the keyword rules and asks the fields one by one, the state is kept in the variables
+type rulesBot struct {
+	greeting   string
+	escalation string
+	rules      []rulesBotRule
+	fields     []rulesBotField
+}
+
+type echoBot struct {
+}
+
+func NewRulesBot(settings *BotSettings) (Bot, *model.AppError) {
+	bot := &rulesBot{
+		greeting:   settings.Greeting,
+		escalation: settings.Escalation,
+	}
+
+	for _, r := range settings.Rules {
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, model.NewAppError("Chat.NewRulesBot", "chat.bot.rules.valid.pattern", nil,
+				fmt.Sprintf("rule \"%s\": %s", r.Pattern, err.Error()), http.StatusBadRequest)
+		}
+		bot.rules = append(bot.rules, rulesBotRule{BotRule: r, re: re})
+	}
+
+	for _, f := range settings.Fields {
+		if f.Name == "" {
+			return nil, model.NewAppError("Chat.NewRulesBot", "chat.bot.rules.valid.field", nil, "field name is required", http.StatusBadRequest)
+		}
+		field := rulesBotField{BotField: f}
+		if f.Pattern != "" {
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				return nil, model.NewAppError("Chat.NewRulesBot", "chat.bot.rules.valid.pattern", nil,
+					fmt.Sprintf("field \"%s\": %s", f.Name, err.Error()), http.StatusBadRequest)
+			}
+			field.re = re
+		}
+		bot.fields = append(bot.fields, field)
+	}
+
+	return bot, nil
+}
+
+func (b *rulesBot) Name() string {
+	return BotTypeRules
+}
+
+func (b *rulesBot) Handle(_ context.Context, req *BotRequest) (*BotResponse, *model.AppError) {
+	res := &BotResponse{
+		Variables: make(map[string]string),
+	}
+
+	if req.Start {
+		if b.greeting != "" {
+			res.Messages = append(res.Messages, b.greeting)
+		}
+		return b.next(req, res), nil
+	}
+
+	text := strings.TrimSpace(req.Text)
+	for _, r := range b.rules {
+		if !r.re.MatchString(text) {
+			continue
+		}
+
+		if r.Answer != "" {
+			res.Messages = append(res.Messages, r.Answer)
+		}
+		for k, v := range r.Variables {
+			res.Variables[k] = v
+		}
+		res.Escalate = r.Escalate
+		res.Close = r.Close
+		if res.Escalate || res.Close {
+			return res, nil
+		}
+
+		return b.next(req, res), nil
+	}
+
+	if f := b.currentField(req.Variables); f != nil {
+		if f.re != nil && !f.re.MatchString(text) {
+			retry := f.Retry
+			if retry == "" {
+				retry = f.Question
+			}
+			res.Messages = append(res.Messages, retry)
+			return res, nil
+		}
+		res.Variables[f.Name] = text
+	}
+
+	return b.next(req, res), nil
+}
+
+// next asks the next empty field or escalates when all fields are collected
+func (b *rulesBot) next(req *BotRequest, res *BotResponse) *BotResponse {
+	vars := model.UnionStringMaps(req.Variables, res.Variables)
+	if f := b.currentField(vars); f != nil {
+		res.Messages = append(res.Messages, f.Question)
+		return res
+	}
+
+	if b.escalation != "" {
+		res.Messages = append(res.Messages, b.escalation)
+	}
+	res.Escalate = true
+
+	return res
+}
+
+func (b *rulesBot) currentField(vars map[string]string) *rulesBotField {
+	for i := range b.fields {
+		if v, ok := vars[b.fields[i].Name]; !ok || v == "" {
+			return &b.fields[i]
+		}
+	}
+
+	return nil
+}
+
+func NewEchoBot() Bot {
+	return &echoBot{}
+}
+
+func (b *echoBot) Name() string {
+	return BotTypeEcho
+}
+
+func (b *echoBot) Handle(_ context.Context, req *BotRequest) (*BotResponse, *model.AppError) {
+	if req.Start {
+		return &BotResponse{}, nil
+	}
+
+	return &BotResponse{
+		Messages: []string{req.Text},
+		Escalate: strings.EqualFold(strings.TrimSpace(req.Text), "agent"),
+	}, nil
+}
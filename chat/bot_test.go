@@ -0,0 +1,108 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestRulesBot(t *testing.T) {
+	bot, err := NewBot(&BotSettings{
+		Greeting:   "Hello",
+		Escalation: "Connecting to an agent",
+		Rules: []BotRule{
+			{Pattern: `\bthanks\b`, Answer: "Bye", Close: true},
+			{Pattern: `\boperator\b`, Escalate: true},
+		},
+		Fields: []BotField{
+			{Name: "email", Question: "Your email?", Pattern: `^\S+@\S+$`, Retry: "Bad email, again?"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := &BotRequest{Start: true, Variables: map[string]string{}}
+	res, _ := bot.Handle(context.Background(), req)
+	if strings.Join(res.Messages, "|") != "Hello|Your email?" || res.Escalate || res.Close {
+		t.Fatalf("start: got %+v", res)
+	}
+
+	req.Start = false
+	req.Text = "nope"
+	if res, _ = bot.Handle(context.Background(), req); strings.Join(res.Messages, "|") != "Bad email, again?" {
+		t.Errorf("retry: got %+v", res)
+	}
+
+	req.Text = "user@example.com"
+	res, _ = bot.Handle(context.Background(), req)
+	if res.Variables["email"] != "user@example.com" || !res.Escalate || strings.Join(res.Messages, "|") != "Connecting to an agent" {
+		t.Errorf("collect: got %+v", res)
+	}
+
+	req.Text = "OK, Thanks!"
+	if res, _ = bot.Handle(context.Background(), req); !res.Close || res.Escalate || strings.Join(res.Messages, "|") != "Bye" {
+		t.Errorf("close: got %+v", res)
+	}
+
+	req.Text = "give me an operator"
+	if res, _ = bot.Handle(context.Background(), req); !res.Escalate || res.Close {
+		t.Errorf("escalate: got %+v", res)
+	}
+
+	if _, err = NewBot(&BotSettings{Rules: []BotRule{{Pattern: "("}}}); err == nil {
+		t.Errorf("bad pattern is accepted")
+	}
+	if _, err = NewBot(&BotSettings{Type: "unknown"}); err == nil {
+		t.Errorf("unknown type is accepted")
+	}
+}
+
+func TestEchoBot(t *testing.T) {
+	bot, err := NewBot(&BotSettings{Type: BotTypeEcho})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if res, _ := bot.Handle(context.Background(), &BotRequest{Start: true}); len(res.Messages) != 0 {
+		t.Errorf("start: got %+v", res)
+	}
+	if res, _ := bot.Handle(context.Background(), &BotRequest{Text: "ping"}); strings.Join(res.Messages, "|") != "ping" || res.Escalate {
+		t.Errorf("echo: got %+v", res)
+	}
+	if res, _ := bot.Handle(context.Background(), &BotRequest{Text: " Agent "}); !res.Escalate {
+		t.Errorf("escalate: got %+v", res)
+	}
+}
+
+func TestBotSummary(t *testing.T) {
+	var transcript []BotMessage
+	for i := 0; i < botSummaryMessages+2; i++ {
+		transcript = append(transcript, BotMessage{From: BotMessageFromClient, Text: string(rune('a' + i))})
+	}
+
+	lines := strings.Split(BotSummary(transcript), "\n")
+	if len(lines) != botSummaryMessages || lines[0] != "client: c" {
+		t.Errorf("got %v", lines)
+	}
+}
+
+func TestCloseBotConnections(t *testing.T) {
+	conn, err := botConnection("127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if c, _ := botConnection("127.0.0.1:1"); c != conn {
+		t.Errorf("connection is not cached")
+	}
+
+	CloseBotConnections()
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("connection is not closed: %s", conn.GetState())
+	}
+	if len(botConnections) != 0 {
+		t.Errorf("connections are not removed")
+	}
+}
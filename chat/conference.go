@@ -0,0 +1,180 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"net/http"
+)
+
+type ChatSessionStats struct {
+	UserId   int64           `json:"user_id"`
+	Role     ChatSessionRole `json:"role"`
+	InviteAt int64           `json:"invite_at"`
+	JoinedAt int64           `json:"joined_at"`
+	LeftAt   int64           `json:"left_at"`
+}
+
+// InviteParticipant invites the agent by the agent of the conversation, the participant joins the conversation,
+// the consult is the internal chat of the agents so the client never sees it
+func (c *Conversation) InviteParticipant(ctx context.Context, fromUserId, userId int64, role ChatSessionRole, timeout uint16,
+	title string) *model.AppError {
+	if role != ChatRoleConsult && role != ChatRoleParticipant {
+		return model.NewAppError("Chat.InviteParticipant", "chat.invite.participant.valid.role", nil,
+			fmt.Sprintf("bad role \"%s\"", role), http.StatusBadRequest)
+	}
+
+	if from := c.activeSessionByUserId(fromUserId); from == nil || from.GetRole() == ChatRoleConsult {
+		return model.NewAppError("Chat.InviteParticipant", "chat.invite.participant.valid.from", nil,
+			fmt.Sprintf("user %d is not in the conversation", fromUserId), http.StatusForbidden)
+	}
+
+	if c.activeSessionByUserId(userId) != nil {
+		return model.NewAppError("Chat.InviteParticipant", "chat.invite.participant.valid.user", nil,
+			fmt.Sprintf("user %d already in the conversation", userId), http.StatusBadRequest)
+	}
+
+	sess := OutboundChat(c.cli, userId, c.id, c.inviterId, c.inviterUserId)
+	sess.setRole(role)
+	c.Lock()
+	c.sessions = append(c.sessions, sess)
+	c.Unlock()
+
+	var invId string
+	var err error
+	if role == ChatRoleConsult {
+		err = c.cli.NewInternalChat(c.DomainId, fromUserId, userId)
+	} else {
+		invId, err = c.cli.InviteToConversation(ctx, c.DomainId, userId, c.id, c.inviterId, c.inviterUserId, title, int(timeout),
+			c.variables)
+	}
+
+	if err != nil {
+		sess.Lock()
+		sess.stopAt = model.GetMillis()
+		sess.Unlock()
+		return model.NewAppError("Chat.InviteParticipant", "chat.invite.participant.app_err", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	sess.Lock()
+	sess.InviteId = invId
+	sess.InviteAt = model.GetMillis()
+	sess.ActivityAt = sess.InviteAt
+	if role == ChatRoleConsult {
+		sess.AnsweredAt = sess.InviteAt
+	}
+	sess.Unlock()
+
+	c.log.Debug(fmt.Sprintf("conversation %s invite %s user_id=%d from user_id=%d", c.id, role, userId, fromUserId))
+
+	return nil
+}
+
+// Handover makes the joined participant the agent of the conversation, the current agent leaves it,
+// the session of the agent stops on the leave event
+func (c *Conversation) Handover(fromUserId, userId int64) *model.AppError {
+	agentSess := c.AgentSession()
+	if agentSess.UserId != fromUserId || agentSess.GetRole() != ChatRoleAgent || agentSess.StopAt() != 0 {
+		return model.NewAppError("Chat.Handover", "chat.handover.valid.from", nil,
+			fmt.Sprintf("user %d is not the agent of the conversation", fromUserId), http.StatusForbidden)
+	}
+
+	sess := c.activeSessionByUserId(userId)
+	if sess == nil || sess.GetRole() != ChatRoleParticipant || !sess.Answered() {
+		return model.NewAppError("Chat.Handover", "chat.handover.valid.user", nil,
+			fmt.Sprintf("user %d is not joined to the conversation", userId), http.StatusBadRequest)
+	}
+
+	sess.setRole(ChatRoleAgent)
+	agentSess.setRole(ChatRoleHandover)
+
+	c.state <- ChatStateHandover
+
+	return agentSess.Leave(model.AgentHandover)
+}
+
+// LeaveParticipant the consult or participant leaves the conversation, the conversation continues
+func (c *Conversation) LeaveParticipant(userId int64) *model.AppError {
+	sess := c.activeSessionByUserId(userId)
+	if sess == nil || !sess.secondary() {
+		return model.NewAppError("Chat.LeaveParticipant", "chat.leave.participant.valid.user", nil,
+			fmt.Sprintf("user %d is not a participant of the conversation", userId), http.StatusBadRequest)
+	}
+
+	if sess.GetRole() == ChatRoleConsult {
+		sess.setStop(model.GetMillis())
+		return nil
+	}
+
+	if !sess.Answered() {
+		err := sess.Decline()
+		sess.setStop(model.GetMillis())
+		return err
+	}
+
+	return sess.Leave(model.ParticipantLeave)
+}
+
+func (c *Conversation) setLeave(channelId string, timestamp int64, cause string) {
+	c.RLock()
+	var sess *ChatSession
+	for _, s := range c.sessions {
+		if s.Direction == ChatDirectionOutbound && s.ChannelId == channelId {
+			sess = s
+		}
+	}
+	c.RUnlock()
+
+	if sess != nil && (sess.secondary() || sess.GetRole() == ChatRoleHandover) {
+		sess.Lock()
+		if sess.stopAt == 0 {
+			sess.stopAt = timestamp
+		}
+		sess.cause = cause
+		sess.Unlock()
+		c.log.Debug(fmt.Sprintf("conversation %s %s left user_id=%d", c.id, sess.GetRole(), sess.UserId))
+		return
+	}
+
+	c.setClose(timestamp, cause)
+}
+
+func (c *Conversation) activeSessionByUserId(userId int64) *ChatSession {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, s := range c.sessions {
+		if s.Direction == ChatDirectionOutbound && s.UserId == userId && s.StopAt() == 0 {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (c *Conversation) sessionsStats() []ChatSessionStats {
+	c.RLock()
+	defer c.RUnlock()
+
+	res := make([]ChatSessionStats, 0, len(c.sessions))
+	for _, s := range c.sessions {
+		if s.Direction != ChatDirectionOutbound {
+			continue
+		}
+		s.RLock()
+		st := ChatSessionStats{
+			UserId:   s.UserId,
+			Role:     s.role,
+			InviteAt: s.InviteAt,
+			JoinedAt: s.AnsweredAt,
+			LeftAt:   s.stopAt,
+		}
+		s.RUnlock()
+		if st.LeftAt == 0 {
+			st.LeftAt = c.closeAt
+		}
+		res = append(res, st)
+	}
+
+	return res
+}
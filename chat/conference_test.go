@@ -0,0 +1,157 @@
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/chat_manager"
+	enginemodel "github.com/webitel/engine/model"
+	"github.com/webitel/wlog"
+)
+
+type testChatCli struct {
+	chat_manager.Chat
+	left     []int64
+	internal []int64
+	invited  []int64
+}
+
+func (c *testChatCli) Name() string {
+	return "test"
+}
+
+func (c *testChatCli) Leave(authUserId int64, channelId, conversationId string, cause enginemodel.LeaveCause) error {
+	c.left = append(c.left, authUserId)
+	return nil
+}
+
+func (c *testChatCli) NewInternalChat(domainId, authUserId, userId int64) error {
+	c.internal = append(c.internal, userId)
+	return nil
+}
+
+func (c *testChatCli) InviteToConversation(ctx context.Context, domainId, userId int64, conversationId, inviterId, invUserId,
+	title string, timeout int, vars map[string]string) (string, error) {
+	c.invited = append(c.invited, userId)
+	return "invite", nil
+}
+
+func testConference(cli *testChatCli) (*Conversation, *ChatSession) {
+	conv := newConversation(cli, 1, "conv", "inviter", "1", nil, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	agent := OutboundChat(cli, 10, "conv", "inviter", "1")
+	agent.ChannelId = "agent"
+	agent.AnsweredAt = model.GetMillis()
+	conv.sessions = append(conv.sessions, agent)
+
+	return conv, agent
+}
+
+func joinParticipant(conv *Conversation, userId int64, channelId string) *ChatSession {
+	sess := OutboundChat(conv.cli, userId, conv.id, conv.inviterId, conv.inviterUserId)
+	sess.setRole(ChatRoleParticipant)
+	sess.ChannelId = channelId
+	sess.AnsweredAt = model.GetMillis()
+	conv.sessions = append(conv.sessions, sess)
+
+	return sess
+}
+
+func TestInviteConsult(t *testing.T) {
+	cli := &testChatCli{}
+	conv, _ := testConference(cli)
+
+	if err := conv.InviteParticipant(context.Background(), 10, 20, ChatRoleConsult, 10, ""); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(cli.internal) != 1 || len(cli.invited) != 0 {
+		t.Fatalf("consult must be the internal chat, internal %v invited %v", cli.internal, cli.invited)
+	}
+
+	if err := conv.InviteParticipant(context.Background(), 20, 30, ChatRoleParticipant, 10, ""); err == nil {
+		t.Fatal("consult must not invite")
+	}
+
+	if err := conv.Handover(10, 20); err == nil {
+		t.Fatal("handover to the consult")
+	}
+
+	if err := conv.LeaveParticipant(20); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if conv.activeSessionByUserId(20) != nil {
+		t.Fatal("consult must stop")
+	}
+}
+
+func TestHandover(t *testing.T) {
+	cli := &testChatCli{}
+	conv, agent := testConference(cli)
+	sess := joinParticipant(conv, 20, "participant")
+
+	if err := conv.Handover(20, 10); err == nil {
+		t.Fatal("handover from the participant")
+	}
+
+	if err := conv.Handover(10, 20); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if agent.StopAt() != 0 {
+		t.Fatal("agent session must stop on the leave event")
+	}
+
+	if agent.GetRole() != ChatRoleHandover || sess.GetRole() != ChatRoleAgent {
+		t.Fatalf("bad roles %s %s", agent.GetRole(), sess.GetRole())
+	}
+
+	if conv.AgentSession() != sess {
+		t.Fatal("participant must be the agent")
+	}
+
+	if state := <-conv.State(); state != ChatStateHandover {
+		t.Fatalf("bad state %v", state)
+	}
+
+	if len(cli.left) != 1 || cli.left[0] != 10 {
+		t.Fatalf("agent must leave, left %v", cli.left)
+	}
+
+	conv.setLeave("agent", 100, "leave")
+	if agent.StopAt() != 100 {
+		t.Fatalf("bad stop %d", agent.StopAt())
+	}
+
+	if !conv.Active() {
+		t.Fatal("conversation must continue")
+	}
+}
+
+func TestLeaveParticipant(t *testing.T) {
+	cli := &testChatCli{}
+	conv, _ := testConference(cli)
+	joinParticipant(conv, 20, "participant")
+
+	if err := conv.LeaveParticipant(10); err == nil {
+		t.Fatal("agent is not the participant")
+	}
+
+	if err := conv.LeaveParticipant(30); err == nil {
+		t.Fatal("user is not in the conversation")
+	}
+
+	if err := conv.LeaveParticipant(20); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	conv.setLeave("participant", 100, "leave")
+	if conv.activeSessionByUserId(20) != nil {
+		t.Fatal("participant must stop")
+	}
+
+	if !conv.Active() {
+		t.Fatal("conversation must continue")
+	}
+}
@@ -20,12 +20,15 @@ const (
 	ChatStateDeclined
 	ChatStateBridge
 	ChatStateClose
+	ChatStateHandover
 )
 
 var (
 	ErrChannelNotFound = model.NewAppError("Chat.InviteInternal", "chat.invite.not_found", nil, "channel not found", http.StatusNotFound)
 )
 
+type MessageHook func(sess *ChatSession, msg *model.ChatMessage)
+
 type Conversation struct {
 	id            string
 	inviterId     string
@@ -43,6 +46,8 @@ type Conversation struct {
 	currentState  ChatState
 	state         chan ChatState
 	cause         string
+	messageHooks  []MessageHook
+	transcript    *transcript
 	log           *wlog.Logger
 	sync.RWMutex
 }
@@ -64,11 +69,12 @@ func newConversation(cli chat_manager.Chat, domainId int64, id, inviterId, invit
 		AnsweredAt:     0,
 		ActivityAt:     model.GetMillis(),
 		stopAt:         0,
+		role:           ChatRoleClient,
 		cli:            cli,
 		variables:      variables,
 	}
 
-	return &Conversation{
+	conv := &Conversation{
 		id:            id,
 		inviterId:     inviterId,
 		inviterUserId: inviterUserId,
@@ -85,6 +91,9 @@ func newConversation(cli chat_manager.Chat, domainId int64, id, inviterId, invit
 			wlog.String("connection", cli.Name()),
 		),
 	}
+	sess.conversation = conv
+
+	return conv
 }
 
 func (cm *ChatManager) NewConversation(domainId int64, id, inviterId, inviterUserId string, variables map[string]string) (*Conversation, *model.AppError) {
@@ -138,7 +147,7 @@ func (c *Conversation) InviteInternal(ctx context.Context, userId int64, timeout
 }
 
 func (c *Conversation) Reporting(noLeave bool) *model.AppError {
-	sess := c.LastSession()
+	sess := c.AgentSession()
 	if sess.StopAt() != 0 {
 		return model.NewAppError("Chat.Reporting", "chat.reporting.valid.stop_at", nil, "Chat is closed", http.StatusBadRequest)
 	}
@@ -173,6 +182,20 @@ func (c *Conversation) LastSession() *ChatSession {
 	return c.sessions[len(c.sessions)-1]
 }
 
+// AgentSession returns the session of the agent who owns the conversation
+func (c *Conversation) AgentSession() *ChatSession {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := len(c.sessions) - 1; i > 0; i-- {
+		if c.sessions[i].GetRole() == ChatRoleAgent {
+			return c.sessions[i]
+		}
+	}
+
+	return c.sessions[len(c.sessions)-1]
+}
+
 func (c *Conversation) Cause() string {
 	c.RLock()
 	cause := c.cause
@@ -260,7 +283,12 @@ func (c *Conversation) setJoined(channelId string, timestamp int64) {
 	c.lastMessageAt = model.GetMillis()
 	c.Unlock()
 
-	if sess != nil {
+	if sess != nil && sess.secondary() {
+		sess.ChannelId = channelId
+		sess.AnsweredAt = timestamp
+		sess.SetActivity()
+		c.log.Debug(fmt.Sprintf("conversation %s %s joined user_id=%d", c.id, sess.GetRole(), sess.UserId))
+	} else if sess != nil {
 		sess.ChannelId = channelId
 		sess.AnsweredAt = timestamp
 		sess.SetActivity()
@@ -271,15 +299,27 @@ func (c *Conversation) setJoined(channelId string, timestamp int64) {
 	}
 }
 
-func (c *Conversation) setNewMessage(channelId string) {
+// OnMessage registers a hook called for each new message of the conversation
+func (c *Conversation) OnMessage(hook MessageHook) {
+	c.Lock()
+	c.messageHooks = append(c.messageHooks, hook)
+	c.Unlock()
+}
+
+func (c *Conversation) setNewMessage(msg *model.ChatMessage) {
 	c.Lock()
 	c.lastMessageAt = model.GetMillis()
+	hooks := c.messageHooks
 	c.Unlock()
 
-	sess := c.getSessionByChannelId(channelId)
+	sess := c.getSessionByChannelId(msg.ChannelId)
 	if sess != nil {
 		sess.SetActivity()
 	}
+
+	for _, h := range hooks {
+		h(sess, msg)
+	}
 }
 
 func (c *Conversation) setClose(timestamp int64, cause string) {
@@ -298,7 +338,11 @@ func (c *Conversation) setClose(timestamp int64, cause string) {
 
 func (c *Conversation) setDeclined(inviteId string, timestamp int64) {
 	sess := c.getSessionByInviteId(inviteId)
-	if sess != nil {
+	if sess != nil && sess.secondary() {
+		sess.Lock()
+		sess.stopAt = timestamp
+		sess.Unlock()
+	} else if sess != nil {
 		//c.Lock()
 		sess.Lock()
 		sess.stopAt = timestamp
@@ -50,8 +50,12 @@ func (m *ChatManager) handleEvent(e *model.ChatEvent) {
 		chat.setJoined(e.ChannelId(), e.Timestamp())
 
 	case ChatEventMessage:
-		chat.setNewMessage(e.MessageChannelId())
-	case ChatEventLeave, ChatEventClose:
+		msg := e.Message()
+		chat.setNewMessage(msg)
+		m.captureTranscript(chat, msg)
+	case ChatEventLeave:
+		chat.setLeave(e.ChannelId(), e.Timestamp(), strings.ToLower(e.Cause()))
+	case ChatEventClose:
 		chat.setClose(e.Timestamp(), strings.ToLower(e.Cause()))
 		//m.RemoveConversation(chat)
 	default:
@@ -1,7 +1,10 @@
 package chat
 
 import (
+	"github.com/webitel/call_center/model"
 	"github.com/webitel/call_center/mq"
+	"github.com/webitel/call_center/store"
+	ccutils "github.com/webitel/call_center/utils"
 	"github.com/webitel/engine/chat_manager"
 	"github.com/webitel/engine/discovery"
 	"github.com/webitel/engine/utils"
@@ -22,15 +25,25 @@ type ChatManager struct {
 	chats     utils.ObjectCache
 	api       chat_manager.ChatManager
 	log       *wlog.Logger
+
+	transcripts       store.ChatTranscriptStore
+	files             ccutils.FileBackend
+	transcriptCh      chan *model.ChatTranscriptMessage
+	transcriptStopped chan struct{}
 }
 
-func NewChatManager(discovery discovery.ServiceDiscovery, mq mq.MQ, log *wlog.Logger) *ChatManager {
+func NewChatManager(discovery discovery.ServiceDiscovery, mq mq.MQ, transcripts store.ChatTranscriptStore, files ccutils.FileBackend,
+	log *wlog.Logger) *ChatManager {
 	return &ChatManager{
-		stop:    make(chan struct{}),
-		stopped: make(chan struct{}),
-		api:     chat_manager.NewChatManager(discovery),
-		mq:      mq,
-		chats:   utils.NewLruWithParams(maxOpenedChat, "Chats", expireCacheChat, ""),
+		stop:              make(chan struct{}),
+		stopped:           make(chan struct{}),
+		api:               chat_manager.NewChatManager(discovery),
+		mq:                mq,
+		transcripts:       transcripts,
+		files:             files,
+		transcriptCh:      make(chan *model.ChatTranscriptMessage, transcriptBufferSize),
+		transcriptStopped: make(chan struct{}),
+		chats:             utils.NewLruWithParams(maxOpenedChat, "Chats", expireCacheChat, ""),
 		log: log.With(
 			wlog.Namespace("context"),
 			wlog.String("name", "chat manager"),
@@ -40,6 +53,7 @@ func NewChatManager(discovery discovery.ServiceDiscovery, mq mq.MQ, log *wlog.Lo
 
 func (m *ChatManager) Start() error {
 	m.startOnce.Do(func() {
+		go m.transcriptWriter()
 		go func() {
 			defer func() {
 				m.log.Debug("stopped chat")
@@ -67,6 +81,8 @@ func (m *ChatManager) Start() error {
 
 func (m *ChatManager) Stop() {
 	m.api.Stop()
+	CloseBotConnections()
 	close(m.stop)
 	<-m.stopped
+	<-m.transcriptStopped
 }
@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"encoding/json"
 	"github.com/webitel/call_center/model"
 	"github.com/webitel/engine/chat_manager"
 	enginemodel "github.com/webitel/engine/model"
@@ -15,6 +16,16 @@ const (
 	ChatDirectionOutbound ChatDirection = "outbound"
 )
 
+type ChatSessionRole string
+
+const (
+	ChatRoleClient      ChatSessionRole = "client"
+	ChatRoleAgent       ChatSessionRole = "agent"
+	ChatRoleConsult     ChatSessionRole = "consult"
+	ChatRoleParticipant ChatSessionRole = "participant"
+	ChatRoleHandover    ChatSessionRole = "handover"
+)
+
 type ChatSession struct {
 	inviterId      string
 	inviterUserId  string
@@ -28,10 +39,12 @@ type ChatSession struct {
 	AnsweredAt     int64
 	stopAt         int64
 	ActivityAt     int64
+	role           ChatSessionRole
 
-	cli       chat_manager.Chat
-	variables map[string]string
-	cause     string
+	cli          chat_manager.Chat
+	conversation *Conversation
+	variables    map[string]string
+	cause        string
 
 	sync.RWMutex
 }
@@ -49,6 +62,7 @@ func OutboundChat(cli chat_manager.Chat, userId int64, conversationId, inviterId
 		CreatedAt:      model.GetMillis(),
 		AnsweredAt:     0,
 		stopAt:         0,
+		role:           ChatRoleAgent,
 		cli:            cli,
 	}
 }
@@ -82,6 +96,27 @@ func (c *ChatSession) SetActivity() {
 	c.Unlock()
 }
 
+func (c *ChatSession) GetRole() ChatSessionRole {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.role
+}
+
+func (c *ChatSession) setRole(role ChatSessionRole) {
+	c.Lock()
+	c.role = role
+	c.Unlock()
+}
+
+func (c *ChatSession) setStop(timestamp int64) {
+	c.Lock()
+	if c.stopAt == 0 {
+		c.stopAt = timestamp
+	}
+	c.Unlock()
+}
+
 func (c *ChatSession) StopAt() int64 {
 	c.RLock()
 	stopAt := c.stopAt
@@ -137,5 +172,18 @@ func (c *ChatSession) Stats() map[string]string {
 			vars["chat_transferred"] = "false"
 		}
 	}
+
+	if c.conversation != nil {
+		if sessions := c.conversation.sessionsStats(); len(sessions) != 0 {
+			data, _ := json.Marshal(sessions)
+			vars["chat_sessions"] = string(data)
+		}
+	}
 	return vars
 }
+
+// secondary session of the agent invited to the conversation by other agent
+func (c *ChatSession) secondary() bool {
+	role := c.GetRole()
+	return role == ChatRoleConsult || role == ChatRoleParticipant
+}
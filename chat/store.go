@@ -34,5 +34,6 @@ func (m *ChatManager) RemoveConversation(chat *Conversation) {
 	}
 
 	m.chats.Remove(chat.id)
+	go m.archiveTranscript(chat)
 	chat.log.Debug(fmt.Sprintf("chat [%s] remove from store domaind_id=%d, chat_user_id=%s", chat.id, chat.DomainId, chat.inviterUserId))
 }
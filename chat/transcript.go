@@ -0,0 +1,160 @@
+package chat
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+	"time"
+)
+
+const (
+	transcriptBufferSize    = 1000
+	transcriptBatchSize     = 100
+	transcriptFlushInterval = time.Second
+)
+
+type transcript struct {
+	attemptId int64
+	queueId   int
+	redactor  *model.ChatRedactor
+	messages  []*model.ChatTranscriptMessage
+}
+
+// SetTranscript enables capture of the messages to the transcript of the attempt
+func (c *Conversation) SetTranscript(attemptId int64, queueId int, redactor *model.ChatRedactor) {
+	c.Lock()
+	c.transcript = &transcript{
+		attemptId: attemptId,
+		queueId:   queueId,
+		redactor:  redactor,
+	}
+	c.Unlock()
+}
+
+func (c *Conversation) transcriptMessage(msg *model.ChatMessage) *model.ChatTranscriptMessage {
+	c.RLock()
+	t := c.transcript
+	c.RUnlock()
+
+	if t == nil {
+		return nil
+	}
+
+	m := &model.ChatTranscriptMessage{
+		DomainId:       c.DomainId,
+		AttemptId:      t.attemptId,
+		QueueId:        t.queueId,
+		ConversationId: c.id,
+		ChannelId:      msg.ChannelId,
+		Sender:         model.ChatTranscriptSenderClient,
+		Type:           msg.Type,
+		Text:           t.redactor.Redact(msg.Text),
+		CreatedAt:      msg.CreatedAt,
+	}
+
+	if sess := c.getSessionByChannelId(msg.ChannelId); sess != nil && sess.Direction == ChatDirectionOutbound {
+		m.Sender = model.ChatTranscriptSenderAgent
+		m.UserId = sess.UserId
+	}
+
+	c.Lock()
+	t.messages = append(t.messages, m)
+	c.Unlock()
+
+	return m
+}
+
+func (m *ChatManager) captureTranscript(conv *Conversation, msg *model.ChatMessage) {
+	tm := conv.transcriptMessage(msg)
+	if tm == nil {
+		return
+	}
+
+	select {
+	case m.transcriptCh <- tm:
+	default:
+		conv.log.Error(fmt.Sprintf("transcript buffer is full, skip message %d", msg.Id))
+	}
+}
+
+func (m *ChatManager) transcriptWriter() {
+	defer close(m.transcriptStopped)
+
+	batch := make([]*model.ChatTranscriptMessage, 0, transcriptBatchSize)
+	ticker := time.NewTicker(transcriptFlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := m.transcripts.Save(batch); err != nil {
+			m.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		}
+		batch = make([]*model.ChatTranscriptMessage, 0, transcriptBatchSize)
+	}
+
+	for {
+		select {
+		case <-m.stop:
+			for {
+				select {
+				case msg := <-m.transcriptCh:
+					batch = append(batch, msg)
+				default:
+					flush()
+					return
+				}
+			}
+		case msg := <-m.transcriptCh:
+			batch = append(batch, msg)
+			if len(batch) >= transcriptBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (m *ChatManager) Transcript(domainId int64, attemptId int64) (*model.ChatTranscript, *model.AppError) {
+	messages, err := m.transcripts.List(domainId, attemptId)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewChatTranscript(attemptId, messages), nil
+}
+
+// archiveTranscript writes the transcript of the closed conversation to the file storage
+func (m *ChatManager) archiveTranscript(conv *Conversation) {
+	conv.RLock()
+	t := conv.transcript
+	var messages []*model.ChatTranscriptMessage
+	if t != nil {
+		messages = append(messages, t.messages...)
+	}
+	conv.RUnlock()
+
+	if m.files == nil || t == nil || len(messages) == 0 {
+		return
+	}
+
+	data, _, err := model.NewChatTranscript(t.attemptId, messages).Export(model.ChatTranscriptFormatJson)
+	if err == nil {
+		_, err = m.files.WriteFile(bytes.NewReader(data), TranscriptFilePath(conv.DomainId, t.attemptId))
+	}
+
+	if err != nil {
+		conv.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+func TranscriptFilePath(domainId int64, attemptId int64) string {
+	return fmt.Sprintf("transcripts/%d/%d.json", domainId, attemptId)
+}
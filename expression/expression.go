@@ -0,0 +1,325 @@
+// Package expression the sandboxed expression language of the queue settings:
+// the arithmetic, the comparison and the logic over the variables, a limited set of the pure functions,
+// the size of the source, the depth and the steps of the evaluation are limited
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const maxSteps = 10000
+
+var errMaxSteps = errors.New("expression exceeded the evaluation steps")
+
+// Env the variables of the evaluation, the value is string, float64, bool or nil
+type Env map[string]interface{}
+
+func EnvFromStrings(vars ...map[string]string) Env {
+	env := make(Env)
+	for _, m := range vars {
+		for k, v := range m {
+			env[k] = v
+		}
+	}
+
+	return env
+}
+
+type Program struct {
+	source string
+	root   node
+}
+
+func Compile(source string) (*Program, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Program{
+		source: source,
+		root:   root,
+	}, nil
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+func (p *Program) Eval(env Env) (interface{}, error) {
+	e := &evaluator{env: env}
+	return e.eval(p.root)
+}
+
+func (p *Program) Float(env Env) (float64, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	f, ok := toNumber(v)
+	if !ok {
+		return 0, fmt.Errorf("result %v is not a number", v)
+	}
+
+	return f, nil
+}
+
+func (p *Program) Bool(env Env) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	return truthy(v), nil
+}
+
+type evaluator struct {
+	env   Env
+	steps int
+}
+
+func (e *evaluator) eval(n node) (interface{}, error) {
+	e.steps++
+	if e.steps > maxSteps {
+		return nil, errMaxSteps
+	}
+
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		return e.env[n.name], nil
+
+	case *unaryNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !truthy(x), nil
+		}
+		f, ok := toNumber(x)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", x)
+		}
+		return -f, nil
+
+	case *condNode:
+		c, err := e.eval(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(c) {
+			return e.eval(n.a)
+		}
+		return e.eval(n.b)
+
+	case *binaryNode:
+		return e.binary(n)
+
+	case *callNode:
+		if n.fn.ident {
+			args := make([]interface{}, len(n.args))
+			for i, a := range n.args {
+				_, ok := e.env[a.(*identNode).name]
+				args[i] = ok
+			}
+			return n.fn.call(args)
+		}
+
+		args := make([]interface{}, len(n.args))
+		for i, a := range n.args {
+			v, err := e.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return n.fn.call(args)
+	}
+
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func (e *evaluator) binary(n *binaryNode) (interface{}, error) {
+	l, err := e.eval(n.l)
+	if err != nil {
+		return nil, err
+	}
+
+	// short circuit
+	switch n.op {
+	case "&&":
+		if !truthy(l) {
+			return false, nil
+		}
+		r, err := e.eval(n.r)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	case "||":
+		if truthy(l) {
+			return true, nil
+		}
+		r, err := e.eval(n.r)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	}
+
+	r, err := e.eval(n.r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, l, r), nil
+	case "+":
+		if lf, rf, ok := numbers(l, r); ok {
+			return lf + rf, nil
+		}
+		return toString(l) + toString(r), nil
+	}
+
+	lf, rf, ok := numbers(l, r)
+	if !ok {
+		return nil, fmt.Errorf("operator %s: %v and %v are not numbers", n.op, l, r)
+	}
+
+	switch n.op {
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+// toNumber the number of the value, the variables are the strings
+func toNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case nil:
+		return 0, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != "" && v != "false" && v != "0"
+	}
+
+	return true
+}
+
+// numbers both values are the numbers when at least one of them is not the string
+func numbers(l, r interface{}) (float64, float64, bool) {
+	_, ls := l.(string)
+	_, rs := r.(string)
+	if ls && rs {
+		return 0, 0, false
+	}
+
+	lf, ok := toNumber(l)
+	if !ok {
+		return 0, 0, false
+	}
+	rf, ok := toNumber(r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return lf, rf, true
+}
+
+func equal(l, r interface{}) bool {
+	if l == nil || r == nil {
+		return toString(l) == toString(r)
+	}
+
+	if lf, rf, ok := numbers(l, r); ok {
+		return lf == rf
+	}
+
+	return toString(l) == toString(r)
+}
+
+func compare(op string, l, r interface{}) bool {
+	var c int
+	if lf, rf, ok := numbers(l, r); ok {
+		switch {
+		case lf < rf:
+			c = -1
+		case lf > rf:
+			c = 1
+		}
+	} else {
+		c = strings.Compare(toString(l), toString(r))
+	}
+
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+
+	return c >= 0
+}
@@ -0,0 +1,94 @@
+package expression
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	t.Log("Eval")
+
+	env := EnvFromStrings(map[string]string{
+		"age":        "35",
+		"city":       "Kyiv",
+		"member.vip": "true",
+	})
+
+	cases := []struct {
+		src string
+		res interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"-age + 5", -30.0},
+		{"age > 30 && city == 'Kyiv'", true},
+		{"age >= 40 || !member.vip", false},
+		{"age > 30 ? 'senior' : 'junior'", "senior"},
+		{"city + '-' + age", "Kyiv-35"},
+		{"exists(city) && !exists(unknown)", true},
+		{"unknown == ''", true},
+		{"num(unknown, 7) + 1", 8.0},
+		{"in(lower(city), 'lviv', 'kyiv')", true},
+		{"max(1, age, 10) - min(3, 2)", 33.0},
+		{"len(city) % 3", 1.0},
+		{"starts_with(city, 'Ky') && ends_with(city, 'iv') && contains(city, 'yi')", true},
+	}
+
+	for _, c := range cases {
+		p, err := Compile(c.src)
+		if err != nil {
+			t.Errorf("%s: compile error %s", c.src, err.Error())
+			continue
+		}
+
+		res, err := p.Eval(env)
+		if err != nil {
+			t.Errorf("%s: eval error %s", c.src, err.Error())
+			continue
+		}
+
+		if res != c.res {
+			t.Errorf("%s: got %v, expected %v", c.src, res, c.res)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Log("CompileErrors")
+
+	for _, src := range []string{
+		"1 +",
+		"(1 + 2",
+		"exit(1)",
+		"len()",
+		"exists('a')",
+		"'abc",
+		"a $ b",
+		"1 ? 2",
+		strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100),
+		strings.Repeat("1+", 3000) + "1",
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%s: expected compile error", src)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	t.Log("EvalErrors")
+
+	for _, src := range []string{
+		"1 / 0",
+		"city * 2",
+		"round(city)",
+	} {
+		p, err := Compile(src)
+		if err != nil {
+			t.Errorf("%s: compile error %s", src, err.Error())
+			continue
+		}
+		if _, err = p.Eval(Env{"city": "Kyiv"}); err == nil {
+			t.Errorf("%s: expected eval error", src)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type function struct {
+	minArgs int
+	maxArgs int  // -1 unlimited
+	ident   bool // the arguments are the names of the variables
+	call    func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]*function{
+	"exists": {minArgs: 1, maxArgs: 1, ident: true, call: func(args []interface{}) (interface{}, error) {
+		return args[0], nil
+	}},
+	"num": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		if f, ok := toNumber(args[0]); ok && args[0] != nil && args[0] != "" {
+			return f, nil
+		}
+		if len(args) > 1 {
+			return args[1], nil
+		}
+		return 0.0, nil
+	}},
+	"str": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return toString(args[0]), nil
+	}},
+	"len": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return float64(len([]rune(toString(args[0])))), nil
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"contains": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	}},
+	"starts_with": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	}},
+	"ends_with": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+	}},
+	"in": {minArgs: 2, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, v := range args[1:] {
+			if equal(args[0], v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}},
+	"min": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return reduceNumbers("min", args, math.Min)
+	}},
+	"max": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return reduceNumbers("max", args, math.Max)
+	}},
+	"abs": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("abs: %v is not a number", args[0])
+		}
+		return math.Abs(f), nil
+	}},
+	"round": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("round: %v is not a number", args[0])
+		}
+		return math.Round(f), nil
+	}},
+}
+
+func reduceNumbers(name string, args []interface{}, fn func(a, b float64) float64) (interface{}, error) {
+	var res float64
+	for i, v := range args {
+		f, ok := toNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: %v is not a number", name, v)
+		}
+		if i == 0 {
+			res = f
+		} else {
+			res = fn(res, f)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,176 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	AmdService_Report_FullMethodName        = "/cc.AmdService/Report"
+	AmdService_Correct_FullMethodName       = "/cc.AmdService/Correct"
+	AmdService_DropVoicemail_FullMethodName = "/cc.AmdService/DropVoicemail"
+)
+
+type AmdServiceServer interface {
+	Report(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Correct(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	DropVoicemail(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// AmdService_ServiceDesc analytics of the answering machine detection,
+// Report request: model.SearchAmdReport, response: {"items": [model.AmdReport]}
+// Correct request: model.AmdCorrection
+// DropVoicemail request: model.VoicemailDropRequest, the agent leaves the message of the preview queue
+var AmdService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.AmdService",
+	HandlerType: (*AmdServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Report",
+			Handler:    _AmdService_Report_Handler,
+		},
+		{
+			MethodName: "Correct",
+			Handler:    _AmdService_Correct_Handler,
+		},
+		{
+			MethodName: "DropVoicemail",
+			Handler:    _AmdService_DropVoicemail_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_amd.proto",
+}
+
+func _AmdService_Report_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AmdServiceServer).Report(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AmdService_Report_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AmdServiceServer).Report(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AmdService_Correct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AmdServiceServer).Correct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AmdService_Correct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AmdServiceServer).Correct(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AmdService_DropVoicemail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AmdServiceServer).DropVoicemail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AmdService_DropVoicemail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AmdServiceServer).DropVoicemail(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type amd struct {
+	app *app.App
+}
+
+func NewAmdApi(a *app.App) *amd {
+	return &amd{app: a}
+}
+
+func (api *amd) Report(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.SearchAmdReport
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	items, err := api.app.AmdReport(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(map[string]interface{}{
+		"items": items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (api *amd) Correct(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.AmdCorrection
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+	req.UserId = model.NewInt64(session.GetUserId())
+
+	if err := api.app.CorrectAmd(&req); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
+
+func (api *amd) DropVoicemail(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.VoicemailDropRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	if err := api.app.DropVoicemail(&req); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
@@ -9,8 +9,18 @@ import (
 type API struct {
 	app *app.App
 
-	agent  *agent
-	member *member
+	agent          *agent
+	member         *member
+	wallboard      *wallboard
+	chatTranscript *chatTranscript
+	chatConference *chatConference
+	attemptJournal *attemptJournal
+	resourceHealth *resourceHealth
+	amd            *amd
+	lead           *lead
+	manual         *manualDistribution
+	memberImport   *memberImport
+	queueEstimate  *queueEstimate
 }
 
 func Init(a *app.App, server *grpc.Server) {
@@ -19,7 +29,27 @@ func Init(a *app.App, server *grpc.Server) {
 	}
 	api.agent = NewAgentApi(a)
 	api.member = NewMemberApi(a)
+	api.wallboard = NewWallboardApi(a)
+	api.chatTranscript = NewChatTranscriptApi(a)
+	api.chatConference = NewChatConferenceApi(a)
+	api.attemptJournal = NewAttemptJournalApi(a)
+	api.resourceHealth = NewResourceHealthApi(a)
+	api.amd = NewAmdApi(a)
+	api.lead = NewLeadApi(a)
+	api.manual = NewManualDistributionApi(a)
+	api.memberImport = NewMemberImportApi(a)
+	api.queueEstimate = NewQueueEstimateApi(a)
 
 	gogrpc.RegisterAgentServiceServer(server, api.agent)
 	gogrpc.RegisterMemberServiceServer(server, api.member)
+	server.RegisterService(&WallboardService_ServiceDesc, api.wallboard)
+	server.RegisterService(&ChatTranscriptService_ServiceDesc, api.chatTranscript)
+	server.RegisterService(&ChatConferenceService_ServiceDesc, api.chatConference)
+	server.RegisterService(&AttemptJournalService_ServiceDesc, api.attemptJournal)
+	server.RegisterService(&ResourceHealthService_ServiceDesc, api.resourceHealth)
+	server.RegisterService(&AmdService_ServiceDesc, api.amd)
+	server.RegisterService(&LeadService_ServiceDesc, api.lead)
+	server.RegisterService(&ManualDistributionService_ServiceDesc, api.manual)
+	server.RegisterService(&MemberImportService_ServiceDesc, api.memberImport)
+	server.RegisterService(&QueueEstimateService_ServiceDesc, api.queueEstimate)
 }
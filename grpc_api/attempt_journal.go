@@ -0,0 +1,88 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	AttemptJournalService_Search_FullMethodName = "/cc.AttemptJournalService/Search"
+)
+
+type AttemptJournalServiceServer interface {
+	Search(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// AttemptJournalService_ServiceDesc the read access to the queues of the domain of the session is required,
+// request: {"domain_id": 1, "attempt_id": 1} or {"domain_id": 1, "member_id": 1, "limit": 10},
+// response: {"items": [model.AttemptJournal]}
+var AttemptJournalService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.AttemptJournalService",
+	HandlerType: (*AttemptJournalServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _AttemptJournalService_Search_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_attempt_journal.proto",
+}
+
+func _AttemptJournalService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AttemptJournalServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AttemptJournalService_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AttemptJournalServiceServer).Search(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type attemptJournal struct {
+	app *app.App
+}
+
+func NewAttemptJournalApi(a *app.App) *attemptJournal {
+	return &attemptJournal{app: a}
+}
+
+func (api *attemptJournal) Search(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.SearchAttemptJournal
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	items, err := api.app.SearchAttemptJournal(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(map[string]interface{}{
+		"items": items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,189 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/chat"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	ChatConferenceService_Invite_FullMethodName   = "/cc.ChatConferenceService/Invite"
+	ChatConferenceService_Handover_FullMethodName = "/cc.ChatConferenceService/Handover"
+	ChatConferenceService_Leave_FullMethodName    = "/cc.ChatConferenceService/Leave"
+)
+
+type ChatConferenceServiceServer interface {
+	Invite(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Handover(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Leave(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// ChatConferenceService_ServiceDesc request: {"domain_id": 1, "conversation_id": "", "from_user_id": 1, "user_id": 2,
+// "role": "consult|participant", "timeout": 30, "title": ""}, response: {}
+var ChatConferenceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.ChatConferenceService",
+	HandlerType: (*ChatConferenceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Invite",
+			Handler:    _ChatConferenceService_Invite_Handler,
+		},
+		{
+			MethodName: "Handover",
+			Handler:    _ChatConferenceService_Handover_Handler,
+		},
+		{
+			MethodName: "Leave",
+			Handler:    _ChatConferenceService_Leave_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_chat_conference.proto",
+}
+
+func _ChatConferenceService_Invite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatConferenceServiceServer).Invite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatConferenceService_Invite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatConferenceServiceServer).Invite(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatConferenceService_Handover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatConferenceServiceServer).Handover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatConferenceService_Handover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatConferenceServiceServer).Handover(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatConferenceService_Leave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatConferenceServiceServer).Leave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatConferenceService_Leave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatConferenceServiceServer).Leave(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type chatConferenceRequest struct {
+	DomainId       int64  `json:"domain_id"`
+	ConversationId string `json:"conversation_id"`
+	FromUserId     int64  `json:"from_user_id"`
+	UserId         int64  `json:"user_id"`
+	Role           string `json:"role"`
+	Timeout        uint16 `json:"timeout"`
+	Title          string `json:"title"`
+}
+
+type chatConference struct {
+	app *app.App
+}
+
+func NewChatConferenceApi(a *app.App) *chatConference {
+	return &chatConference{app: a}
+}
+
+// authorize the session of the domain, the from user of the agent is the user of the session
+func (api *chatConference) authorize(ctx context.Context, req *chatConferenceRequest) (*auth_manager.Session, *model.AppError) {
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, "", auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+	if req.FromUserId == 0 {
+		req.FromUserId = session.GetUserId()
+	}
+
+	return session, nil
+}
+
+func (api *chatConference) Invite(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req chatConferenceRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.authorize(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = api.app.ChatInviteParticipant(ctx, session, req.ConversationId, req.FromUserId, req.UserId,
+		chat.ChatSessionRole(req.Role), req.Timeout, req.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
+
+func (api *chatConference) Handover(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req chatConferenceRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.authorize(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = api.app.ChatHandover(session, req.ConversationId, req.FromUserId, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
+
+func (api *chatConference) Leave(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req chatConferenceRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.authorize(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = api.app.ChatLeaveParticipant(session, req.ConversationId, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
@@ -0,0 +1,140 @@
+package grpc_api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	ChatTranscriptService_Export_FullMethodName = "/cc.ChatTranscriptService/Export"
+)
+
+type ChatTranscriptServiceServer interface {
+	Export(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// ChatTranscriptService_ServiceDesc request: {"domain_id": 1, "attempt_id": 1, "format": "json|text|html", "store": false},
+// response: {"attempt_id": 1, "format": "text", "content_type": "text/plain", "content": "..."},
+// with "store" the content is written to the file storage and the response has "location", the key of the file in the storage, instead of "content"
+var ChatTranscriptService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.ChatTranscriptService",
+	HandlerType: (*ChatTranscriptServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    _ChatTranscriptService_Export_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_chat_transcript.proto",
+}
+
+func _ChatTranscriptService_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatTranscriptServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChatTranscriptService_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatTranscriptServiceServer).Export(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type chatTranscriptExportRequest struct {
+	DomainId  int64  `json:"domain_id"`
+	AttemptId int64  `json:"attempt_id"`
+	Format    string `json:"format"`
+	Store     bool   `json:"store"`
+}
+
+type chatTranscriptExportResponse struct {
+	AttemptId   int64  `json:"attempt_id"`
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content,omitempty"`
+	Location    string `json:"location,omitempty"`
+}
+
+type chatTranscript struct {
+	app *app.App
+}
+
+func NewChatTranscriptApi(a *app.App) *chatTranscript {
+	return &chatTranscript{app: a}
+}
+
+func (api *chatTranscript) Export(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req chatTranscriptExportRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	// the stored transcript is written to the file storage of the domain
+	if req.Store {
+		if err = api.app.CheckAccess(session, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE); err != nil {
+			return nil, err
+		}
+	}
+	req.DomainId = session.GetDomainId()
+
+	t, err := api.app.ChatTranscript(req.DomainId, req.AttemptId)
+	if err != nil {
+		return nil, err
+	}
+
+	data, contentType, err := t.Export(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	res := chatTranscriptExportResponse{
+		AttemptId:   req.AttemptId,
+		Format:      req.Format,
+		ContentType: contentType,
+	}
+
+	if req.Store {
+		res.Location, err = api.app.StoreFile(chatTranscriptExportPath(req.DomainId, req.AttemptId, req.Format), data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		res.Content = string(data)
+	}
+
+	out, err := toStruct(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func chatTranscriptExportPath(domainId int64, attemptId int64, format string) string {
+	ext := format
+	switch format {
+	case "", model.ChatTranscriptFormatJson:
+		ext = "json"
+	case model.ChatTranscriptFormatText:
+		ext = "txt"
+	}
+
+	return fmt.Sprintf("exports/%d/transcript_%d.%s", domainId, attemptId, ext)
+}
@@ -0,0 +1,85 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	LeadService_Score_FullMethodName = "/cc.LeadService/Score"
+)
+
+type LeadServiceServer interface {
+	Score(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// LeadService_ServiceDesc the lead scoring of the outbound queues,
+// Score request: model.SearchLeadScore, response: model.LeadScore with the explanation
+var LeadService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.LeadService",
+	HandlerType: (*LeadServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Score",
+			Handler:    _LeadService_Score_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_lead.proto",
+}
+
+func _LeadService_Score_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LeadServiceServer).Score(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LeadService_Score_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LeadServiceServer).Score(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type lead struct {
+	app *app.App
+}
+
+func NewLeadApi(a *app.App) *lead {
+	return &lead{app: a}
+}
+
+func (api *lead) Score(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.SearchLeadScore
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	score, err := api.app.LeadScore(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(score)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,181 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	ManualDistributionService_List_FullMethodName    = "/cc.ManualDistributionService/List"
+	ManualDistributionService_Reserve_FullMethodName = "/cc.ManualDistributionService/Reserve"
+	ManualDistributionService_Release_FullMethodName = "/cc.ManualDistributionService/Release"
+)
+
+type ManualDistributionServiceServer interface {
+	List(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Reserve(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Release(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// ManualDistributionService_ServiceDesc the agent pulls the waiting attempts of the manual distribution queues,
+// List request: model.SearchManualAttempt, response: {"items": [model.ManualAttempt]}
+// Reserve request: model.ManualReservation, response: model.ManualReserved, the conflict is the error with the code 409
+// Release request: model.ManualReservation, response: {}
+var ManualDistributionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.ManualDistributionService",
+	HandlerType: (*ManualDistributionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _ManualDistributionService_List_Handler,
+		},
+		{
+			MethodName: "Reserve",
+			Handler:    _ManualDistributionService_Reserve_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _ManualDistributionService_Release_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_manual_distribution.proto",
+}
+
+func _ManualDistributionService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManualDistributionServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManualDistributionService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManualDistributionServiceServer).List(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManualDistributionService_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManualDistributionServiceServer).Reserve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManualDistributionService_Reserve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManualDistributionServiceServer).Reserve(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManualDistributionService_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManualDistributionServiceServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManualDistributionService_Release_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManualDistributionServiceServer).Release(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type manualDistribution struct {
+	app *app.App
+}
+
+func NewManualDistributionApi(a *app.App) *manualDistribution {
+	return &manualDistribution{app: a}
+}
+
+func (api *manualDistribution) List(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.SearchManualAttempt
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeAgent, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	items, err := api.app.ManualAttempts(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(map[string]interface{}{
+		"items": items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (api *manualDistribution) Reserve(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.ManualReservation
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeAgent, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	res, err := api.app.ManualReserve(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (api *manualDistribution) Release(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req model.ManualReservation
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeAgent, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	if err := api.app.ManualRelease(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
@@ -0,0 +1,209 @@
+package grpc_api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	MemberImportService_Import_FullMethodName = "/cc.MemberImportService/Import"
+	MemberImportService_Export_FullMethodName = "/cc.MemberImportService/Export"
+)
+
+type MemberImportServiceServer interface {
+	Import(grpc.ClientStreamingServer[structpb.Struct, structpb.Struct]) error
+	Export(*structpb.Struct, grpc.ServerStreamingServer[structpb.Struct]) error
+}
+
+// MemberImportService_ServiceDesc the bulk load of the members to the outbound queue,
+// Import request stream: model.MemberImportRequest then {"content": "..."} with the next chunks of the file
+// (without the chunks when the file is the location of the storage), response: model.MemberImportResult with the errors of the rows,
+// Export request: model.MemberExportRequest, response stream: {"content": "..."} with the chunks of the file (without the chunks
+// when it's stored) then model.MemberExportResponse
+var MemberImportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.MemberImportService",
+	HandlerType: (*MemberImportServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Import",
+			Handler:       _MemberImportService_Import_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _MemberImportService_Export_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cc_member_import.proto",
+}
+
+func _MemberImportService_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MemberImportServiceServer).Import(&grpc.GenericServerStream[structpb.Struct, structpb.Struct]{ServerStream: stream})
+}
+
+func _MemberImportService_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(structpb.Struct)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemberImportServiceServer).Export(m, &grpc.GenericServerStream[structpb.Struct, structpb.Struct]{ServerStream: stream})
+}
+
+const memberExportChunkSize = 64 * 1024
+
+type memberImport struct {
+	app *app.App
+}
+
+func NewMemberImportApi(a *app.App) *memberImport {
+	return &memberImport{app: a}
+}
+
+func (api *memberImport) Import(stream grpc.ClientStreamingServer[structpb.Struct, structpb.Struct]) error {
+	in, e := stream.Recv()
+	if e != nil {
+		return e
+	}
+
+	var req model.MemberImportRequest
+	if err := fromStruct(in, &req); err != nil {
+		return err
+	}
+
+	session, err := api.app.AuthorizeDomain(stream.Context(), req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return err
+	}
+	req.DomainId = session.GetDomainId()
+
+	var src io.Reader
+	if req.Location == "" {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go receiveMemberImport(stream, req.Content, pw)
+		src = pr
+	}
+
+	res, err := api.app.ImportMembers(stream.Context(), &req, src)
+	if err != nil {
+		return err
+	}
+
+	out, err := toStruct(res)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(out)
+}
+
+// receiveMemberImport writes the content of the request and the chunks of the stream to the reader of the import
+func receiveMemberImport(stream grpc.ClientStreamingServer[structpb.Struct, structpb.Struct], content string, pw *io.PipeWriter) {
+	if _, err := io.WriteString(pw, content); err != nil {
+		return
+	}
+
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			pw.Close()
+			return
+		} else if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		if _, err = io.WriteString(pw, in.GetFields()["content"].GetStringValue()); err != nil {
+			return
+		}
+	}
+}
+
+func (api *memberImport) Export(in *structpb.Struct, stream grpc.ServerStreamingServer[structpb.Struct]) error {
+	var req model.MemberExportRequest
+	if err := fromStruct(in, &req); err != nil {
+		return err
+	}
+
+	session, err := api.app.AuthorizeDomain(stream.Context(), req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return err
+	}
+	req.DomainId = session.GetDomainId()
+
+	var res *model.MemberExportResponse
+	if req.Store {
+		res, err = api.app.StoreMembersExport(stream.Context(), &req, memberExportPath(req.DomainId, req.QueueId, req.Format))
+	} else {
+		w := &memberExportWriter{stream: stream}
+		if res, err = api.app.ExportMembers(stream.Context(), &req, w); err == nil {
+			err = w.flush()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := toStruct(res)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(out)
+}
+
+// memberExportWriter sends the chunks of the export by the lines, so the chunk is the valid utf-8 string
+type memberExportWriter struct {
+	stream grpc.ServerStreamingServer[structpb.Struct]
+	buf    bytes.Buffer
+}
+
+func (w *memberExportWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	if w.buf.Len() < memberExportChunkSize {
+		return len(p), nil
+	}
+
+	if i := bytes.LastIndexByte(w.buf.Bytes(), '\n'); i >= 0 {
+		if err := w.send(w.buf.Next(i + 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *memberExportWriter) flush() *model.AppError {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	if err := w.send(w.buf.Next(w.buf.Len())); err != nil {
+		return model.NewAppError("ExportMembers", "app.member_export.write.app_error", nil,
+			err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (w *memberExportWriter) send(chunk []byte) error {
+	return w.stream.Send(&structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"content": structpb.NewStringValue(string(chunk)),
+		},
+	})
+}
+
+func memberExportPath(domainId int64, queueId int, format string) string {
+	return fmt.Sprintf("exports/%d/members_%d_%d.%s", domainId, queueId, model.GetMillis(), format)
+}
@@ -0,0 +1,91 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	QueueEstimateService_Estimate_FullMethodName = "/cc.QueueEstimateService/Estimate"
+)
+
+type QueueEstimateServiceServer interface {
+	Estimate(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// QueueEstimateService_ServiceDesc expected wait of the caller before the join to the inbound queue, used by the flow,
+// request: {"domain_id": 1, "queue_id": 1, "priority": 0}, response: model.QueueEstimate
+var QueueEstimateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.QueueEstimateService",
+	HandlerType: (*QueueEstimateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Estimate",
+			Handler:    _QueueEstimateService_Estimate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_queue_estimate.proto",
+}
+
+func _QueueEstimateService_Estimate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueueEstimateServiceServer).Estimate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueueEstimateService_Estimate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueueEstimateServiceServer).Estimate(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type queueEstimate struct {
+	app *app.App
+}
+
+func NewQueueEstimateApi(a *app.App) *queueEstimate {
+	return &queueEstimate{app: a}
+}
+
+type queueEstimateRequest struct {
+	DomainId int64 `json:"domain_id"`
+	QueueId  int   `json:"queue_id"`
+	Priority int   `json:"priority"`
+}
+
+func (api *queueEstimate) Estimate(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req queueEstimateRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	res, err := api.app.QueueEstimate(req.DomainId, req.QueueId, req.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := toStruct(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
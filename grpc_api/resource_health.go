@@ -0,0 +1,135 @@
+package grpc_api
+
+import (
+	"context"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	ResourceHealthService_List_FullMethodName  = "/cc.ResourceHealthService/List"
+	ResourceHealthService_Reset_FullMethodName = "/cc.ResourceHealthService/Reset"
+)
+
+type ResourceHealthServiceServer interface {
+	List(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Reset(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+// ResourceHealthService_ServiceDesc health of the outbound resources, the states of the breakers are shared by the nodes,
+// the statistics are of the calls of this node, the domain is taken from the session
+// List request: {}, response: {"resources": [model.ResourceHealth], "gateways": [model.GatewayHealth]}
+// Reset request: {"resource_id": 1}, the breaker is reset on all nodes
+var ResourceHealthService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.ResourceHealthService",
+	HandlerType: (*ResourceHealthServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _ResourceHealthService_List_Handler,
+		},
+		{
+			MethodName: "Reset",
+			Handler:    _ResourceHealthService_Reset_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cc_resource_health.proto",
+}
+
+func _ResourceHealthService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHealthServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceHealthService_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHealthServiceServer).List(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ResourceHealthService_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResourceHealthServiceServer).Reset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResourceHealthService_Reset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResourceHealthServiceServer).Reset(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+type resourceHealth struct {
+	app *app.App
+}
+
+func NewResourceHealthApi(a *app.App) *resourceHealth {
+	return &resourceHealth{app: a}
+}
+
+type resourceHealthRequest struct {
+	DomainId   int64 `json:"domain_id"`
+	ResourceId int   `json:"resource_id"`
+}
+
+func (api *resourceHealth) List(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req resourceHealthRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeResource, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	resources, gateways := api.app.ResourceHealth(req.DomainId)
+
+	out, err := toStruct(map[string]interface{}{
+		"resources": resources,
+		"gateways":  gateways,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (api *resourceHealth) Reset(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req resourceHealthRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+
+	session, err := api.app.AuthorizeDomain(ctx, req.DomainId, model.PermissionScopeResource, auth_manager.PERMISSION_ACCESS_UPDATE)
+	if err != nil {
+		return nil, err
+	}
+	req.DomainId = session.GetDomainId()
+
+	if err := api.app.ResetResourceHealth(req.DomainId, req.ResourceId); err != nil {
+		return nil, err
+	}
+
+	return &structpb.Struct{}, nil
+}
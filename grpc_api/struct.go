@@ -0,0 +1,39 @@
+package grpc_api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/webitel/call_center/model"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// services without generated messages use google.protobuf.Struct with the json of the model
+
+func fromStruct(in *structpb.Struct, v interface{}) *model.AppError {
+	data, err := protojson.Marshal(in)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+
+	if err != nil {
+		return model.NewAppError("GRPC.FromStruct", "grpc.struct.decode.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func toStruct(v interface{}) (*structpb.Struct, *model.AppError) {
+	out := &structpb.Struct{}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = protojson.Unmarshal(data, out)
+	}
+
+	if err != nil {
+		return nil, model.NewAppError("GRPC.ToStruct", "grpc.struct.encode.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package grpc_api
+
+import (
+	"time"
+
+	"github.com/webitel/call_center/app"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/engine/auth_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	WallboardService_Subscribe_FullMethodName = "/cc.WallboardService/Subscribe"
+)
+
+type WallboardServiceServer interface {
+	Subscribe(*structpb.Struct, grpc.ServerStreamingServer[structpb.Struct]) error
+}
+
+// WallboardService_ServiceDesc request: {"domain_id": 1, "queue_ids": [1], "team_ids": [1], "interval": 10},
+// response stream of model.WallboardSnapshot, the domain of the access token with the read access to the queues is required
+var WallboardService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cc.WallboardService",
+	HandlerType: (*WallboardServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _WallboardService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cc_wallboard.proto",
+}
+
+func _WallboardService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(structpb.Struct)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WallboardServiceServer).Subscribe(m, &grpc.GenericServerStream[structpb.Struct, structpb.Struct]{ServerStream: stream})
+}
+
+type wallboardSubscribeRequest struct {
+	DomainId int64 `json:"domain_id"`
+	QueueIds []int `json:"queue_ids"`
+	TeamIds  []int `json:"team_ids"`
+	Interval int   `json:"interval"` // sec
+}
+
+type wallboard struct {
+	app *app.App
+}
+
+func NewWallboardApi(a *app.App) *wallboard {
+	return &wallboard{app: a}
+}
+
+func (api *wallboard) Subscribe(in *structpb.Struct, out grpc.ServerStreamingServer[structpb.Struct]) error {
+	var req wallboardSubscribeRequest
+	if err := fromStruct(in, &req); err != nil {
+		return err
+	}
+
+	session, err := api.app.AuthorizeDomain(out.Context(), req.DomainId, model.PermissionScopeQueue, auth_manager.PERMISSION_ACCESS_READ)
+	if err != nil {
+		return err
+	}
+
+	w := api.app.Queue().Manager().Wallboard()
+	sub, err := w.Subscribe(session.GetDomainId(), req.QueueIds, req.TeamIds, time.Duration(req.Interval)*time.Second)
+	if err != nil {
+		return err
+	}
+	defer w.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-out.Context().Done():
+			return nil
+		case snapshot := <-sub.Snapshots():
+			msg, err := toStruct(snapshot)
+			if err != nil {
+				return err
+			}
+			if e := out.Send(msg); e != nil {
+				return e
+			}
+		}
+	}
+}
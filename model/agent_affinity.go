@@ -0,0 +1,118 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	AffinityLastAgent      = "last_agent"
+	AffinityPreferredAgent = "preferred_agent"
+	AffinityPreferredTeam  = "preferred_team"
+	AffinityAny            = "any"
+
+	QueueAffinityVariable                = "cc_affinity"
+	QueueAffinityPreferredAgentsVariable = "cc_preferred_agents"
+	QueueAffinityPreferredTeamVariable   = "cc_preferred_team"
+	QueueAffinityVipVariable             = "cc_vip"
+
+	AffinityMin = 0.05 // below the strength the attempt is offered to any agent
+
+	defaultAffinityLookbackDays = 30
+	defaultAffinityHalfLife     = 30
+	defaultAffinityVipAgents    = 5
+	maxAffinityVipAgents        = 100
+)
+
+// QueueAffinity the preferred agents of the attempt, the preference degrades by the half-life of the waiting,
+// configured in the queue payload: {"affinity": {"last_agent": true, "preferred_team_id": 1, "vip": "usr_segment == \"gold\"", "vip_priority": 100}}
+type QueueAffinity struct {
+	LastAgent       bool   `json:"last_agent"`        // the last agent who handled the member in any channel
+	LookbackDays    int    `json:"lookback_days"`     // def 30
+	PreferredAgents []int  `json:"preferred_agents"`  // and the variable cc_preferred_agents "1,2,3"
+	PreferredTeamId *int   `json:"preferred_team_id"` // and the variable cc_preferred_team
+	Vip             string `json:"vip"`               // expression, def the variable cc_vip
+	VipPriority     int    `json:"vip_priority"`      // added to the weight of the vip attempt
+	VipAgents       int    `json:"vip_agents"`        // the best skilled agents of the queue are preferred for the vip, def 5
+	HalfLife        uint32 `json:"half_life"`         // sec, def 30
+}
+
+// AttemptAffinity the affinity of the attempt for the distributor, the agent is reserved when the weight of the agent
+// or of the team is not weaker than the best weight degraded by the half-life since the start
+type AttemptAffinity struct {
+	Since      int64              `json:"since"` // ms
+	HalfLife   uint32             `json:"half_life"`
+	Best       float64            `json:"best"`
+	Min        float64            `json:"min"`
+	Agents     map[string]float64 `json:"agents,omitempty"`
+	TeamId     int                `json:"team_id,omitempty"`
+	TeamWeight float64            `json:"team_weight,omitempty"`
+}
+
+func (a *AttemptAffinity) ToJson() []byte {
+	data, _ := json.Marshal(a)
+	return data
+}
+
+func QueueAffinityFromBytes(data []byte) *QueueAffinity {
+	var payload struct {
+		Affinity *QueueAffinity `json:"affinity"`
+	}
+	json.Unmarshal(data, &payload)
+
+	return payload.Affinity
+}
+
+func (a *QueueAffinity) LookbackDaysOrDefault() int {
+	if a.LookbackDays <= 0 {
+		return defaultAffinityLookbackDays
+	}
+
+	return a.LookbackDays
+}
+
+func (a *QueueAffinity) HalfLifeSec() uint32 {
+	if a.HalfLife == 0 {
+		return defaultAffinityHalfLife
+	}
+
+	return a.HalfLife
+}
+
+func (a *QueueAffinity) VipAgentsLimit() int {
+	switch {
+	case a.VipAgents <= 0:
+		return defaultAffinityVipAgents
+	case a.VipAgents > maxAffinityVipAgents:
+		return maxAffinityVipAgents
+	}
+
+	return a.VipAgents
+}
+
+// AffinityWeight the strength of the affinity level before the degradation
+func AffinityWeight(level string) float64 {
+	switch level {
+	case AffinityLastAgent:
+		return 1
+	case AffinityPreferredAgent:
+		return 0.75
+	case AffinityPreferredTeam:
+		return 0.5
+	}
+
+	return 0
+}
+
+// ParseAgentIds the list of the agent ids separated by the comma
+func ParseAgentIds(s string) []int {
+	var res []int
+	for _, v := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && id > 0 {
+			res = append(res, id)
+		}
+	}
+
+	return res
+}
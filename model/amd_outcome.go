@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+)
+
+const (
+	AmdOutcomeHuman   = "human"
+	AmdOutcomeMachine = "machine"
+	AmdOutcomeNotSure = "not_sure"
+
+	QueueAmdCorrectionVariable = "cc_amd_correction" // human or machine, reported by the agent
+)
+
+// AmdOutcome result of the answering machine detection of the attempt,
+// Correction is reported by the agent or the supervisor
+type AmdOutcome struct {
+	AttemptId       int64           `json:"attempt_id" db:"attempt_id"`
+	DomainId        int64           `json:"domain_id" db:"domain_id"`
+	QueueId         int             `json:"queue_id" db:"queue_id"`
+	SettingsVersion string          `json:"settings_version" db:"settings_version"`
+	Settings        json.RawMessage `json:"settings,omitempty" db:"-"`
+	Ai              bool            `json:"ai" db:"ai"`
+	Result          string          `json:"result" db:"result"`
+	AmdResult       string          `json:"amd_result,omitempty" db:"amd_result"`
+	AmdCause        string          `json:"amd_cause,omitempty" db:"amd_cause"`
+	AiResult        string          `json:"ai_result,omitempty" db:"ai_result"`
+	AiError         string          `json:"ai_error,omitempty" db:"ai_error"`
+	Human           bool            `json:"human" db:"human"`
+	DurationMs      int64           `json:"duration_ms" db:"duration_ms"`
+	CreatedAt       int64           `json:"created_at" db:"created_at"`
+	Correction      *string         `json:"correction,omitempty" db:"correction"`
+}
+
+type AmdCorrection struct {
+	DomainId  int64  `json:"domain_id"`
+	AttemptId int64  `json:"attempt_id"`
+	Result    string `json:"result"`
+	UserId    *int64 `json:"user_id,omitempty"`
+}
+
+func (c *AmdCorrection) IsValid() *AppError {
+	if c.DomainId == 0 {
+		return NewAppError("AmdCorrection.IsValid", "model.amd_correction.is_valid.domain_id", nil, "domain_id is required", http.StatusBadRequest)
+	}
+
+	if c.AttemptId == 0 {
+		return NewAppError("AmdCorrection.IsValid", "model.amd_correction.is_valid.attempt_id", nil, "attempt_id is required", http.StatusBadRequest)
+	}
+
+	if c.Result != AmdOutcomeHuman && c.Result != AmdOutcomeMachine {
+		return NewAppError("AmdCorrection.IsValid", "model.amd_correction.is_valid.result", nil,
+			fmt.Sprintf("bad result \"%s\"", c.Result), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+type SearchAmdReport struct {
+	DomainId int64  `json:"domain_id"`
+	QueueId  *int   `json:"queue_id"`
+	Since    int64  `json:"since"`
+	Until    int64  `json:"until"`
+	Version  string `json:"settings_version"`
+}
+
+// AmdReport per queue and settings version, the false positive - the machine that was the human,
+// the false negative - the human that was the machine
+type AmdReport struct {
+	QueueId           int     `json:"queue_id" db:"queue_id"`
+	SettingsVersion   string  `json:"settings_version" db:"settings_version"`
+	Settings          *string `json:"settings,omitempty" db:"settings"`
+	Total             int     `json:"total" db:"total"`
+	Human             int     `json:"human" db:"human"`
+	Machine           int     `json:"machine" db:"machine"`
+	NotSure           int     `json:"not_sure" db:"not_sure"`
+	Corrected         int     `json:"corrected" db:"corrected"`
+	FalsePositive     int     `json:"false_positive" db:"false_positive"`
+	FalseNegative     int     `json:"false_negative" db:"false_negative"`
+	FalsePositiveRate float64 `json:"false_positive_rate" db:"false_positive_rate"`
+	FalseNegativeRate float64 `json:"false_negative_rate" db:"false_negative_rate"`
+	AvgDurationMs     int64   `json:"avg_duration_ms" db:"avg_duration_ms"`
+	FirstAt           int64   `json:"first_at" db:"first_at"`
+	LastAt            int64   `json:"last_at" db:"last_at"`
+}
+
+// Version hash of the tuning settings, the outcomes of the same settings are compared in the report
+func (amd *QueueAmdSettings) Version() string {
+	h := fnv.New32a()
+	h.Write([]byte(amd.ToArgs()))
+	if amd.Ai {
+		h.Write([]byte("ai:" + amd.AiTags()))
+	}
+	if amd.AllowNotSure {
+		h.Write([]byte("not_sure"))
+	}
+
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func (amd *QueueAmdSettings) ToJson() []byte {
+	data, _ := json.Marshal(amd)
+	return data
+}
@@ -0,0 +1,40 @@
+package model
+
+import "testing"
+
+func TestQueueAmdSettingsVersion(t *testing.T) {
+	t.Log("QueueAmdSettingsVersion")
+
+	a := &QueueAmdSettings{Enabled: true, MaxNumberOfWords: 3}
+	b := &QueueAmdSettings{Enabled: true}
+	if a.Version() != b.Version() {
+		t.Errorf("default values: expected the same version, got %s %s", a.Version(), b.Version())
+	}
+
+	c := &QueueAmdSettings{Enabled: true, MaxNumberOfWords: 4}
+	if a.Version() == c.Version() {
+		t.Errorf("changed settings: expected the new version")
+	}
+
+	d := &QueueAmdSettings{Enabled: true, Ai: true, PositiveTags: []string{"human"}}
+	e := &QueueAmdSettings{Enabled: true, Ai: true, PositiveTags: []string{"human", "ivr"}}
+	if d.Version() == e.Version() || d.Version() == b.Version() {
+		t.Errorf("ai tags: expected the new version")
+	}
+}
+
+func TestAmdCorrectionIsValid(t *testing.T) {
+	t.Log("AmdCorrectionIsValid")
+
+	if err := (&AmdCorrection{DomainId: 1, AttemptId: 1, Result: AmdOutcomeMachine}).IsValid(); err != nil {
+		t.Errorf("valid: %s", err.Error())
+	}
+
+	if err := (&AmdCorrection{AttemptId: 1, Result: AmdOutcomeMachine}).IsValid(); err == nil {
+		t.Errorf("domain: expected error")
+	}
+
+	if err := (&AmdCorrection{DomainId: 1, AttemptId: 1, Result: AmdOutcomeNotSure}).IsValid(); err == nil {
+		t.Errorf("not_sure: expected error")
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import "net/http"
+
+const (
+	AttemptJournalState       = "state"
+	AttemptJournalInfo        = "info"
+	AttemptJournalResource    = "resource"
+	AttemptJournalHook        = "hook"
+	AttemptJournalSchema      = "schema"
+	AttemptJournalAmd         = "amd"
+	AttemptJournalVoicemail   = "voicemail"
+	AttemptJournalDisposition = "disposition"
+	AttemptJournalRouting     = "routing"
+	AttemptJournalWrapUp      = "wrap_up"
+
+	AttemptJournalMaxLimit = 500
+)
+
+type AttemptJournalEvent struct {
+	At    int64                  `json:"at"`
+	Event string                 `json:"event"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+type AttemptJournal struct {
+	AttemptId int64                 `json:"attempt_id" db:"attempt_id"`
+	DomainId  int64                 `json:"domain_id" db:"domain_id"`
+	QueueId   int                   `json:"queue_id" db:"queue_id"`
+	MemberId  *int64                `json:"member_id,omitempty" db:"member_id"`
+	Channel   string                `json:"channel" db:"channel"`
+	Result    string                `json:"result" db:"result"`
+	JoinedAt  int64                 `json:"joined_at" db:"joined_at"`
+	LeavingAt int64                 `json:"leaving_at" db:"leaving_at"`
+	Events    []AttemptJournalEvent `json:"events" db:"-"`
+}
+
+type SearchAttemptJournal struct {
+	DomainId  int64  `json:"domain_id"`
+	AttemptId *int64 `json:"attempt_id"`
+	MemberId  *int64 `json:"member_id"`
+	Limit     int    `json:"limit"`
+}
+
+func (s *SearchAttemptJournal) IsValid() *AppError {
+	if s.DomainId == 0 {
+		return NewAppError("SearchAttemptJournal.IsValid", "model.search_attempt_journal.is_valid.domain_id", nil,
+			"domain_id is required", http.StatusBadRequest)
+	}
+
+	if s.AttemptId == nil && s.MemberId == nil {
+		return NewAppError("SearchAttemptJournal.IsValid", "model.search_attempt_journal.is_valid.filter", nil,
+			"attempt_id or member_id is required", http.StatusBadRequest)
+	}
+
+	if s.Limit < 0 || s.Limit > AttemptJournalMaxLimit {
+		return NewAppError("SearchAttemptJournal.IsValid", "model.search_attempt_journal.is_valid.limit", nil,
+			"limit is out of range", http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package model
+
+import "testing"
+
+func TestSearchAttemptJournalIsValid(t *testing.T) {
+	t.Log("SearchAttemptJournalIsValid")
+
+	id := NewInt64(1)
+	tests := []struct {
+		search SearchAttemptJournal
+		ok     bool
+	}{
+		{SearchAttemptJournal{DomainId: 1, AttemptId: id}, true},
+		{SearchAttemptJournal{DomainId: 1, MemberId: id, Limit: AttemptJournalMaxLimit}, true},
+		{SearchAttemptJournal{AttemptId: id}, false},
+		{SearchAttemptJournal{DomainId: 1}, false},
+		{SearchAttemptJournal{DomainId: 1, MemberId: id, Limit: AttemptJournalMaxLimit + 1}, false},
+		{SearchAttemptJournal{DomainId: 1, MemberId: id, Limit: -1}, false},
+	}
+
+	for i, v := range tests {
+		if err := v.search.IsValid(); (err == nil) != v.ok {
+			t.Errorf("case %d: expected valid %v, got %v", i, v.ok, err)
+		}
+	}
+}
@@ -2,5 +2,6 @@ package model
 
 func NewBool(b bool) *bool       { return &b }
 func NewInt(n int) *int          { return &n }
+func NewInt32(n int32) *int32    { return &n }
 func NewInt64(n int64) *int64    { return &n }
 func NewString(s string) *string { return &s }
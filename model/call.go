@@ -267,6 +267,19 @@ type CallActionAMD struct {
 	Cause  string `json:"cause"`  // deprecated
 }
 
+type CallActionDtmf struct {
+	CallAction
+	Digit string `json:"digit"`
+}
+
+func (c *CallActionDtmf) Rune() rune {
+	for _, r := range c.Digit {
+		return r
+	}
+
+	return 0
+}
+
 type CallVariables map[string]interface{}
 
 func (c *CallActionData) GetEvent() interface{} {
@@ -299,6 +312,11 @@ func (c *CallActionData) GetEvent() interface{} {
 			CallAction: c.CallAction,
 		}
 
+	case CallActionDtmfName:
+		c.parsed = &CallActionDtmf{
+			CallAction: c.CallAction,
+		}
+
 	case CallActionHangupName:
 		c.parsed = &CallActionHangup{
 			CallAction: c.CallAction,
@@ -62,3 +62,41 @@ func (c ChatEvent) InviteId() string {
 	i, _ := c.Data["invite_id"].(string)
 	return i
 }
+
+type ChatMessage struct {
+	Id        int64  `json:"id"`
+	ChannelId string `json:"channel_id"`
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	FromId    int64  `json:"from_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func (c ChatEvent) Message() *ChatMessage {
+	msg := &ChatMessage{
+		ChannelId: c.MessageChannelId(),
+		CreatedAt: c.Timestamp(),
+	}
+
+	if m, ok := c.Data["message"].(map[string]interface{}); ok {
+		if i, ok := m["id"].(float64); ok {
+			msg.Id = int64(i)
+		}
+		msg.Type, _ = m["type"].(string)
+		msg.Text, _ = m["text"].(string)
+		if i, ok := m["created_at"].(float64); ok {
+			msg.CreatedAt = int64(i)
+		}
+		if from, ok := m["from"].(map[string]interface{}); ok {
+			if i, ok := from["id"].(float64); ok {
+				msg.FromId = int64(i)
+			}
+		}
+	}
+
+	if msg.CreatedAt == 0 {
+		msg.CreatedAt = GetMillis()
+	}
+
+	return msg
+}
@@ -0,0 +1,54 @@
+package model
+
+const (
+	ChatAlertSentimentScoreVariable = "cc_sentiment_score"
+	ChatAlertSentimentLastVariable  = "cc_sentiment_last"
+	ChatAlertRulesVariable          = "cc_chat_alerts"
+)
+
+type ChatAlertRule struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+	// fires when the score of the message is less or equal
+	SentimentBelow *float64 `json:"sentiment_below"`
+	// client, agent or empty for all
+	Sender string `json:"sender"`
+}
+
+type ChatAlertTrend struct {
+	Messages  int     `json:"messages"`
+	Threshold float64 `json:"threshold"`
+}
+
+// ChatAlertLexicon the words of the queue are added to the default words of the lexicon analyzer
+type ChatAlertLexicon struct {
+	Positive []string `json:"positive"`
+	Negative []string `json:"negative"`
+	Negators []string `json:"negators"`
+}
+
+type ChatAlertSettings struct {
+	Enabled       bool              `json:"enabled"`
+	Analyzer      string            `json:"analyzer"`
+	Lexicon       *ChatAlertLexicon `json:"lexicon"`
+	Rules         []ChatAlertRule   `json:"rules"`
+	NegativeTrend *ChatAlertTrend   `json:"negative_trend"`
+}
+
+type ChatAlert struct {
+	DomainId       int64   `json:"-"`
+	QueueId        int     `json:"queue_id"`
+	AttemptId      int64   `json:"attempt_id"`
+	ConversationId string  `json:"conversation_id"`
+	AgentUserId    int64   `json:"agent_user_id,omitempty"`
+	Rule           string  `json:"rule"`
+	Sender         string  `json:"sender"`
+	Text           string  `json:"text"`
+	Score          float64 `json:"score"`
+	CreatedAt      int64   `json:"created_at"`
+}
+
+func (s *ChatAlertSettings) Allow() bool {
+	return s != nil && s.Enabled
+}
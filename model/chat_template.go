@@ -0,0 +1,97 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	chatAutoMessagesDefaultLanguageVariable = "language"
+	chatAutoMessagesDefaultPositionInterval = 60
+	chatAutoMessagesDefaultWarningBefore    = 60
+	chatAutoMessagesDefaultAgentIdleAfter   = 60
+)
+
+var chatTemplateVariable = regexp.MustCompile(`\$\{([\w.\-]+)\}`)
+
+// ChatTemplate message text with ${variable} placeholders and translations by the language
+type ChatTemplate struct {
+	Text string            `json:"text"`
+	I18n map[string]string `json:"i18n"`
+}
+
+type ChatAutoMessagesSettings struct {
+	Language         string `json:"language"`
+	LanguageVariable string `json:"language_variable"`
+
+	Greeting *ChatTemplate `json:"greeting"`
+
+	Position         *ChatTemplate `json:"position"`
+	PositionInterval uint32        `json:"position_interval"`
+
+	IdleClientWarning *ChatTemplate `json:"idle_client_warning"`
+	WarningBefore     int64         `json:"warning_before"`
+
+	AgentIdle      *ChatTemplate `json:"agent_idle"`
+	AgentIdleAfter int64         `json:"agent_idle_after"`
+}
+
+func (t *ChatTemplate) Allow() bool {
+	return t != nil && (t.Text != "" || len(t.I18n) != 0)
+}
+
+// Render picks the translation by language (en-US, then en) and replaces the variables, unknown variables are removed
+func (t *ChatTemplate) Render(language string, vars map[string]string) string {
+	text := t.Text
+	if language != "" && t.I18n != nil {
+		language = strings.ToLower(language)
+		if v, ok := t.I18n[language]; ok {
+			text = v
+		} else if i := strings.IndexAny(language, "-_"); i > 0 {
+			if v, ok = t.I18n[language[:i]]; ok {
+				text = v
+			}
+		}
+	}
+
+	return chatTemplateVariable.ReplaceAllStringFunc(text, func(s string) string {
+		return vars[s[2:len(s)-1]]
+	})
+}
+
+func (s *ChatAutoMessagesSettings) LanguageOf(vars map[string]string) string {
+	name := s.LanguageVariable
+	if name == "" {
+		name = chatAutoMessagesDefaultLanguageVariable
+	}
+
+	if v, ok := vars[name]; ok && v != "" {
+		return v
+	}
+
+	return s.Language
+}
+
+func (s *ChatAutoMessagesSettings) PositionIntervalSec() uint32 {
+	if s.PositionInterval == 0 {
+		return chatAutoMessagesDefaultPositionInterval
+	}
+
+	return s.PositionInterval
+}
+
+func (s *ChatAutoMessagesSettings) WarningBeforeSec() int64 {
+	if s.WarningBefore == 0 {
+		return chatAutoMessagesDefaultWarningBefore
+	}
+
+	return s.WarningBefore
+}
+
+func (s *ChatAutoMessagesSettings) AgentIdleAfterSec() int64 {
+	if s.AgentIdleAfter == 0 {
+		return chatAutoMessagesDefaultAgentIdleAfter
+	}
+
+	return s.AgentIdleAfter
+}
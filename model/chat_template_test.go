@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestChatTemplateRender(t *testing.T) {
+	tpl := &ChatTemplate{
+		Text: "Hello ${name}, position ${position}${unknown}",
+		I18n: map[string]string{
+			"uk": "Вітаємо ${name}, позиція ${position}",
+		},
+	}
+	vars := map[string]string{"name": "John", "position": "2"}
+
+	if res := tpl.Render("", vars); res != "Hello John, position 2" {
+		t.Errorf("bad render: %s", res)
+	}
+	if res := tpl.Render("uk-UA", vars); res != "Вітаємо John, позиція 2" {
+		t.Errorf("bad render i18n: %s", res)
+	}
+	if res := tpl.Render("de", vars); res != "Hello John, position 2" {
+		t.Errorf("bad render fallback: %s", res)
+	}
+}
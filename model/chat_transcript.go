@@ -0,0 +1,144 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	ChatTranscriptSenderClient = "client"
+	ChatTranscriptSenderAgent  = "agent"
+)
+
+const (
+	ChatTranscriptFormatJson = "json"
+	ChatTranscriptFormatText = "text"
+	ChatTranscriptFormatHtml = "html"
+)
+
+const chatTranscriptDefaultMask = "***"
+
+type ChatTranscriptSettings struct {
+	Enabled bool     `json:"enabled"`
+	Redact  []string `json:"redact"`
+	Mask    string   `json:"mask"`
+}
+
+type ChatTranscriptMessage struct {
+	Id             int64  `json:"id" db:"id"`
+	DomainId       int64  `json:"domain_id" db:"domain_id"`
+	AttemptId      int64  `json:"attempt_id" db:"attempt_id"`
+	QueueId        int    `json:"queue_id" db:"queue_id"`
+	ConversationId string `json:"conversation_id" db:"conversation_id"`
+	ChannelId      string `json:"channel_id" db:"channel_id"`
+	Sender         string `json:"sender" db:"sender"`
+	UserId         int64  `json:"user_id,omitempty" db:"user_id"`
+	Type           string `json:"type" db:"type"`
+	Text           string `json:"text" db:"text"`
+	CreatedAt      int64  `json:"created_at" db:"created_at"`
+}
+
+type ChatTranscript struct {
+	AttemptId      int64                    `json:"attempt_id"`
+	ConversationId string                   `json:"conversation_id"`
+	Messages       []*ChatTranscriptMessage `json:"messages"`
+}
+
+// ChatRedactor masks the personal data of the message before it is stored
+type ChatRedactor struct {
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func (s *ChatTranscriptSettings) Allow() bool {
+	return s != nil && s.Enabled
+}
+
+func (s *ChatTranscriptSettings) Redactor() (*ChatRedactor, *AppError) {
+	r := &ChatRedactor{
+		mask: s.Mask,
+	}
+	if r.mask == "" {
+		r.mask = chatTranscriptDefaultMask
+	}
+
+	for _, p := range s.Redact {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, NewAppError("ChatTranscriptSettings.Redactor", "model.chat_transcript.redact.app_error", nil,
+				fmt.Sprintf("pattern \"%s\": %s", p, err.Error()), http.StatusBadRequest)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+func (r *ChatRedactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, r.mask)
+	}
+
+	return text
+}
+
+func NewChatTranscript(attemptId int64, messages []*ChatTranscriptMessage) *ChatTranscript {
+	t := &ChatTranscript{
+		AttemptId: attemptId,
+		Messages:  messages,
+	}
+	if len(messages) != 0 {
+		t.ConversationId = messages[0].ConversationId
+	}
+
+	return t
+}
+
+// Export returns the transcript in the format and the content type
+func (t *ChatTranscript) Export(format string) ([]byte, string, *AppError) {
+	switch format {
+	case ChatTranscriptFormatJson, "":
+		data, _ := json.Marshal(t)
+		return data, "application/json", nil
+	case ChatTranscriptFormatText:
+		var buf bytes.Buffer
+		for _, m := range t.Messages {
+			fmt.Fprintf(&buf, "[%s] %s: %s\n", transcriptTime(m.CreatedAt), m.senderName(), m.Text)
+		}
+		return buf.Bytes(), "text/plain; charset=utf-8", nil
+	case ChatTranscriptFormatHtml:
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "<html><head><meta charset=\"utf-8\"><title>Attempt %d</title></head><body>\n", t.AttemptId)
+		fmt.Fprintf(&buf, "<h3>Conversation %s</h3>\n<table>\n", html.EscapeString(t.ConversationId))
+		for _, m := range t.Messages {
+			fmt.Fprintf(&buf, "<tr class=\"%s\"><td>%s</td><td>%s</td><td>%s</td></tr>\n", m.Sender,
+				transcriptTime(m.CreatedAt), html.EscapeString(m.senderName()), html.EscapeString(m.Text))
+		}
+		buf.WriteString("</table>\n</body></html>\n")
+		return buf.Bytes(), "text/html; charset=utf-8", nil
+	default:
+		return nil, "", NewAppError("ChatTranscript.Export", "model.chat_transcript.export.format", nil,
+			fmt.Sprintf("unknown format \"%s\"", format), http.StatusBadRequest)
+	}
+}
+
+func (m *ChatTranscriptMessage) senderName() string {
+	if m.Sender == ChatTranscriptSenderAgent && m.UserId != 0 {
+		return fmt.Sprintf("%s %d", m.Sender, m.UserId)
+	}
+
+	return m.Sender
+}
+
+func transcriptTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
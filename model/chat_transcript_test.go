@@ -0,0 +1,50 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChatRedactor(t *testing.T) {
+	s := ChatTranscriptSettings{
+		Enabled: true,
+		Redact:  []string{`\b\d{16}\b`, `[\w.]+@[\w.]+`},
+	}
+
+	r, err := s.Redactor()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res := r.Redact("card 4111111111111111 mail test@example.com")
+	if res != "card *** mail ***" {
+		t.Errorf("bad redact: %s", res)
+	}
+}
+
+func TestChatTranscriptExport(t *testing.T) {
+	tr := NewChatTranscript(1, []*ChatTranscriptMessage{
+		{ConversationId: "c1", Sender: ChatTranscriptSenderClient, Text: "<b>hi</b>", CreatedAt: 1000},
+		{ConversationId: "c1", Sender: ChatTranscriptSenderAgent, UserId: 10, Text: "hello", CreatedAt: 2000},
+	})
+
+	data, _, err := tr.Export(ChatTranscriptFormatText)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(string(data), "agent 10: hello") {
+		t.Errorf("bad text export: %s", data)
+	}
+
+	data, _, err = tr.Export(ChatTranscriptFormatHtml)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Contains(string(data), "<b>hi</b>") {
+		t.Errorf("html export not escaped: %s", data)
+	}
+
+	if _, _, err = tr.Export("pdf"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -59,6 +59,20 @@ type QueueSettings struct {
 	PollingInterval   time.Duration `json:"polling_interval" flag:"polling_interval|500ms|Polling distribute interval (default 500ms)" env:"POLLING_INTERVAL"`
 }
 
+type FileSettings struct {
+	Driver      string `json:"driver" flag:"file_driver||File storage driver: local / s3" env:"FILE_DRIVER"`
+	Directory   string `json:"directory" flag:"file_directory|./data|Local file storage directory" env:"FILE_DIRECTORY"`
+	AttemptLogs bool   `json:"attempt_logs" flag:"file_attempt_logs|false|Store attempt logs to the file storage" env:"FILE_ATTEMPT_LOGS"`
+
+	S3Endpoint  string `json:"s3_endpoint" flag:"file_s3_endpoint||S3 endpoint host[:port]" env:"FILE_S3_ENDPOINT"`
+	S3Bucket    string `json:"s3_bucket" flag:"file_s3_bucket||S3 bucket" env:"FILE_S3_BUCKET"`
+	S3Region    string `json:"s3_region" flag:"file_s3_region|us-east-1|S3 region" env:"FILE_S3_REGION"`
+	S3AccessKey string `json:"s3_access_key" flag:"file_s3_access_key||S3 access key" env:"FILE_S3_ACCESS_KEY"`
+	S3SecretKey string `json:"s3_secret_key" flag:"file_s3_secret_key||S3 secret key" env:"FILE_S3_SECRET_KEY"`
+	S3Prefix    string `json:"s3_prefix" flag:"file_s3_prefix||S3 path prefix" env:"FILE_S3_PREFIX"`
+	S3Insecure  bool   `json:"s3_insecure" flag:"file_s3_insecure|false|S3 use http" env:"FILE_S3_INSECURE"`
+}
+
 type Config struct {
 	DiscoverySettings    DiscoverySettings    `json:"discovery_settings"`
 	QueueSettings        QueueSettings        `json:"queue_settings"`
@@ -68,4 +82,5 @@ type Config struct {
 	MessageQueueSettings MessageQueueSettings `json:"message_queue_settings"`
 	CallSettings         CallSettings         `json:"call_settings"`
 	Log                  LogSettings          `json:"log_settings"`
+	FileSettings         FileSettings         `json:"file_settings"`
 }
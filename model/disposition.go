@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	DispositionCategorySuccess = "success"
+	DispositionCategoryRetry   = "retry"
+	DispositionCategoryFailure = "failure" // final failure, the member is stopped
+	DispositionCategoryDnc     = "dnc"     // do not call the communication anymore
+
+	DispositionNextCurrent = "current" // the next attempt to the same communication
+	DispositionNextOther   = "other"   // the current communication is excluded
+
+	QueueDispositionVariable         = "cc_disposition"
+	QueueDispositionCategoryVariable = "cc_disposition_category"
+	QueueDispositionRetriesVariable  = "cc_disposition_retries_%s"
+)
+
+// QueueDisposition the result code of the attempt with the retry policy,
+// MaxRetries and WaitBetweenRetries override the settings of the queue
+type QueueDisposition struct {
+	Code               string  `json:"code"`
+	Name               string  `json:"name"`
+	Category           string  `json:"category"`
+	WaitBetweenRetries uint32  `json:"wait_between_retries"`
+	MaxRetries         uint32  `json:"max_retries"`
+	NextCommunication  string  `json:"next_communication"`
+	SchemaId           *uint32 `json:"schema_id"`    // hook of the disposition
+	WrapUpTime         *uint32 `json:"wrap_up_time"` // overrides the wrap up time of the queue and the team
+}
+
+// QueueDispositions the catalog of the queue, configured in the queue payload:
+// {"dispositions": {"strict": true, "items": [{"code": "busy", "category": "retry", "max_retries": 3}]}},
+// Strict rejects the results that are not in the catalog
+type QueueDispositions struct {
+	Strict bool                `json:"strict"`
+	Items  []*QueueDisposition `json:"items"`
+	codes  map[string]*QueueDisposition
+}
+
+func QueueDispositionsFromBytes(data []byte) *QueueDispositions {
+	var payload struct {
+		Dispositions *QueueDispositions `json:"dispositions"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.Dispositions == nil || len(payload.Dispositions.Items) == 0 {
+		return nil
+	}
+
+	d := payload.Dispositions
+	d.codes = make(map[string]*QueueDisposition)
+	for _, v := range d.Items {
+		if v.Code != "" && v.IsValid() == nil {
+			d.codes[v.Code] = v
+		}
+	}
+
+	return d
+}
+
+func (d *QueueDisposition) IsValid() *AppError {
+	switch d.Category {
+	case DispositionCategorySuccess, DispositionCategoryRetry, DispositionCategoryFailure, DispositionCategoryDnc:
+	default:
+		return NewAppError("QueueDisposition.IsValid", "model.queue_disposition.is_valid.category", nil,
+			fmt.Sprintf("code \"%s\" bad category \"%s\"", d.Code, d.Category), http.StatusBadRequest)
+	}
+
+	switch d.NextCommunication {
+	case "", DispositionNextCurrent, DispositionNextOther:
+	default:
+		return NewAppError("QueueDisposition.IsValid", "model.queue_disposition.is_valid.next_communication", nil,
+			fmt.Sprintf("code \"%s\" bad next communication \"%s\"", d.Code, d.NextCommunication), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (d *QueueDisposition) Final() bool {
+	return d.Category != DispositionCategoryRetry
+}
+
+func (d *QueueDisposition) RetriesVariable() string {
+	return fmt.Sprintf(QueueDispositionRetriesVariable, d.Code)
+}
+
+// Get the disposition of the result, the error when the catalog is strict and the code is unknown
+func (d *QueueDispositions) Get(status string) (*QueueDisposition, *AppError) {
+	if v, ok := d.codes[status]; ok {
+		return v, nil
+	}
+
+	if d.Strict {
+		return nil, NewAppError("QueueDispositions.Get", "model.queue_disposition.get.not_found", nil,
+			fmt.Sprintf("result \"%s\" is not in the disposition catalog", status), http.StatusBadRequest)
+	}
+
+	return nil, nil
+}
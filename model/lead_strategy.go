@@ -0,0 +1,145 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	LeadOrderFreshFirst   = "fresh_first"   // the members without the attempts are the first
+	LeadOrderRetriesFirst = "retries_first" // the members with the attempts are the first
+
+	LeadRecycledVariable = "cc_recycled"
+
+	defaultLeadScoringInterval = 60
+	defaultLeadScoringLimit    = 5000
+	maxLeadScoringLimit        = 30000
+)
+
+type LeadBucket struct {
+	BucketId int32 `json:"bucket_id"`
+	Weight   int   `json:"weight"`
+}
+
+// LeadRecycle the stopped members with the disposition are returned to the queue after the delay
+type LeadRecycle struct {
+	Disposition string `json:"disposition"`
+	After       uint32 `json:"after"`        // sec
+	MaxRecycles uint32 `json:"max_recycles"` // 0 - unlimited
+}
+
+// QueueLeadStrategy the scoring of the waiting members of the outbound queue,
+// configured in the queue payload: {"lead_strategy": {"score": "num(amount) / 100", "order": "fresh_first"}},
+// the scorer orders the members by the tier of the order and the score, the buckets are interleaved by the weighted
+// round-robin, the distributor orders the members of the same priority by the position in this order
+type QueueLeadStrategy struct {
+	Order    string        `json:"order"`
+	Score    string        `json:"score"` // expression over the member variables
+	Buckets  []LeadBucket  `json:"buckets"`
+	Recycle  []LeadRecycle `json:"recycle"`
+	Interval uint32        `json:"interval"` // sec
+	Limit    int           `json:"limit"`
+}
+
+// LeadStrategyQueue the queue claimed for the scoring by the node
+type LeadStrategyQueue struct {
+	QueueId  int    `json:"queue_id" db:"queue_id"`
+	DomainId int64  `json:"domain_id" db:"domain_id"`
+	Strategy []byte `json:"strategy" db:"strategy"`
+}
+
+type LeadMember struct {
+	Id           int64             `json:"id" db:"id"`
+	BucketId     *int32            `json:"bucket_id" db:"bucket_id"`
+	Priority     int               `json:"priority" db:"priority"`
+	Attempts     int               `json:"attempts" db:"attempts"`
+	Name         string            `json:"name" db:"name"`
+	Variables    map[string]string `json:"variables" db:"variables"`
+	CreatedAt    int64             `json:"created_at" db:"created_at"`
+	LastHangupAt int64             `json:"last_hangup_at" db:"last_hangup_at"`
+}
+
+// LeadScore the score of the member with the explanation, the priority is the position of the last scoring
+type LeadScore struct {
+	MemberId     int64       `json:"member_id" db:"member_id"`
+	QueueId      int         `json:"queue_id" db:"queue_id"`
+	BasePriority int         `json:"base_priority" db:"base_priority"`
+	Tier         int         `json:"tier" db:"tier"`
+	Score        float64     `json:"score" db:"score"`
+	Priority     int         `json:"priority" db:"priority"`
+	Explanation  StringArray `json:"explanation" db:"explanation"`
+	UpdatedAt    int64       `json:"updated_at" db:"updated_at"`
+}
+
+type SearchLeadScore struct {
+	DomainId int64 `json:"domain_id"`
+	MemberId int64 `json:"member_id"`
+}
+
+func QueueLeadStrategyFromBytes(data []byte) *QueueLeadStrategy {
+	var payload struct {
+		LeadStrategy *QueueLeadStrategy `json:"lead_strategy"`
+	}
+	json.Unmarshal(data, &payload)
+
+	return payload.LeadStrategy
+}
+
+func (s *QueueLeadStrategy) IsValid() *AppError {
+	switch s.Order {
+	case "", LeadOrderFreshFirst, LeadOrderRetriesFirst:
+	default:
+		return NewAppError("QueueLeadStrategy.IsValid", "model.lead_strategy.is_valid.order", nil,
+			fmt.Sprintf("bad order \"%s\"", s.Order), http.StatusBadRequest)
+	}
+
+	for _, r := range s.Recycle {
+		if r.Disposition == "" {
+			return NewAppError("QueueLeadStrategy.IsValid", "model.lead_strategy.is_valid.recycle", nil,
+				"recycle disposition is required", http.StatusBadRequest)
+		}
+	}
+
+	for _, b := range s.Buckets {
+		if b.Weight < 0 {
+			return NewAppError("QueueLeadStrategy.IsValid", "model.lead_strategy.is_valid.bucket", nil,
+				fmt.Sprintf("bucket %d bad weight %d", b.BucketId, b.Weight), http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *QueueLeadStrategy) IntervalSec() uint32 {
+	if s.Interval == 0 {
+		return defaultLeadScoringInterval
+	}
+
+	return s.Interval
+}
+
+func (s *QueueLeadStrategy) MembersLimit() int {
+	if s.Limit <= 0 {
+		return defaultLeadScoringLimit
+	}
+
+	if s.Limit > maxLeadScoringLimit {
+		return maxLeadScoringLimit
+	}
+
+	return s.Limit
+}
+
+// BucketWeight the buckets out of the strategy have the weight 1
+func (s *QueueLeadStrategy) BucketWeight(bucketId *int32) int {
+	if bucketId != nil {
+		for _, b := range s.Buckets {
+			if b.BucketId == *bucketId {
+				return b.Weight
+			}
+		}
+	}
+
+	return 1
+}
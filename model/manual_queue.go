@@ -1,6 +1,11 @@
 package model
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 type MemberWaiting struct {
 	AttemptId     int64           `json:"attempt_id" db:"attempt_id"`
@@ -19,3 +24,96 @@ type MemberWaitingByUsers struct {
 	Calls    []*MemberWaiting `json:"-" db:"calls"`
 	Chats    []*MemberWaiting `json:"-" db:"chats"`
 }
+
+const (
+	defaultManualAttemptsLimit = 40
+	maxManualAttemptsLimit     = 500
+)
+
+// SearchManualAttempt the waiting attempts of the manual distribution queues the agent is eligible for
+type SearchManualAttempt struct {
+	DomainId  int64  `json:"domain_id"`
+	AgentId   int    `json:"agent_id"`
+	Channel   string `json:"channel"`
+	QueueIds  []int  `json:"queue_ids"`
+	BucketIds []int  `json:"bucket_ids"`
+	Q         string `json:"q"` // the destination or the name
+	Limit     int    `json:"limit"`
+}
+
+type ManualAttempt struct {
+	AttemptId   int64   `json:"attempt_id" db:"attempt_id"`
+	Queue       Lookup  `json:"queue" db:"queue"`
+	Bucket      *Lookup `json:"bucket,omitempty" db:"bucket"`
+	Channel     string  `json:"channel" db:"channel"`
+	Destination string  `json:"destination" db:"destination"`
+	Name        string  `json:"name" db:"name"`
+	SessionId   string  `json:"session_id,omitempty" db:"session_id"`
+	Position    int     `json:"position" db:"position"` // in the queue
+	Wait        int     `json:"wait" db:"wait"`
+	Deadline    int     `json:"deadline" db:"deadline"`
+	JoinedAt    int64   `json:"joined_at" db:"joined_at"`
+}
+
+// ManualReservation the attempt reserved by the agent, the attempt is offered by the node of the attempt
+type ManualReservation struct {
+	DomainId  int64 `json:"domain_id" db:"domain_id"`
+	AgentId   int   `json:"agent_id" db:"agent_id"`
+	AttemptId int64 `json:"attempt_id" db:"attempt_id"`
+}
+
+type ManualReserved struct {
+	AttemptId int64  `json:"attempt_id" db:"attempt_id"`
+	QueueId   int    `json:"queue_id" db:"queue_id"`
+	AgentId   int    `json:"agent_id" db:"agent_id"`
+	Channel   string `json:"channel" db:"channel"`
+	NodeId    string `json:"node_id" db:"node_id"`
+}
+
+func (s *SearchManualAttempt) IsValid() *AppError {
+	if s.DomainId == 0 || s.AgentId == 0 {
+		return NewAppError("SearchManualAttempt.IsValid", "model.manual_attempt.is_valid.agent_id", nil,
+			"domain_id and agent_id are required", http.StatusBadRequest)
+	}
+
+	switch s.Channel {
+	case "", QueueChannelCall, QueueChannelChat:
+	default:
+		return NewAppError("SearchManualAttempt.IsValid", "model.manual_attempt.is_valid.channel", nil,
+			fmt.Sprintf("bad channel \"%s\"", s.Channel), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (s *SearchManualAttempt) GetLimit() int {
+	switch {
+	case s.Limit <= 0:
+		return defaultManualAttemptsLimit
+	case s.Limit > maxManualAttemptsLimit:
+		return maxManualAttemptsLimit
+	}
+
+	return s.Limit
+}
+
+// QueryLike the pattern of the ilike, "*" is the wildcard
+func (s *SearchManualAttempt) QueryLike() string {
+	q := strings.TrimSpace(s.Q)
+	if q == "" {
+		return ""
+	}
+	q = strings.ReplaceAll(q, "%", "\\%")
+	q = strings.ReplaceAll(q, "_", "\\_")
+
+	return "%" + strings.ReplaceAll(q, "*", "%") + "%"
+}
+
+func (r *ManualReservation) IsValid() *AppError {
+	if r.DomainId == 0 || r.AgentId == 0 || r.AttemptId == 0 {
+		return NewAppError("ManualReservation.IsValid", "model.manual_reservation.is_valid", nil,
+			"domain_id, agent_id and attempt_id are required", http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import "testing"
+
+func TestSearchManualAttempt(t *testing.T) {
+	t.Log("SearchManualAttempt")
+
+	s := &SearchManualAttempt{DomainId: 1, AgentId: 2, Channel: "sms"}
+	if err := s.IsValid(); err == nil {
+		t.Errorf("bad channel: expected error")
+	}
+
+	s.Channel = QueueChannelChat
+	if err := s.IsValid(); err != nil {
+		t.Errorf("valid search: %s", err.Error())
+	}
+
+	if s.GetLimit() != defaultManualAttemptsLimit {
+		t.Errorf("default limit: got %d", s.GetLimit())
+	}
+
+	for q, like := range map[string]string{
+		"":          "",
+		" 380* ":    "%380%%",
+		"50%_off":   "%50\\%\\_off%",
+		"John*Smit": "%John%Smit%",
+	} {
+		s.Q = q
+		if v := s.QueryLike(); v != like {
+			t.Errorf("query %q: got %q, want %q", q, v, like)
+		}
+	}
+}
@@ -160,6 +160,8 @@ type RenewalProcessing struct {
 	QueueId    int    `json:"queue_id" db:"queue_id"` // todo queue null
 	DomainId   int64  `json:"domain_id" db:"domain_id"`
 	RenewalSec uint32 `json:"renewal_sec" db:"renewal_sec"`
+	Processing bool   `json:"-" db:"processing"`
+	Renewed    bool   `json:"-" db:"renewed"`
 }
 
 type EventAttemptOffering struct {
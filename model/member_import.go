@@ -0,0 +1,431 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MemberImportFormatCsv   = "csv"
+	MemberImportFormatJsonl = "jsonl"
+
+	MemberImportErrorParse            = "parse"
+	MemberImportErrorPriority         = "priority"
+	MemberImportErrorPhone            = "phone"
+	MemberImportErrorDuplicate        = "duplicate"
+	MemberImportErrorDnc              = "dnc"
+	MemberImportErrorNoCommunications = "no_communications"
+	MemberImportErrorStore            = "store"
+
+	MemberImportBatchSize    = 500
+	MemberExportBatchSize    = 1000
+	maxMemberImportErrors    = 1000
+	maxMemberImportLineBytes = 1024 * 1024
+)
+
+// MemberImportCommunication the column of the communication, Phone - the destination is normalized to E.164
+type MemberImportCommunication struct {
+	Column   string `json:"column"`
+	TypeId   int    `json:"type_id"`
+	Priority int    `json:"priority"`
+	Phone    bool   `json:"phone"`
+}
+
+// MemberImportMapping the columns of the csv header or the keys of the jsonl object,
+// Variables - the name of the variable to the column
+type MemberImportMapping struct {
+	Name           string                      `json:"name"`
+	Priority       string                      `json:"priority"`
+	ImportId       string                      `json:"import_id"`
+	Variables      map[string]string           `json:"variables"`
+	Communications []MemberImportCommunication `json:"communications"`
+}
+
+// MemberImportRequest the file is the Content with the content of the next messages of the stream or the Location in the file storage,
+// DefaultCountry - the calling code of the numbers without it, Dedup - skips the members with the existing destinations of the queue
+type MemberImportRequest struct {
+	DomainId       int64               `json:"domain_id"`
+	QueueId        int                 `json:"queue_id"`
+	Format         string              `json:"format"`
+	Mapping        MemberImportMapping `json:"mapping"`
+	DefaultCountry string              `json:"default_country"`
+	Dedup          bool                `json:"dedup"`
+	Content        string              `json:"content"`
+	Location       string              `json:"location"`
+}
+
+type MemberImportRow struct {
+	Line           int                   `json:"-"`
+	Name           string                `json:"name"`
+	Priority       int                   `json:"priority"`
+	ImportId       *string               `json:"import_id"`
+	Variables      map[string]string     `json:"variables"`
+	Communications []MemberCommunication `json:"communications"`
+}
+
+type MemberImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type MemberImportResult struct {
+	Total      int                  `json:"total"`
+	Inserted   int                  `json:"inserted"`
+	Duplicates int                  `json:"duplicates"`
+	Rejected   int                  `json:"rejected"`
+	Errors     []*MemberImportError `json:"errors"`
+	Truncated  bool                 `json:"truncated"` // the errors are limited
+}
+
+func (r *MemberImportRequest) IsValid() *AppError {
+	switch {
+	case r.QueueId == 0:
+		return NewAppError("MemberImportRequest.IsValid", "model.member_import.is_valid.queue_id", nil,
+			"queue_id is required", http.StatusBadRequest)
+	case r.Format != MemberImportFormatCsv && r.Format != MemberImportFormatJsonl:
+		return NewAppError("MemberImportRequest.IsValid", "model.member_import.is_valid.format", nil,
+			fmt.Sprintf("unknown format \"%s\"", r.Format), http.StatusBadRequest)
+	case len(r.Mapping.Communications) == 0:
+		return NewAppError("MemberImportRequest.IsValid", "model.member_import.is_valid.communications", nil,
+			"mapping of the communications is required", http.StatusBadRequest)
+	}
+
+	for _, c := range r.Mapping.Communications {
+		if c.Column == "" || c.TypeId == 0 {
+			return NewAppError("MemberImportRequest.IsValid", "model.member_import.is_valid.communication", nil,
+				"column and type_id of the communication are required", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (r *MemberImportResult) AddError(line int, field, code, msg string) {
+	if len(r.Errors) >= maxMemberImportErrors {
+		r.Truncated = true
+		return
+	}
+
+	r.Errors = append(r.Errors, &MemberImportError{
+		Line:    line,
+		Field:   field,
+		Code:    code,
+		Message: msg,
+	})
+}
+
+// MemberImportReader reads the rows of the file one by one
+type MemberImportReader struct {
+	req     *MemberImportRequest
+	csv     *csv.Reader
+	header  map[string]int
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewMemberImportReader(r io.Reader, req *MemberImportRequest) (*MemberImportReader, *AppError) {
+	reader := &MemberImportReader{
+		req: req,
+	}
+
+	if req.Format == MemberImportFormatJsonl {
+		reader.scanner = bufio.NewScanner(r)
+		reader.scanner.Buffer(make([]byte, 64*1024), maxMemberImportLineBytes)
+		return reader, nil
+	}
+
+	reader.csv = csv.NewReader(r)
+	reader.csv.FieldsPerRecord = -1
+	reader.csv.TrimLeadingSpace = true
+
+	header, err := reader.csv.Read()
+	if err != nil {
+		return nil, NewAppError("MemberImportReader", "model.member_import.reader.header", nil,
+			err.Error(), http.StatusBadRequest)
+	}
+	reader.line = 1
+	reader.header = make(map[string]int)
+	for i, v := range header {
+		reader.header[strings.TrimSpace(strings.TrimPrefix(v, "\ufeff"))] = i
+	}
+
+	return reader, nil
+}
+
+// Next the row and the errors of the row, the row is nil when it's rejected,
+// the error is io.EOF at the end of the file or the file can't be read
+func (r *MemberImportReader) Next() (*MemberImportRow, []*MemberImportError, error) {
+	get, err := r.record()
+	if err != nil {
+		if _, ok := err.(rowError); ok {
+			return nil, []*MemberImportError{{Line: r.line, Code: MemberImportErrorParse, Message: err.Error()}}, nil
+		}
+		return nil, nil, err
+	}
+
+	return r.row(get)
+}
+
+// rowError the row can't be parsed, the next rows are read
+type rowError struct {
+	error
+}
+
+func (r *MemberImportReader) record() (func(string) string, error) {
+	if r.scanner != nil {
+		for r.scanner.Scan() {
+			r.line++
+			data := bytes.TrimSpace(r.scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+
+			var obj map[string]interface{}
+			d := json.NewDecoder(bytes.NewReader(data))
+			d.UseNumber()
+			if err := d.Decode(&obj); err != nil {
+				return nil, rowError{err}
+			}
+
+			return func(key string) string {
+				switch v := obj[key].(type) {
+				case nil:
+					return ""
+				case string:
+					return v
+				default:
+					return fmt.Sprintf("%v", v)
+				}
+			}, nil
+		}
+		if err := r.scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	rec, err := r.csv.Read()
+	if err != nil {
+		if e, ok := err.(*csv.ParseError); ok {
+			r.line = e.Line
+			return nil, rowError{err}
+		}
+		return nil, err
+	}
+	r.line, _ = r.csv.FieldPos(0)
+
+	return func(key string) string {
+		if i, ok := r.header[key]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}, nil
+}
+
+func (r *MemberImportReader) row(get func(string) string) (*MemberImportRow, []*MemberImportError, error) {
+	var errs []*MemberImportError
+	m := &r.req.Mapping
+	row := &MemberImportRow{
+		Line:      r.line,
+		Variables: make(map[string]string),
+	}
+
+	if m.Name != "" {
+		row.Name = strings.TrimSpace(get(m.Name))
+	}
+
+	if m.Priority != "" {
+		if v := strings.TrimSpace(get(m.Priority)); v != "" {
+			p, err := strconv.ParseInt(v, 10, 16)
+			if err != nil {
+				errs = append(errs, &MemberImportError{Line: r.line, Field: m.Priority, Code: MemberImportErrorPriority,
+					Message: fmt.Sprintf("bad priority \"%s\"", v)})
+				return nil, errs, nil
+			}
+			row.Priority = int(p)
+		}
+	}
+
+	if m.ImportId != "" {
+		if v := strings.TrimSpace(get(m.ImportId)); v != "" {
+			row.ImportId = &v
+		}
+	}
+
+	for name, col := range m.Variables {
+		if v := get(col); v != "" {
+			row.Variables[name] = v
+		}
+	}
+
+	for _, c := range m.Communications {
+		v := strings.TrimSpace(get(c.Column))
+		if v == "" {
+			continue
+		}
+
+		if c.Phone {
+			n, ok := NormalizeE164(v, r.req.DefaultCountry)
+			if !ok {
+				errs = append(errs, &MemberImportError{Line: r.line, Field: c.Column, Code: MemberImportErrorPhone,
+					Message: fmt.Sprintf("bad phone number \"%s\"", v)})
+				continue
+			}
+			v = n
+		}
+
+		row.Communications = append(row.Communications, MemberCommunication{
+			Destination: v,
+			Type:        Communication{Id: c.TypeId},
+			Priority:    c.Priority,
+		})
+	}
+
+	if len(row.Communications) == 0 {
+		errs = append(errs, &MemberImportError{Line: r.line, Code: MemberImportErrorNoCommunications,
+			Message: "no valid communications"})
+		return nil, errs, nil
+	}
+
+	return row, errs, nil
+}
+
+// MemberExportRequest the members of the queue with the results, Variables - the columns of the variables
+type MemberExportRequest struct {
+	DomainId  int64    `json:"domain_id"`
+	QueueId   int      `json:"queue_id"`
+	Format    string   `json:"format"`
+	Variables []string `json:"variables"`
+	Store     bool     `json:"store"`
+}
+
+type MemberExport struct {
+	Id             int64                 `json:"id" db:"id"`
+	Name           string                `json:"name" db:"name"`
+	ImportId       *string               `json:"import_id" db:"import_id"`
+	Priority       int                   `json:"priority" db:"priority"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	StopCause      *string               `json:"stop_cause" db:"stop_cause"`
+	LastResult     *string               `json:"last_result" db:"last_result"`
+	LastAgentId    *int                  `json:"last_agent_id" db:"last_agent_id"`
+	LastCallAt     *time.Time            `json:"last_call_at" db:"last_call_at"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	StopAt         *time.Time            `json:"stop_at" db:"stop_at"`
+	Variables      StringMap             `json:"variables" db:"variables"`
+	Communications []MemberCommunication `json:"communications" db:"communications"`
+}
+
+type MemberExportResponse struct {
+	QueueId     int    `json:"queue_id"`
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Count       int    `json:"count"`
+	Content     string `json:"content,omitempty"`
+	Location    string `json:"location,omitempty"`
+}
+
+var memberExportColumns = []string{"id", "name", "import_id", "priority", "destinations", "attempts", "stop_cause",
+	"last_result", "last_agent_id", "last_call_at", "created_at", "stop_at"}
+
+// MemberExportWriter writes the members in the format of the import
+type MemberExportWriter struct {
+	format    string
+	variables []string
+	csv       *csv.Writer
+	json      *json.Encoder
+}
+
+func NewMemberExportWriter(w io.Writer, format string, variables []string) (*MemberExportWriter, string, *AppError) {
+	switch format {
+	case MemberImportFormatCsv:
+		writer := &MemberExportWriter{format: format, variables: variables, csv: csv.NewWriter(w)}
+		writer.csv.Write(append(append([]string{}, memberExportColumns...), variables...))
+		return writer, "text/csv; charset=utf-8", nil
+	case MemberImportFormatJsonl:
+		return &MemberExportWriter{format: format, variables: variables, json: json.NewEncoder(w)}, "application/x-ndjson", nil
+	default:
+		return nil, "", NewAppError("MemberExportWriter", "model.member_export.format", nil,
+			fmt.Sprintf("unknown format \"%s\"", format), http.StatusBadRequest)
+	}
+}
+
+func (w *MemberExportWriter) Write(m *MemberExport) error {
+	if w.json != nil {
+		if len(w.variables) != 0 {
+			vars := make(StringMap)
+			for _, k := range w.variables {
+				if v, ok := m.Variables[k]; ok {
+					vars[k] = v
+				}
+			}
+			m.Variables = vars
+		}
+		return w.json.Encode(m)
+	}
+
+	destinations := make([]string, 0, len(m.Communications))
+	for _, c := range m.Communications {
+		destinations = append(destinations, c.Destination)
+	}
+
+	rec := []string{
+		strconv.FormatInt(m.Id, 10),
+		m.Name,
+		exportString(m.ImportId),
+		strconv.Itoa(m.Priority),
+		strings.Join(destinations, ";"),
+		strconv.Itoa(m.Attempts),
+		exportString(m.StopCause),
+		exportString(m.LastResult),
+		exportInt(m.LastAgentId),
+		exportTime(m.LastCallAt),
+		exportTime(&m.CreatedAt),
+		exportTime(m.StopAt),
+	}
+	for _, k := range w.variables {
+		rec = append(rec, m.Variables[k])
+	}
+
+	return w.csv.Write(rec)
+}
+
+func (w *MemberExportWriter) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	return nil
+}
+
+func exportString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func exportInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
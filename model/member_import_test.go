@@ -0,0 +1,70 @@
+package model
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemberImportReader(t *testing.T) {
+	t.Log("MemberImportReader")
+
+	mapping := MemberImportMapping{
+		Name:      "name",
+		Priority:  "priority",
+		Variables: map[string]string{"crm_id": "id"},
+		Communications: []MemberImportCommunication{
+			{Column: "phone", TypeId: 1, Phone: true},
+			{Column: "mobile", TypeId: 2, Phone: true},
+		},
+	}
+
+	csvReq := &MemberImportRequest{Format: MemberImportFormatCsv, DefaultCountry: "1", Mapping: mapping}
+	jsonlReq := &MemberImportRequest{Format: MemberImportFormatJsonl, DefaultCountry: "1", Mapping: mapping}
+
+	files := map[*MemberImportRequest]string{
+		csvReq: "name,priority,id,phone,mobile\n" +
+			"Alice,5,c1,415 555 2671,bad\n" +
+			"Bob,high,c2,4155552672,\n" +
+			"Dave,40000,c4,4155552673,\n" +
+			"Carol,,c3,,\n",
+		jsonlReq: `{"name": "Alice", "priority": 5, "id": "c1", "phone": "415 555 2671", "mobile": "bad"}` + "\n" +
+			`{"name": "Bob", "priority": "high", "id": "c2", "phone": "4155552672"}` + "\n\n" +
+			`{"name": "Dave", "priority": 40000, "id": "c4", "phone": "4155552673"}` + "\n" +
+			`{"name": "Carol", "id": "c3"}` + "\n",
+	}
+
+	for req, content := range files {
+		r, err := NewMemberImportReader(strings.NewReader(content), req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		row, errs, e := r.Next()
+		if e != nil || row == nil {
+			t.Fatalf("%s row 1: %v %v", req.Format, row, e)
+		}
+		if row.Name != "Alice" || row.Priority != 5 || row.Variables["crm_id"] != "c1" ||
+			len(row.Communications) != 1 || row.Communications[0].Destination != "+14155552671" {
+			t.Errorf("%s row 1: got %+v", req.Format, row)
+		}
+		line := 2 // the header of the csv
+		if req.Format == MemberImportFormatJsonl {
+			line = 1
+		}
+		if len(errs) != 1 || errs[0].Code != MemberImportErrorPhone || errs[0].Field != "mobile" || errs[0].Line != line {
+			t.Errorf("%s row 1 errors: got %+v", req.Format, errs)
+		}
+
+		for _, code := range []string{MemberImportErrorPriority, MemberImportErrorPriority, MemberImportErrorNoCommunications} {
+			row, errs, e = r.Next()
+			if e != nil || row != nil || len(errs) != 1 || errs[0].Code != code {
+				t.Errorf("%s: expected rejected row %s, got %+v %v", req.Format, code, errs, e)
+			}
+		}
+
+		if _, _, e = r.Next(); e != io.EOF {
+			t.Errorf("%s: expected EOF, got %v", req.Format, e)
+		}
+	}
+}
@@ -6,6 +6,7 @@ const (
 	NotificationHideMember  = "hide_member"
 	NotificationHideAttempt = "hide_attempt"
 	NotificationWaitingList = "waiting_list"
+	NotificationChatAlert   = "chat_alert"
 )
 
 type Notification struct {
@@ -0,0 +1,9 @@
+package model
+
+// the object classes of the session permissions
+const (
+	PermissionScopeQueue    = "cc_queue"
+	PermissionScopeAgent    = "cc_agent"
+	PermissionScopeTeam     = "cc_team"
+	PermissionScopeResource = "cc_resource"
+)
@@ -0,0 +1,70 @@
+package model
+
+import "strings"
+
+const (
+	minE164Digits = 7
+	maxE164Digits = 15
+)
+
+// e164TrunkCountries the calling codes of the countries where the leading "0" is a part of the national number
+var e164TrunkCountries = map[string]bool{
+	"39":  true, // Italy
+	"378": true, // San Marino
+	"225": true, // Côte d'Ivoire
+	"242": true, // Republic of the Congo
+}
+
+// NormalizeNumber the digits of the number without the international and the trunk prefixes,
+// the destinations and the prefixes are compared in this form
+func NormalizeNumber(number string) string {
+	return strings.TrimLeft(numberDigits(number), "0")
+}
+
+func numberDigits(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+}
+
+// NormalizeE164 the number with the leading "+" and the country calling code,
+// the international prefix "00" is replaced and the trunk prefix "0" of the national number is removed
+// unless the country keeps it in the international format
+func NormalizeE164(number, defaultCountry string) (string, bool) {
+	number = strings.TrimSpace(number)
+	for i, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '+' && i == 0:
+		case c == ' ' || c == '-' || c == '(' || c == ')' || c == '.':
+		default:
+			return "", false
+		}
+	}
+
+	n := numberDigits(number)
+	switch {
+	case strings.HasPrefix(number, "+"):
+	case strings.HasPrefix(n, "00"):
+		n = n[2:]
+	case defaultCountry != "":
+		cc := strings.TrimPrefix(defaultCountry, "+")
+		if !strings.HasPrefix(n, cc) || len(n) < minE164Digits+len(cc) {
+			if !e164TrunkCountries[cc] {
+				n = strings.TrimLeft(n, "0")
+			}
+			n = cc + n
+		}
+	default:
+		return "", false
+	}
+
+	if len(n) < minE164Digits || len(n) > maxE164Digits || n[0] == '0' {
+		return "", false
+	}
+
+	return "+" + n, true
+}
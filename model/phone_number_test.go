@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestNormalizeE164(t *testing.T) {
+	t.Log("NormalizeE164")
+
+	tests := []struct {
+		number, country, exp string
+		ok                   bool
+	}{
+		{"+1 (415) 555-2671", "", "+14155552671", true},
+		{"00380 44 123 45 67", "", "+380441234567", true},
+		{"044 123 45 67", "380", "+380441234567", true},
+		{"380441234567", "+380", "+380441234567", true},
+		{"4155552671", "", "", false},
+		{"+1 415 abc", "", "", false},
+		{"+12345", "", "", false},
+		{"+1234567890123456", "", "", false},
+		{"06 1234 5678", "39", "+390612345678", true},
+		{"+39 06 1234 5678", "", "+390612345678", true},
+	}
+
+	for _, v := range tests {
+		n, ok := NormalizeE164(v.number, v.country)
+		if n != v.exp || ok != v.ok {
+			t.Errorf("%s: got %s %v, expected %s %v", v.number, n, ok, v.exp, v.ok)
+		}
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	t.Log("NormalizeNumber")
+
+	tests := map[string]string{
+		"+380 (44) 123-45-67": "380441234567",
+		"00380441234567":      "380441234567",
+		"0441234567":          "441234567",
+	}
+	for number, exp := range tests {
+		if n := NormalizeNumber(number); n != exp {
+			t.Errorf("%s: got %s, expected %s", number, n, exp)
+		}
+	}
+}
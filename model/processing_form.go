@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	FormFieldString      = "string"
+	FormFieldNumber      = "number"
+	FormFieldInteger     = "integer"
+	FormFieldBool        = "bool"
+	FormFieldEmail       = "email"
+	FormFieldPhone       = "phone"
+	FormFieldDate        = "date"     // 2006-01-02
+	FormFieldDateTime    = "datetime" // RFC3339
+	FormFieldSelect      = "select"
+	FormFieldMultiSelect = "multiselect" // the options separated by the comma
+
+	FormFieldErrorRequired = "required"
+	FormFieldErrorType     = "type"
+	FormFieldErrorRegex    = "regex"
+	FormFieldErrorOption   = "option"
+	FormFieldErrorLength   = "length"
+	FormFieldErrorUnknown  = "unknown"
+)
+
+// FormFieldDefinition the field of the processing form, Visible - the expression over the fields of the form
+// and the variables of the attempt, the hidden field is not validated
+type FormFieldDefinition struct {
+	Id        string   `json:"id"`
+	Type      string   `json:"type"` // def string
+	Required  bool     `json:"required"`
+	Regex     string   `json:"regex"`
+	Options   []string `json:"options"`
+	MaxLength int      `json:"max_length"`
+	Visible   string   `json:"visible"`
+}
+
+// QueueFormDefinition the server-side definition of the processing form, configured in the queue payload:
+// {"form_definition": {"strict": true, "fields": [{"id": "email", "type": "email", "required": true}]}},
+// Strict rejects the fields that are not defined, the fields are not validated on the NoValidateActions
+type QueueFormDefinition struct {
+	Strict            bool                   `json:"strict"`
+	NoValidateActions []string               `json:"no_validate_actions"`
+	Fields            []*FormFieldDefinition `json:"fields"`
+}
+
+func QueueFormDefinitionFromBytes(data []byte) *QueueFormDefinition {
+	var payload struct {
+		FormDefinition *QueueFormDefinition `json:"form_definition"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.FormDefinition == nil || len(payload.FormDefinition.Fields) == 0 {
+		return nil
+	}
+
+	return payload.FormDefinition
+}
+
+func (f *FormFieldDefinition) IsValid() *AppError {
+	if f.Id == "" {
+		return NewAppError("FormFieldDefinition.IsValid", "model.form_field.is_valid.id", nil,
+			"field id is required", http.StatusBadRequest)
+	}
+
+	switch f.Type {
+	case "", FormFieldString, FormFieldNumber, FormFieldInteger, FormFieldBool, FormFieldEmail, FormFieldPhone,
+		FormFieldDate, FormFieldDateTime:
+	case FormFieldSelect, FormFieldMultiSelect:
+		if len(f.Options) == 0 {
+			return NewAppError("FormFieldDefinition.IsValid", "model.form_field.is_valid.options", nil,
+				fmt.Sprintf("field \"%s\" options are required", f.Id), http.StatusBadRequest)
+		}
+	default:
+		return NewAppError("FormFieldDefinition.IsValid", "model.form_field.is_valid.type", nil,
+			fmt.Sprintf("field \"%s\" bad type \"%s\"", f.Id, f.Type), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// FieldError the structured error of the field of the form
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewFormValidationError(where string, fields []*FieldError) *AppError {
+	details := make([]string, 0, len(fields))
+	for _, v := range fields {
+		details = append(details, fmt.Sprintf("%s: %s", v.Field, v.Message))
+	}
+
+	err := NewAppError(where, "model.form.validation.app_error", nil, strings.Join(details, "; "), http.StatusBadRequest)
+	err.Fields = fields
+
+	return err
+}
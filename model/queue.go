@@ -3,7 +3,10 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -18,6 +21,10 @@ const (
 	QueueTypeOutboundTask
 )
 
+const (
+	defaultCallbackConfirmDuration = 10
+)
+
 const (
 	QUEUE_SIDE_FLOW   = "flow"
 	QUEUE_SIDE_MEMBER = "member"
@@ -47,15 +54,18 @@ const (
 )
 
 const (
-	AgentTimeout   LeaveCause = "agent_timeout"
-	ClientTimeout             = "client_timeout"
-	SilenceTimeout            = "silence_timeout"
+	AgentTimeout     LeaveCause = "agent_timeout"
+	ClientTimeout               = "client_timeout"
+	SilenceTimeout              = "silence_timeout"
+	AgentHandover               = "agent_handover"
+	ParticipantLeave            = "participant_leave"
 )
 
 type LeaveCause string
 
 const (
 	ClientLeave CloseCause = "client_leave"
+	FlowEnd     CloseCause = "flow_end"
 )
 
 type CloseCause string
@@ -63,6 +73,25 @@ type CloseCause string
 const (
 	QueueAutoAnswerVariable = "wbt_auto_answer"
 	QueueManualDistribute   = "cc_manual_distribution"
+	QueueBotSummaryVariable = "cc_bot_summary"
+
+	QueueChatHandoverVariable = "cc_chat_handover_user_id"
+)
+
+const (
+	CallbackVariable             = "cc_callback"
+	CallbackAttemptIdVariable    = "cc_callback_attempt_id"
+	CallbackQueueIdVariable      = "cc_callback_queue_id"
+	CallbackMaxAttemptsVariable  = "cc_callback_max_attempts"
+	CallbackWaitBetweenVariable  = "cc_callback_wait_between"
+	CallbackMemberIdCallVariable = "cc_callback_member_id"
+)
+
+const (
+	QueuePositionVariable = "cc_position"
+	QueueEwtVariable      = "cc_ewt"
+
+	QueueOverflowFromVariable = "cc_overflow_from_queue_id"
 )
 
 type RingtoneFile struct {
@@ -85,6 +114,32 @@ func RingtoneUri(domainId int64, id int, mimeType string) string {
 	}
 }
 
+type TtsSettings struct {
+	Provider string `json:"provider"`
+	Voice    string `json:"voice"`
+	Language string `json:"language"`
+	// Text ${position}, ${ewt} and ${ewt_min} are replaced
+	Text string `json:"text"`
+}
+
+func TtsUri(domainId int64, tts *TtsSettings, text string) string {
+	q := url.Values{}
+	q.Set("domain_id", strconv.Itoa(int(domainId)))
+	q.Set("text", text)
+	if tts.Voice != "" {
+		q.Set("voice", tts.Voice)
+	}
+	if tts.Language != "" {
+		q.Set("language", tts.Language)
+	}
+	provider := tts.Provider
+	if provider == "" {
+		provider = "default"
+	}
+
+	return fmt.Sprintf("http_cache://http://$${cdr_url}/sys/tts/%s?%s&.wav", provider, q.Encode())
+}
+
 var ToneList = map[string]string{
 	"none":       "",
 	"default":    "L=1;%(500,500,1000)",
@@ -156,9 +211,119 @@ type QueueAmdSettings struct {
 	buildString          *string
 }
 
+// QueueCallbackSettings describe "virtual hold": every Timeout seconds of waiting the caller
+// is offered to press Digit and leave the queue, the member is created in QueueId
+// with the original join time, so it keeps the position.
+// The caller hears ConfirmFile and is hung up, or returns to the flow when ReturnFlow is set
 type QueueCallbackSettings struct {
-	Enabled bool  `json:"enabled"`
-	Timeout int32 `json:"timeout"`
+	Enabled            bool          `json:"enabled"`
+	Timeout            int32         `json:"timeout"`
+	Digit              string        `json:"digit"`
+	OfferFile          *RingtoneFile `json:"offer_file"`
+	ConfirmFile        *RingtoneFile `json:"confirm_file"`
+	ConfirmMaxDuration uint          `json:"confirm_max_duration"` // sec, the caller is hung up after the confirmation
+	ReturnFlow         bool          `json:"return_flow"`
+	QueueId            *int          `json:"queue_id"`
+	Priority           int           `json:"priority"`
+	MaxAttempts        uint          `json:"max_attempts"`
+	WaitBetweenRetries uint64        `json:"wait_between_retries"`
+}
+
+func (c *QueueCallbackSettings) Allow() bool {
+	return c.Enabled && c.QueueId != nil && *c.QueueId > 0
+}
+
+func (c *QueueCallbackSettings) ConfirmDuration() time.Duration {
+	if c.ConfirmMaxDuration == 0 {
+		return time.Second * defaultCallbackConfirmDuration
+	}
+
+	return time.Second * time.Duration(c.ConfirmMaxDuration)
+}
+
+func (c *QueueCallbackSettings) IsDigit(d rune) bool {
+	if c.Digit == "" {
+		return d == '1'
+	}
+
+	return string(d) == c.Digit
+}
+
+// QueueAnnouncementSettings periodic message for the waiting caller, Tts has priority over File
+type QueueAnnouncementSettings struct {
+	Enabled  bool          `json:"enabled"`
+	Interval uint16        `json:"interval"` // def 60 sec
+	File     *RingtoneFile `json:"file"`
+	Tts      *TtsSettings  `json:"tts"`
+}
+
+const (
+	OverflowActionQueue    = "queue"
+	OverflowActionTeam     = "team"
+	OverflowActionPriority = "priority"
+)
+
+// QueueOverflowRule all set conditions must match, the queue and priority actions are applied once
+type QueueOverflowRule struct {
+	After    uint16 `json:"after"`     // sec
+	EwtAbove uint16 `json:"ewt_above"` // sec
+	NoAgents bool   `json:"no_agents"`
+	Action   string `json:"action"`
+	QueueId  *int   `json:"queue_id"`
+	TeamId   *int   `json:"team_id"`
+	Priority int    `json:"priority"`
+}
+
+func (r *QueueOverflowRule) Valid() bool {
+	switch r.Action {
+	case OverflowActionQueue:
+		return r.QueueId != nil
+	case OverflowActionTeam:
+		return r.TeamId != nil
+	case OverflowActionPriority:
+		return r.Priority != 0
+	default:
+		return false
+	}
+}
+
+func (r *QueueOverflowRule) Match(waitSec, ewt, agents int) bool {
+	if waitSec < int(r.After) {
+		return false
+	}
+
+	if r.EwtAbove > 0 && ewt <= int(r.EwtAbove) {
+		return false
+	}
+
+	if r.NoAgents && agents > 0 {
+		return false
+	}
+
+	return true
+}
+
+type AttemptOverflow struct {
+	QueueId        int   `json:"queue_id" db:"queue_id"`
+	QueueUpdatedAt int64 `json:"queue_updated_at" db:"queue_updated_at"`
+	Weight         int   `json:"weight" db:"weight"`
+}
+
+type InboundQueueStats struct {
+	QueueId      int     `json:"queue_id" db:"queue_id"`
+	Agents       int     `json:"agents" db:"agents"`
+	Handled      int     `json:"handled" db:"handled"`
+	AvgHandleSec float64 `json:"avg_handle_sec" db:"avg_handle_sec"`
+	AvgWaitSec   float64 `json:"avg_wait_sec" db:"avg_wait_sec"`
+}
+
+// QueueEstimate the expected wait of the caller before the join to the queue
+type QueueEstimate struct {
+	QueueId  int `json:"queue_id"`
+	Waiting  int `json:"waiting"`
+	Position int `json:"position"`
+	Ewt      int `json:"ewt"`
+	Agents   int `json:"agents"`
 }
 
 type QueueHook struct {
@@ -186,6 +351,10 @@ type QueueInboundSettings struct {
 	StickyAgentSec     uint16  `json:"sticky_agent_sec"` // def 30 sec
 	AutoAnswerTone     *string `json:"auto_answer_tone"`
 	ManualDistribution bool    `json:"manual_distribution"`
+
+	Callback     QueueCallbackSettings     `json:"callback"`
+	Announcement QueueAnnouncementSettings `json:"announcement"`
+	Overflow     []QueueOverflowRule       `json:"overflow"`
 }
 
 func QueueInboundSettingsFromBytes(data []byte) QueueInboundSettings {
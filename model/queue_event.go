@@ -38,3 +38,94 @@ func (e *QueueEventCount) ToJSON() string {
 	data, _ := json.Marshal(e)
 	return string(data)
 }
+
+const (
+	SlaStatusOk       = "ok"
+	SlaStatusWarning  = "warning"
+	SlaStatusCritical = "critical"
+
+	SlaWindow15m = "15m"
+	SlaWindow1h  = "1h"
+	SlaWindowDay = "day"
+)
+
+// QueueSlaSettings e.g. 80/20: Target 80 percent answered in Threshold 20 sec
+type QueueSlaSettings struct {
+	Enabled             bool    `json:"enabled"`
+	Target              float64 `json:"target"`    // percent
+	Threshold           uint32  `json:"threshold"` // sec
+	ShortAbandon        uint32  `json:"short_abandon"`
+	ExcludeShortAbandon bool    `json:"exclude_short_abandon"`
+	Warning             float64 `json:"warning"`  // percent
+	Critical            float64 `json:"critical"` // percent
+	Window              string  `json:"window"`   // 15m, 1h, day
+}
+
+func QueueSlaSettingsFromBytes(data []byte) *QueueSlaSettings {
+	var payload struct {
+		Sla *QueueSlaSettings `json:"sla"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.Sla == nil || !payload.Sla.Enabled {
+		return nil
+	}
+
+	if payload.Sla.Threshold == 0 {
+		payload.Sla.Threshold = 20
+	}
+	if payload.Sla.Target == 0 {
+		payload.Sla.Target = 80
+	}
+	if payload.Sla.Warning == 0 {
+		payload.Sla.Warning = payload.Sla.Target
+	}
+	if payload.Sla.Window == "" {
+		payload.Sla.Window = SlaWindow15m
+	}
+
+	return payload.Sla
+}
+
+func (s *QueueSlaSettings) Status(level float64) string {
+	if s.Critical > 0 && level < s.Critical {
+		return SlaStatusCritical
+	}
+	if level < s.Warning {
+		return SlaStatusWarning
+	}
+
+	return SlaStatusOk
+}
+
+type QueueSla struct {
+	QueueId             int     `json:"-" db:"queue_id"`
+	Window              string  `json:"window" db:"window"`
+	Offered             int     `json:"offered" db:"offered"`
+	Answered            int     `json:"answered" db:"answered"`
+	AnsweredInThreshold int     `json:"answered_in_threshold" db:"answered_in_threshold"`
+	Abandoned           int     `json:"abandoned" db:"abandoned"`
+	ShortAbandoned      int     `json:"short_abandoned" db:"short_abandoned"`
+	Level               float64 `json:"level" db:"-"`
+}
+
+// SearchQueueSla the windows of the queue are counted from the attempts of all nodes
+type SearchQueueSla struct {
+	QueueId      int    `json:"queue_id"`
+	Threshold    uint32 `json:"threshold"`
+	ShortAbandon uint32 `json:"short_abandon"`
+}
+
+type QueueSlaEvent struct {
+	QueueEvent
+	DomainId  int64      `json:"domain_id"`
+	Status    string     `json:"status"`
+	Previous  string     `json:"previous"`
+	Target    float64    `json:"target"`
+	Threshold uint32     `json:"threshold"`
+	Sla       []QueueSla `json:"sla"`
+}
+
+func (e *QueueSlaEvent) ToJSON() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
@@ -0,0 +1,29 @@
+package model
+
+import "encoding/json"
+
+const (
+	QueueRoutingAgentFilter   = "agent_filter"
+	QueueRoutingPriorityBoost = "priority_boost"
+	QueueRoutingTimeout       = "timeout"
+)
+
+// QueueRouting the expressions of the routing over the attempt, the queue and the agent variables,
+// configured in the queue payload: {"routing": {"agent_filter": "agent.lang == usr_lang", "timeout": "usr_vip ? 600 : 120"}}
+type QueueRouting struct {
+	AgentFilter   string `json:"agent_filter"`   // the agent is offered when the result is true
+	PriorityBoost string `json:"priority_boost"` // added to the weight of the inbound attempt
+	Timeout       string `json:"timeout"`        // the max wait time of the attempt in seconds
+}
+
+func QueueRoutingFromBytes(data []byte) *QueueRouting {
+	var payload struct {
+		Routing *QueueRouting `json:"routing"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.Routing == nil || (payload.Routing.AgentFilter == "" && payload.Routing.PriorityBoost == "" && payload.Routing.Timeout == "") {
+		return nil
+	}
+
+	return payload.Routing
+}
@@ -20,8 +20,12 @@ var (
 type OutboundResourceUnReserveStrategy string
 
 type OutboundResourceParameters struct {
-	SipCidType       string `json:"cid_type" db:"-"`
-	IgnoreEarlyMedia string `json:"ignore_early_media" db:"-"`
+	SipCidType       string                  `json:"cid_type" db:"-"`
+	IgnoreEarlyMedia string                  `json:"ignore_early_media" db:"-"`
+	Health           *ResourceHealthSettings `json:"health,omitempty" db:"-"`
+	Weight           uint16                  `json:"weight,omitempty" db:"-"`
+	Rates            []ResourceRate          `json:"rates,omitempty" db:"-"`
+	CallerId         *CallerIdSettings       `json:"caller_id,omitempty" db:"-"`
 }
 
 type OutboundResource struct {
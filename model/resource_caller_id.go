@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+const (
+	CallerIdStrategyRandom = "random" // random display number of the resource
+	CallerIdStrategyLocal  = "local"  // the display number of the destination area or country by the prefix table
+	CallerIdStrategyRotate = "rotate" // the least used display number of the day
+
+	QueueCallerIdReasonVariable = "cc_caller_id_reason"
+)
+
+// CallerIdSettings selection of the display number of the resource,
+// configured in the parameters of the outbound resource: {"caller_id": {...}}
+type CallerIdSettings struct {
+	Strategy string   `json:"strategy"`
+	Prefixes []string `json:"prefixes"`  // area codes and countries, e.g. 1415, 1, 44
+	DailyCap uint32   `json:"daily_cap"` // calls per number per day, 0 - unlimited
+	Sticky   bool     `json:"sticky"`    // the same display for retries to the member
+	Timezone string   `json:"timezone"`  // the day of the daily cap, def UTC
+}
+
+func (s *CallerIdSettings) Allow() bool {
+	return s != nil && s.Strategy != "" && s.Strategy != CallerIdStrategyRandom
+}
+
+// Location the valid timezone of the daily cap
+func (s *CallerIdSettings) Location() string {
+	if s.Timezone == "" {
+		return "UTC"
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return "UTC"
+	}
+
+	return s.Timezone
+}
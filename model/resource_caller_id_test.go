@@ -0,0 +1,22 @@
+package model
+
+import "testing"
+
+func TestCallerIdLocation(t *testing.T) {
+	t.Log("CallerIdLocation")
+
+	s := &CallerIdSettings{}
+	if s.Location() != "UTC" {
+		t.Errorf("default: got %s", s.Location())
+	}
+
+	s.Timezone = "Europe/Kyiv"
+	if s.Location() != "Europe/Kyiv" {
+		t.Errorf("valid: got %s", s.Location())
+	}
+
+	s.Timezone = "Mars/Olympus"
+	if s.Location() != "UTC" {
+		t.Errorf("invalid: got %s", s.Location())
+	}
+}
@@ -0,0 +1,118 @@
+package model
+
+import "encoding/json"
+
+const (
+	ResourceHealthClosed   = "closed"    // healthy, all traffic
+	ResourceHealthDegraded = "degraded"  // part of the traffic
+	ResourceHealthOpen     = "open"      // circuit broken, no traffic
+	ResourceHealthHalfOpen = "half_open" // limited probe calls
+
+	MemberResultDeferred = "deferred" // no healthy resource, the member is ready after the open timeout
+)
+
+// ResourceHealthSettings the health of the resource is calculated over the last Window calls,
+// configured in the parameters of the outbound resource: {"health": {...}}
+type ResourceHealthSettings struct {
+	Enabled        bool        `json:"enabled"`
+	Window         uint16      `json:"window"`
+	MinCalls       uint16      `json:"min_calls"`
+	MinAsr         float64     `json:"min_asr"`         // percent, open below
+	DegradedAsr    float64     `json:"degraded_asr"`    // percent, degraded below
+	MaxSetupTime   uint32      `json:"max_setup_time"`  // ms, degraded above the average
+	ErrorCodes     StringArray `json:"error_codes"`     // masks e.g. 5XX, count as the failure of the gateway
+	MaxErrorRate   float64     `json:"max_error_rate"`  // percent, open above
+	DegradedWeight uint8       `json:"degraded_weight"` // percent of the traffic when degraded
+	OpenTimeout    uint32      `json:"open_timeout"`    // sec before half-open
+	HalfOpenCalls  uint16      `json:"half_open_calls"`
+}
+
+func (s *ResourceHealthSettings) Allow() bool {
+	return s != nil && s.Enabled
+}
+
+func (s *ResourceHealthSettings) WindowSize() int {
+	if s.Window == 0 {
+		return 50
+	}
+	return int(s.Window)
+}
+
+func (s *ResourceHealthSettings) MinCallsCount() int {
+	if s.MinCalls == 0 {
+		return 10
+	}
+	return int(s.MinCalls)
+}
+
+func (s *ResourceHealthSettings) Weight() int {
+	if s.DegradedWeight == 0 || s.DegradedWeight > 100 {
+		return 50
+	}
+	return int(s.DegradedWeight)
+}
+
+func (s *ResourceHealthSettings) OpenTimeoutSec() uint32 {
+	if s.OpenTimeout == 0 {
+		return 60
+	}
+	return s.OpenTimeout
+}
+
+func (s *ResourceHealthSettings) HalfOpenCallsCount() int {
+	if s.HalfOpenCalls == 0 {
+		return 3
+	}
+	return int(s.HalfOpenCalls)
+}
+
+type ResourceHealthStats struct {
+	Calls      int            `json:"calls"`
+	Answered   int            `json:"answered"`
+	Failures   int            `json:"failures"`
+	Asr        float64        `json:"asr"`
+	ErrorRate  float64        `json:"error_rate"`
+	AvgSetupMs int64          `json:"avg_setup_ms"`
+	Errors     map[string]int `json:"errors,omitempty"`
+}
+
+type ResourceHealth struct {
+	ResourceId int    `json:"resource_id"`
+	Name       string `json:"name"`
+	GatewayId  int64  `json:"gateway_id"`
+	DomainId   int64  `json:"domain_id"`
+	State      string `json:"state"`
+	Weight     int    `json:"weight"`
+	Cause      string `json:"cause,omitempty"`
+	ChangedAt  int64  `json:"changed_at"`
+	ResourceHealthStats
+}
+
+// ResourceHealthState the state of the breaker shared by the nodes, the newer change wins
+type ResourceHealthState struct {
+	ResourceId int    `json:"resource_id" db:"resource_id"`
+	DomainId   int64  `json:"domain_id" db:"domain_id"`
+	State      string `json:"state" db:"state"`
+	Cause      string `json:"cause" db:"cause"`
+	NodeId     string `json:"node_id" db:"node_id"`
+	ChangedAt  int64  `json:"changed_at" db:"changed_at"`
+}
+
+type GatewayHealth struct {
+	GatewayId int64 `json:"gateway_id"`
+	DomainId  int64 `json:"domain_id"`
+	ResourceHealthStats
+}
+
+type ResourceHealthEvent struct {
+	Name     string         `json:"name"`
+	Node     string         `json:"node"`
+	Time     int64          `json:"time"`
+	Previous string         `json:"previous"`
+	Health   ResourceHealth `json:"health"`
+}
+
+func (e *ResourceHealthEvent) ToJSON() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
@@ -0,0 +1,53 @@
+package model
+
+import "encoding/json"
+
+const (
+	ResourceStrategyWeighted  = "weighted"   // weighted round-robin by the weight of the resource
+	ResourceStrategyLeastCost = "least_cost" // the lowest cost of the destination prefix
+	ResourceStrategyLoad      = "load"       // the lowest utilization of the limit and rps
+	ResourceStrategyGeo       = "geo"        // the caller id closest to the destination
+	ResourceStrategyGroup     = "group"      // the strategy of the resource group
+
+	QueueResourceStrategyVariable = "cc_resource_strategy"
+	QueueResourceReasonVariable   = "cc_resource_reason"
+)
+
+// QueueResourceStrategy selection of the outbound resource of the attempt,
+// configured in the queue payload: {"resource_strategy": {"strategy": "least_cost"}}
+type QueueResourceStrategy struct {
+	Strategy string `json:"strategy"`
+}
+
+func QueueResourceStrategyFromBytes(data []byte) *QueueResourceStrategy {
+	var payload struct {
+		ResourceStrategy *QueueResourceStrategy `json:"resource_strategy"`
+	}
+	json.Unmarshal(data, &payload)
+	if payload.ResourceStrategy == nil || payload.ResourceStrategy.Strategy == "" {
+		return nil
+	}
+
+	return payload.ResourceStrategy
+}
+
+// ResourceRate cost of the call by the destination prefix
+type ResourceRate struct {
+	Prefix string  `json:"prefix"`
+	Cost   float64 `json:"cost"`
+}
+
+// ResourceCandidate the resource of the queue groups, Patterns - the "similar to" masks of the destination
+type ResourceCandidate struct {
+	ResourceId        int64       `json:"resource_id" db:"resource_id"`
+	ResourceUpdatedAt int64       `json:"resource_updated_at" db:"resource_updated_at"`
+	GatewayUpdatedAt  int64       `json:"gateway_updated_at" db:"gateway_updated_at"`
+	GroupId           int64       `json:"group_id" db:"group_id"`
+	GroupStrategy     string      `json:"group_strategy" db:"group_strategy"`
+	CommunicationId   int         `json:"communication_id" db:"communication_id"`
+	Patterns          StringArray `json:"patterns" db:"patterns"`
+	Priority          int         `json:"priority" db:"priority"`
+	Limit             int         `json:"limit" db:"limit"`
+	Used              int         `json:"used" db:"used"`
+	AllowCall         bool        `json:"allow_call" db:"allow_call"`
+}
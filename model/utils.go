@@ -46,13 +46,14 @@ func UtcTime(t *time.Time) *time.Time {
 }
 
 type AppError struct {
-	Id            string `json:"id"`
-	Message       string `json:"message"`               // Message to be display to the end user without debugging information
-	DetailedError string `json:"detail"`                // Internal error string to help the developer
-	RequestId     string `json:"request_id,omitempty"`  // The RequestId that's also set in the header
-	StatusCode    int    `json:"status_code,omitempty"` // The http status code
-	Where         string `json:"-"`                     // The function where it happened in the form of Struct.Func
-	IsOAuth       bool   `json:"is_oauth,omitempty"`    // Whether the error is OAuth specific
+	Id            string        `json:"id"`
+	Message       string        `json:"message"`               // Message to be display to the end user without debugging information
+	DetailedError string        `json:"detail"`                // Internal error string to help the developer
+	RequestId     string        `json:"request_id,omitempty"`  // The RequestId that's also set in the header
+	StatusCode    int           `json:"status_code,omitempty"` // The http status code
+	Where         string        `json:"-"`                     // The function where it happened in the form of Struct.Func
+	IsOAuth       bool          `json:"is_oauth,omitempty"`    // Whether the error is OAuth specific
+	Fields        []*FieldError `json:"fields,omitempty"`      // The errors of the fields of the form
 	params        map[string]interface{}
 }
 
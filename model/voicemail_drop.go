@@ -0,0 +1,73 @@
+package model
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	MemberResultVoicemail = "voicemail"
+
+	VoicemailDropAmd    = "amd"
+	VoicemailDropManual = "manual"
+
+	QueueVoicemailDropVariable = "cc_voicemail_drop" // amd or manual
+
+	defaultVoicemailDropSilence     = 500
+	defaultVoicemailDropMaxDuration = 60
+	defaultVoicemailDropGreeting    = 20
+)
+
+// QueueVoicemailDrop the machine action of the queue: the pre-recorded message is left after the greeting of the machine,
+// the attempt is finished with the voicemail result, Success stops the member otherwise the member is retried.
+// The media server does not report the beep of the machine, the end of the greeting is the Greeting timeout
+// after the detection, so it's set by the usual length of the greetings of the campaign
+type QueueVoicemailDrop struct {
+	File               *RingtoneFile `json:"file"`
+	Silence            uint          `json:"silence"`      // ms after the greeting before the message
+	Greeting           uint          `json:"greeting"`     // sec, the wait for the end of the greeting after the detection
+	MaxDuration        uint          `json:"max_duration"` // sec, the call is hung up after the message
+	Success            bool          `json:"success"`
+	WaitBetweenRetries uint64        `json:"wait_between_retries"`
+}
+
+type VoicemailDropRequest struct {
+	DomainId  int64 `json:"domain_id"`
+	AttemptId int64 `json:"attempt_id"`
+}
+
+func (d *QueueVoicemailDrop) Allow() bool {
+	return d != nil && d.File != nil && d.File.Id > 0
+}
+
+func (d *QueueVoicemailDrop) SilenceMs() uint {
+	if d.Silence == 0 {
+		return defaultVoicemailDropSilence
+	}
+
+	return d.Silence
+}
+
+func (d *QueueVoicemailDrop) WaitGreeting() time.Duration {
+	if d.Greeting == 0 {
+		return time.Second * defaultVoicemailDropGreeting
+	}
+
+	return time.Second * time.Duration(d.Greeting)
+}
+
+func (d *QueueVoicemailDrop) Duration() time.Duration {
+	if d.MaxDuration == 0 {
+		return time.Second * defaultVoicemailDropMaxDuration
+	}
+
+	return time.Second * time.Duration(d.MaxDuration)
+}
+
+func (r *VoicemailDropRequest) IsValid() *AppError {
+	if r.AttemptId == 0 {
+		return NewAppError("VoicemailDropRequest.IsValid", "model.voicemail_drop.is_valid.attempt_id", nil, "attempt_id is required", http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import "encoding/json"
+
+type WallboardAgent struct {
+	Id     int    `json:"id" db:"id"`
+	TeamId int    `json:"team_id" db:"team_id"`
+	Status string `json:"status" db:"status"`
+}
+
+type WallboardQueue struct {
+	QueueId        int            `json:"queue_id" db:"queue_id"`
+	Waiting        int            `json:"waiting" db:"waiting"`
+	LongestWaitSec int            `json:"longest_wait_sec" db:"longest_wait_sec"`
+	Active         int            `json:"active" db:"active"`
+	Handled        int            `json:"handled" db:"handled"`
+	Abandoned      int            `json:"abandoned" db:"abandoned"`
+	Agents         map[string]int `json:"agents,omitempty" db:"-"`
+	Sla            []QueueSla     `json:"sla,omitempty" db:"-"`
+}
+
+type WallboardTeam struct {
+	TeamId int            `json:"team_id"`
+	Agents map[string]int `json:"agents"`
+}
+
+type WallboardSnapshot struct {
+	Timestamp int64             `json:"timestamp"`
+	Queues    []*WallboardQueue `json:"queues"`
+	Teams     []*WallboardTeam  `json:"teams"`
+}
+
+func (s *WallboardSnapshot) ToJSON() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
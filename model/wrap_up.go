@@ -0,0 +1,42 @@
+package model
+
+import "encoding/json"
+
+const (
+	QueueWrapUpForcedVariable = "cc_wrap_up_forced"
+)
+
+// QueueWrapUp the after-call work of the queue, configured in the queue payload:
+// {"wrap_up": {"time": 30, "max_renewals": 3, "default_disposition": "no_result"}},
+// Time overrides the wrap up time of the team, the wrap_up_time of the disposition overrides both
+type QueueWrapUp struct {
+	Time               *uint32 `json:"time"`
+	MaxRenewals        int     `json:"max_renewals"`        // the limit of the processing renewals per attempt, 0 - unlimited
+	DefaultDisposition string  `json:"default_disposition"` // the result of the attempt when the processing timed out
+}
+
+func QueueWrapUpFromBytes(data []byte) *QueueWrapUp {
+	var payload struct {
+		WrapUp *QueueWrapUp `json:"wrap_up"`
+	}
+	json.Unmarshal(data, &payload)
+
+	return payload.WrapUp
+}
+
+// AttemptWrapUp the metric of the after-call work of the attempt
+type AttemptWrapUp struct {
+	AttemptId   int64  `json:"attempt_id" db:"attempt_id"`
+	DomainId    int64  `json:"domain_id" db:"domain_id"`
+	QueueId     *int   `json:"queue_id" db:"queue_id"`
+	AgentId     *int   `json:"agent_id" db:"agent_id"`
+	Disposition string `json:"disposition" db:"disposition"`
+	Forced      bool   `json:"forced" db:"forced"`
+	WrapTimeSec uint32 `json:"wrap_time_sec" db:"wrap_time_sec"`
+}
+
+// WrapUpExpired the processing of the attempt timed out, ended with the default disposition of the queue
+type WrapUpExpired struct {
+	AttemptId   int64  `json:"attempt_id" db:"attempt_id"`
+	Disposition string `json:"disposition" db:"disposition"`
+}
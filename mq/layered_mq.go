@@ -52,3 +52,11 @@ func (l *LayeredMQ) AgentChannelEvent(channel string, domainId int64, queueId in
 func (l *LayeredMQ) SendNotification(domainId int64, event *model.Notification) *model.AppError {
 	return l.MQLayer.SendNotification(domainId, event)
 }
+
+func (l *LayeredMQ) QueueSlaEvent(domainId int64, queueId int, e E) *model.AppError {
+	return l.MQLayer.QueueSlaEvent(domainId, queueId, e)
+}
+
+func (l *LayeredMQ) ResourceHealthEvent(domainId int64, resourceId int, e E) *model.AppError {
+	return l.MQLayer.ResourceHealthEvent(domainId, resourceId, e)
+}
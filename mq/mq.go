@@ -19,6 +19,8 @@ type MQ interface {
 	AgentChannelEvent(channel string, domainId int64, queueId int, userId int64, e E) *model.AppError
 
 	SendNotification(domainId int64, event *model.Notification) *model.AppError
+	QueueSlaEvent(domainId int64, queueId int, e E) *model.AppError
+	ResourceHealthEvent(domainId int64, resourceId int, e E) *model.AppError
 
 	QueueEvent() QueueEvent
 }
@@ -17,3 +17,11 @@ func NewQueueMQ(amqp mq.MQ) mq.QueueEvent {
 func (a *AMQP) QueueUpdateListMembers(channel string, domainId int64, queueId int, userId int64, e mq.E) *model.AppError {
 	return a.SendJSON(fmt.Sprintf("events.channel.%s.%d.%d.%d", channel, domainId, queueId, userId), []byte(e.ToJSON()))
 }
+
+func (a *AMQP) QueueSlaEvent(domainId int64, queueId int, e mq.E) *model.AppError {
+	return a.SendJSON(fmt.Sprintf("events.sla.%d.%d", domainId, queueId), []byte(e.ToJSON()))
+}
+
+func (a *AMQP) ResourceHealthEvent(domainId int64, resourceId int, e mq.E) *model.AppError {
+	return a.SendJSON(fmt.Sprintf("events.resource.%d.%d", domainId, resourceId), []byte(e.ToJSON()))
+}
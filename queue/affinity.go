@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/webitel/call_center/agent_manager"
+	"github.com/webitel/call_center/expression"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const minAffinity = model.AffinityMin
+
+type queueAffinity struct {
+	settings *model.QueueAffinity
+	vip      *expression.Program
+}
+
+// attemptAffinity the preferred agents of the attempt resolved on the first agent
+type attemptAffinity struct {
+	lastAgentId int
+	preferred   map[int]bool
+	teamId      int
+	vip         bool
+	best        string
+	since       time.Time
+	distributed bool // the distributor reserves the agents by the affinity
+}
+
+func newQueueAffinity(settings *model.QueueAffinity) (*queueAffinity, *model.AppError) {
+	if settings == nil {
+		return nil, nil
+	}
+
+	affinity := &queueAffinity{
+		settings: settings,
+	}
+
+	if settings.Vip != "" {
+		p, err := expression.Compile(settings.Vip)
+		if err != nil {
+			return nil, model.NewAppError("Queue.Affinity", "queue.affinity.vip.invalid", nil,
+				fmt.Sprintf("affinity.vip: %s", err.Error()), http.StatusBadRequest)
+		}
+		affinity.vip = p
+	}
+
+	return affinity, nil
+}
+
+func (af *attemptAffinity) level(agentId, teamId int) string {
+	switch {
+	case af.lastAgentId != 0 && af.lastAgentId == agentId:
+		return model.AffinityLastAgent
+	case af.preferred[agentId]:
+		return model.AffinityPreferredAgent
+	case af.teamId != 0 && af.teamId == teamId:
+		return model.AffinityPreferredTeam
+	}
+
+	return model.AffinityAny
+}
+
+// required the strength of the best level halves every half-life of the waiting
+func (af *attemptAffinity) required(wait, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 0
+	}
+
+	return model.AffinityWeight(af.best) * math.Pow(0.5, float64(wait)/float64(halfLife))
+}
+
+// accept the agent is accepted when the level of the agent is not weaker than the degraded best level
+func (af *attemptAffinity) accept(agentId, teamId int, wait, halfLife time.Duration) (string, bool) {
+	lvl := af.level(agentId, teamId)
+	r := af.required(wait, halfLife)
+
+	return lvl, r < minAffinity || model.AffinityWeight(lvl) >= r
+}
+
+// distribution the affinity of the distributor, nil when any agent is preferred
+func (af *attemptAffinity) distribution(halfLife uint32) *model.AttemptAffinity {
+	if af.best == model.AffinityAny {
+		return nil
+	}
+
+	res := &model.AttemptAffinity{
+		Since:    af.since.UnixNano() / int64(time.Millisecond),
+		HalfLife: halfLife,
+		Best:     model.AffinityWeight(af.best),
+		Min:      minAffinity,
+		Agents:   make(map[string]float64, len(af.preferred)+1),
+	}
+
+	for id := range af.preferred {
+		res.Agents[strconv.Itoa(id)] = model.AffinityWeight(model.AffinityPreferredAgent)
+	}
+	if af.lastAgentId != 0 {
+		res.Agents[strconv.Itoa(af.lastAgentId)] = model.AffinityWeight(model.AffinityLastAgent)
+	}
+	if af.teamId != 0 {
+		res.TeamId = af.teamId
+		res.TeamWeight = model.AffinityWeight(model.AffinityPreferredTeam)
+	}
+
+	return res
+}
+
+func (queue *BaseQueue) isVip(attempt *Attempt) bool {
+	if queue.affinity == nil {
+		return false
+	}
+
+	if queue.affinity.vip == nil {
+		v, _ := attempt.GetVariable(model.QueueAffinityVipVariable)
+		return v == "true"
+	}
+
+	ok, err := queue.affinity.vip.Bool(routingEnv(queue, attempt, nil))
+	if err != nil {
+		attempt.log.Error(fmt.Sprintf("affinity.vip: %s", err.Error()))
+		return false
+	}
+
+	return ok
+}
+
+// VipPriority the boost of the weight of the vip attempt
+func (queue *BaseQueue) VipPriority(attempt *Attempt) int {
+	if queue.affinity == nil || queue.affinity.settings.VipPriority == 0 || !queue.isVip(attempt) {
+		return 0
+	}
+
+	return queue.affinity.settings.VipPriority
+}
+
+func (queue *BaseQueue) resolveAffinity(attempt *Attempt) *attemptAffinity {
+	attempt.affinityOnce.Do(func() {
+		settings := queue.affinity.settings
+		af := &attemptAffinity{
+			preferred: make(map[int]bool),
+			since:     time.Now(),
+			best:      model.AffinityAny,
+		}
+		if attempt.member != nil && !attempt.member.CreatedAt.IsZero() {
+			af.since = attempt.member.CreatedAt
+		}
+
+		if settings.LastAgent {
+			id, err := queue.queueManager.store.Agent().LastHandled(queue.domainId, attempt.MemberId(), attempt.Destination(),
+				settings.LookbackDaysOrDefault())
+			if err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			}
+			af.lastAgentId = id
+		}
+
+		for _, id := range settings.PreferredAgents {
+			af.preferred[id] = true
+		}
+		if v, ok := attempt.GetVariable(model.QueueAffinityPreferredAgentsVariable); ok {
+			for _, id := range model.ParseAgentIds(v) {
+				af.preferred[id] = true
+			}
+		}
+
+		if v, ok := attempt.GetVariable(model.QueueAffinityPreferredTeamVariable); ok {
+			af.teamId, _ = strconv.Atoi(v)
+		}
+		if af.teamId == 0 && settings.PreferredTeamId != nil {
+			af.teamId = *settings.PreferredTeamId
+		}
+
+		if af.vip = queue.isVip(attempt); af.vip {
+			ids, err := queue.queueManager.store.Queue().BestSkilledAgentIds(queue.id, settings.VipAgentsLimit())
+			if err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			}
+			for _, id := range ids {
+				af.preferred[int(id)] = true
+			}
+		}
+
+		switch {
+		case af.lastAgentId != 0:
+			af.best = model.AffinityLastAgent
+		case len(af.preferred) != 0:
+			af.best = model.AffinityPreferredAgent
+		case af.teamId != 0:
+			af.best = model.AffinityPreferredTeam
+		}
+
+		if d := af.distribution(settings.HalfLifeSec()); d != nil {
+			if err := queue.queueManager.store.Member().SetAttemptAffinity(attempt.Id(), d.ToJson()); err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			} else {
+				af.distributed = true
+			}
+		}
+
+		attempt.affinity = af
+	})
+
+	return attempt.affinity
+}
+
+// DistributeAffinity the preferred agents of the attempt are reserved by the distributor
+func (queue *BaseQueue) DistributeAffinity(attempt *Attempt) {
+	if queue.affinity == nil {
+		return
+	}
+
+	queue.resolveAffinity(attempt)
+}
+
+// AllowAffinityAgent the agent is allowed by the affinity of the attempt, the level of the agent is saved to the variables,
+// the agent reserved by the distributor with the affinity of the attempt is allowed
+func (queue *BaseQueue) AllowAffinityAgent(attempt *Attempt, agent agent_manager.AgentObject) bool {
+	if queue.affinity == nil {
+		return true
+	}
+
+	distributed := attempt.affinity != nil && attempt.affinity.distributed
+	af := queue.resolveAffinity(attempt)
+	lvl, ok := af.accept(agent.Id(), agent.TeamId(), time.Since(af.since), time.Duration(queue.affinity.settings.HalfLifeSec())*time.Second)
+	if !ok && !distributed {
+		return false
+	}
+
+	attempt.AddVariables(map[string]string{
+		model.QueueAffinityVariable: lvl,
+	})
+	attempt.Journal(model.AttemptJournalRouting, map[string]interface{}{
+		"affinity": lvl,
+		"agent_id": agent.Id(),
+		"vip":      af.vip,
+	})
+
+	return true
+}
@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestAttemptAffinityDegrade(t *testing.T) {
+	t.Log("AttemptAffinityDegrade")
+
+	af := &attemptAffinity{
+		lastAgentId: 1,
+		preferred:   map[int]bool{2: true},
+		teamId:      10,
+		best:        model.AffinityLastAgent,
+	}
+	halfLife := 30 * time.Second
+
+	cases := []struct {
+		agentId, teamId int
+		wait            time.Duration
+		level           string
+		ok              bool
+	}{
+		{1, 20, 0, model.AffinityLastAgent, true},
+		{2, 20, 0, model.AffinityPreferredAgent, false},
+		{2, 20, 15 * time.Second, model.AffinityPreferredAgent, true},
+		{3, 10, 15 * time.Second, model.AffinityPreferredTeam, false},
+		{3, 10, 30 * time.Second, model.AffinityPreferredTeam, true},
+		{4, 20, 60 * time.Second, model.AffinityAny, false},
+		{4, 20, 130 * time.Second, model.AffinityAny, true},
+	}
+
+	for _, c := range cases {
+		lvl, ok := af.accept(c.agentId, c.teamId, c.wait, halfLife)
+		if lvl != c.level || ok != c.ok {
+			t.Errorf("agent %d wait %s: got %s %v, want %s %v", c.agentId, c.wait, lvl, ok, c.level, c.ok)
+		}
+	}
+
+	none := &attemptAffinity{best: model.AffinityAny}
+	if _, ok := none.accept(4, 20, 0, halfLife); !ok {
+		t.Errorf("no affinity: agent is not accepted")
+	}
+}
+
+func TestParseAgentIds(t *testing.T) {
+	t.Log("ParseAgentIds")
+
+	ids := model.ParseAgentIds(" 1, 2,x,,-3,4")
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 4 {
+		t.Errorf("got %v", ids)
+	}
+}
+
+func TestAttemptAffinityDistribution(t *testing.T) {
+	t.Log("AttemptAffinityDistribution")
+
+	af := &attemptAffinity{
+		lastAgentId: 1,
+		preferred:   map[int]bool{1: true, 2: true},
+		teamId:      10,
+		best:        model.AffinityLastAgent,
+		since:       time.Now(),
+	}
+
+	d := af.distribution(30)
+	if d == nil || d.Best != 1 || d.Min != minAffinity || d.HalfLife != 30 {
+		t.Fatalf("got %+v", d)
+	}
+
+	if d.Agents["1"] != 1 || d.Agents["2"] != 0.75 || d.TeamId != 10 || d.TeamWeight != 0.5 {
+		t.Errorf("weights: got %+v", d)
+	}
+
+	if (&attemptAffinity{best: model.AffinityAny}).distribution(30) != nil {
+		t.Errorf("no affinity: expected nil")
+	}
+}
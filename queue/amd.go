@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/call_manager"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+// amdHuman decides the result of the answering machine detection and stores the outcome for the analytics
+func (queue *CallingQueue) amdHuman(attempt *Attempt, call call_manager.Call, amd *model.QueueAmdSettings) bool {
+	human := IsHuman(call, amd)
+	if amd == nil || !amd.Enabled {
+		return human
+	}
+
+	o := &model.AmdOutcome{
+		AttemptId:       attempt.Id(),
+		DomainId:        queue.domainId,
+		QueueId:         queue.Id(),
+		SettingsVersion: amd.Version(),
+		Settings:        amd.ToJson(),
+		Ai:              amd.Ai,
+		AmdResult:       call.AmdResult(),
+		AmdCause:        call.AmdCause(),
+		Human:           human,
+		CreatedAt:       model.GetMillis(),
+	}
+
+	if amd.Ai {
+		ai := call.AiResult()
+		o.AiResult = ai.Result
+		o.AiError = ai.Error
+	}
+	o.Result = amdOutcomeResult(o, human)
+
+	if call.AmdAt() > 0 && call.AcceptAt() > 0 && call.AmdAt() > call.AcceptAt() {
+		o.DurationMs = call.AmdAt() - call.AcceptAt()
+	}
+
+	attempt.Journal(model.AttemptJournalAmd, map[string]interface{}{
+		"result":      o.Result,
+		"amd_result":  o.AmdResult,
+		"ai_result":   o.AiResult,
+		"duration_ms": o.DurationMs,
+		"version":     o.SettingsVersion,
+	})
+
+	go queue.queueManager.saveAmdOutcome(o)
+
+	return human
+}
+
+func amdOutcomeResult(o *model.AmdOutcome, human bool) string {
+	if o.Ai {
+		if o.AiError != "" || o.AiResult == "undefined" {
+			return model.AmdOutcomeNotSure
+		}
+	} else if o.AmdResult == call_manager.AmdNotSure {
+		return model.AmdOutcomeNotSure
+	}
+
+	if human {
+		return model.AmdOutcomeHuman
+	}
+
+	return model.AmdOutcomeMachine
+}
+
+func (qm *Manager) saveAmdOutcome(o *model.AmdOutcome) {
+	if err := qm.store.Amd().Save(o); err != nil {
+		qm.log.Error(err.Error(),
+			wlog.Err(err),
+			wlog.Int64("attempt_id", o.AttemptId),
+		)
+	}
+}
+
+// CorrectAmd the agent or the supervisor reports the real result of the detection
+func (qm *Manager) CorrectAmd(correction *model.AmdCorrection) *model.AppError {
+	if err := correction.IsValid(); err != nil {
+		return err
+	}
+
+	if attempt, ok := qm.GetAttempt(correction.AttemptId); ok && attempt.domainId == correction.DomainId {
+		attempt.Journal(model.AttemptJournalAmd, map[string]interface{}{
+			"correction": correction.Result,
+		})
+	}
+
+	return qm.store.Amd().Correct(correction)
+}
+
+func (qm *Manager) reportAmdCorrection(domainId, attemptId int64, result string) {
+	err := qm.CorrectAmd(&model.AmdCorrection{
+		DomainId:  domainId,
+		AttemptId: attemptId,
+		Result:    result,
+	})
+
+	if err != nil {
+		qm.log.Error(err.Error(),
+			wlog.Err(err),
+			wlog.Int64("attempt_id", attemptId),
+		)
+	}
+}
@@ -18,8 +18,11 @@ type App interface {
 	GetCall(id string) (*model.Call, *model.AppError)
 	GetChat(id string) (*chat.Conversation, *model.AppError)
 	QueueSettings() model.QueueSettings
+	FileSettings() model.FileSettings
+	StoreFile(path string, data []byte) (string, *model.AppError)
 	NotificationHideMember(domainId int64, queueId int, memberId *int64, agentId int) *model.AppError
 	NotificationInterceptAttempt(domainId int64, queueId int, channel string, attemptId int64, skipAgentId int32) *model.AppError
 	NotificationWaitingList(e *model.MemberWaitingByUsers) *model.AppError
+	NotificationChatAlert(alert *model.ChatAlert) *model.AppError
 	SetAgentBreakOut(agent agent_manager.AgentObject) *model.AppError
 }
@@ -83,6 +83,16 @@ type Attempt struct {
 	bridgedAt             int64
 	transferredAt         int64 // todo work in chat
 	manualDistribution    bool
+	voicemailDrop         *model.QueueVoicemailDrop
+	affinity              *attemptAffinity
+	affinityOnce          sync.Once
+	callerId              string // the display chosen by the caller id settings of the resource
+	manualReleased        bool   // the offer is canceled by the manual release of the agent
+
+	journal        []model.AttemptJournalEvent
+	journalMx      sync.Mutex
+	journalStored  int // the count of the events that are stored
+	journalStoreMx sync.Mutex
 
 	log *wlog.Logger
 }
@@ -248,7 +258,9 @@ func (a *Attempt) SetState(state string) {
 	}
 	a.Unlock()
 
+	a.journalState(state)
 	a.queue.Hook(state, a)
+	a.queue.Manager().Wallboard().AttemptState(a, state)
 }
 
 func (a *Attempt) GetState() string {
@@ -343,6 +355,12 @@ func (a *Attempt) FlipResource(res *model.AttemptFlipResource) {
 	a.member.ResourceUpdatedAt = res.ResourceUpdatedAt
 	a.member.GatewayUpdatedAt = res.GatewayUpdatedAt
 	a.member.MemberCallId = res.CallId
+
+	if res.ResourceId != nil {
+		a.Journal(model.AttemptJournalResource, map[string]interface{}{
+			"resource_id": *res.ResourceId,
+		})
+	}
 }
 
 func (a *Attempt) Display() string {
@@ -548,10 +566,9 @@ func (a *Attempt) SetResult(result string) {
 
 func (a *Attempt) Log(info string) {
 	a.log.Debug(fmt.Sprintf("attempt [%v] > %s", a.Id(), info))
-	//a.Logs = append(a.Logs, LogItem{
-	//	Time: model.GetMillis(),
-	//	Info: info,
-	//})
+	a.Journal(model.AttemptJournalInfo, map[string]interface{}{
+		"info": info,
+	})
 }
 
 func (a *Attempt) LogIfError(err error) {
@@ -563,7 +580,39 @@ func (a *Attempt) LogIfError(err error) {
 }
 
 func (a *Attempt) LogsData() []byte {
-	data, _ := json.Marshal(a)
+	var info json.RawMessage
+	if a.Info != nil {
+		if i := a.Info.Data(); json.Valid(i) {
+			info = i
+		}
+	}
+
+	a.RLock()
+	data, _ := json.Marshal(struct {
+		Id        int64                       `json:"id"`
+		MemberId  *int64                      `json:"member_id,omitempty"`
+		QueueId   int                         `json:"queue_id"`
+		DomainId  int64                       `json:"domain_id"`
+		Channel   string                      `json:"channel"`
+		State     string                      `json:"state"`
+		Result    *string                     `json:"result,omitempty"`
+		Variables map[string]string           `json:"variables,omitempty"`
+		Info      json.RawMessage             `json:"info,omitempty"`
+		Journal   []model.AttemptJournalEvent `json:"journal,omitempty"`
+	}{
+		Id:        a.member.Id,
+		MemberId:  a.member.MemberId,
+		QueueId:   a.member.QueueId,
+		DomainId:  a.domainId,
+		Channel:   a.channel,
+		State:     a.state,
+		Result:    a.member.Result,
+		Variables: a.member.Variables,
+		Info:      info,
+		Journal:   a.JournalEvents(),
+	})
+	a.RUnlock()
+
 	return data
 }
 
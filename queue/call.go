@@ -219,6 +219,8 @@ func (queue *CallingQueue) NewCallUseResource(callRequest *model.CallRequest, re
 }
 
 func (queue *CallingQueue) CallCheckResourceError(resource ResourceObject, call call_manager.Call) {
+	queue.reportResourceHealth(resource, call)
+
 	if call.Err() != nil {
 		queue.queueManager.SetResourceError(resource, fmt.Sprintf("%d", call.HangupCauseCode()))
 	} else {
@@ -226,6 +228,25 @@ func (queue *CallingQueue) CallCheckResourceError(resource ResourceObject, call
 	}
 }
 
+func (queue *CallingQueue) reportResourceHealth(resource ResourceObject, call call_manager.Call) {
+	var setupMs int64
+	var code string
+
+	answered := call.AcceptAt() > 0 || call.BridgeAt() > 0
+	setupAt := call.RingingAt()
+	if setupAt == 0 {
+		setupAt = call.AcceptAt()
+	}
+	if setupAt > 0 && call.InviteAt() > 0 && setupAt > call.InviteAt() {
+		setupMs = setupAt - call.InviteAt()
+	}
+	if !answered && call.HangupCauseCode() > 0 {
+		code = fmt.Sprintf("%d", call.HangupCauseCode())
+	}
+
+	queue.queueManager.resourceHealth.Report(resource, answered, setupMs, code)
+}
+
 func (queue *CallingQueue) GetTransferredCall(id string) (call_manager.Call, *model.AppError) {
 	var call call_manager.Call
 	var err *model.AppError
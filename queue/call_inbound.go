@@ -46,6 +46,25 @@ func (queue *InboundQueue) DistributeAttempt(attempt *Attempt) *model.AppError {
 	return nil
 }
 
+// waitTimeout the rest of max wait time, the time in the previous queue is counted after overflow
+// and in the queues with the overflow rules
+func (queue *InboundQueue) waitTimeout(attempt *Attempt) time.Duration {
+	t := queue.RoutingTimeout(attempt, time.Second*time.Duration(queue.props.MaxWaitTime))
+	if attempt.member.CreatedAt.IsZero() {
+		return t
+	}
+
+	if _, ok := attempt.GetVariable(model.QueueOverflowFromVariable); !ok && !queue.hasOverflow() {
+		return t
+	}
+
+	if t -= time.Since(attempt.member.CreatedAt); t < time.Second {
+		t = time.Second
+	}
+
+	return t
+}
+
 func (queue *InboundQueue) run(attempt *Attempt, mCall call_manager.Call) {
 	var err *model.AppError
 	defer attempt.Log("stopped queue")
@@ -61,6 +80,7 @@ func (queue *InboundQueue) run(attempt *Attempt, mCall call_manager.Call) {
 	}
 
 	attempt.SetState(model.MemberStateWaitAgent)
+	queue.setWaitingVariables(attempt, mCall)
 
 	attempts := 0
 
@@ -73,12 +93,47 @@ func (queue *InboundQueue) run(attempt *Attempt, mCall call_manager.Call) {
 	ags := attempt.On(AttemptHookDistributeAgent)
 
 	//TODO
-	timeout := time.NewTimer(time.Second * time.Duration(queue.props.MaxWaitTime))
+	timeout := time.NewTimer(queue.waitTimeout(attempt))
+
+	var callbackOffer <-chan time.Time
+	var callbackOffered, callback bool
+	if t := queue.callbackTicker(); t != nil {
+		defer t.Stop()
+		callbackOffer = t.C
+	}
+
+	var announcement <-chan time.Time
+	if t := queue.announcementTicker(); t != nil {
+		defer t.Stop()
+		announcement = t.C
+	}
+
+	var overflow <-chan time.Time
+	var overflowQueue QueueObject
+	overflowFired := make([]bool, len(queue.props.Overflow))
+	if t := queue.overflowTicker(); t != nil {
+		defer t.Stop()
+		overflow = t.C
+	}
 
 	for calling {
 		select {
 		case <-timeout.C:
 			calling = false
+		case <-overflow:
+			if overflowQueue = queue.checkOverflow(attempt, overflowFired); overflowQueue != nil {
+				calling = false
+			}
+		case <-announcement:
+			queue.announce(attempt, mCall)
+		case <-callbackOffer:
+			queue.offerCallback(attempt, mCall)
+			callbackOffered = true
+		case d := <-mCall.Dtmf():
+			if callbackOffered && queue.props.Callback.IsDigit(d) && queue.acceptCallback(attempt, mCall) {
+				callback = true
+				calling = false
+			}
 		case <-attempt.Context.Done():
 			calling = false
 		case <-attempt.Cancel():
@@ -272,7 +327,11 @@ func (queue *InboundQueue) run(attempt *Attempt, mCall call_manager.Call) {
 			}
 
 			if agentCall.BridgeAt() == 0 {
-				team.MissedAgentAndWaitingAttempt(attempt, agent)
+				if attempt.takeManualReleased() {
+					team.WaitingAgentAndWaitingAttempt(attempt, agent)
+				} else {
+					team.MissedAgentAndWaitingAttempt(attempt, agent)
+				}
 				attempt.SetState(model.MemberStateWaitAgent)
 				if agentCall != nil && agentCall.HangupAt() == 0 {
 					//TODO WaitForHangup
@@ -291,13 +350,27 @@ func (queue *InboundQueue) run(attempt *Attempt, mCall call_manager.Call) {
 		attempt.log.Warn(fmt.Sprintf("agent call %s no hangup", agentCall.Id()))
 	}
 
+	if overflowQueue != nil {
+		timeout.Stop()
+		attempt.Off(AttemptHookDistributeAgent, ags)
+		if err = overflowQueue.DistributeAttempt(attempt); err == nil {
+			return
+		}
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+
 	if agentCall != nil && agentCall.BridgeAt() > 0 {
 		team.Reporting(queue, attempt, agent, agentCall.ReportingAt() > 0, agentCall.Transferred())
+	} else if callback {
+		queue.queueManager.LeavingMember(attempt)
+		queue.finishCallback(attempt, mCall)
 	} else if !queue.queueManager.SendAfterDistributeSchema(attempt) {
 		queue.queueManager.Abandoned(attempt)
 	}
 
-	if mCall.HangupAt() == 0 && mCall.BridgeAt() == 0 {
+	if !callback && mCall.HangupAt() == 0 && mCall.BridgeAt() == 0 {
 		err = mCall.StopPlayback()
 		if err != nil {
 			attempt.log.Error(err.Error(),
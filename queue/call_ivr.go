@@ -152,6 +152,7 @@ func (queue *IVRQueue) run(attempt *Attempt) {
 
 	attempt.memberChannel = call
 
+	queue.queueManager.countCallerId(attempt, callerIdNumber)
 	call.Invite()
 	if call.Err() != nil {
 		// TODO
@@ -186,7 +187,7 @@ func (queue *IVRQueue) run(attempt *Attempt) {
 			case call_manager.CALL_STATE_DETECT_AMD, call_manager.CALL_STATE_ACCEPT:
 				// FIXME
 				if (state == call_manager.CALL_STATE_ACCEPT && queue.Amd != nil && queue.Amd.Enabled) ||
-					(state == call_manager.CALL_STATE_DETECT_AMD && !IsHuman(call, queue.Amd)) {
+					(state == call_manager.CALL_STATE_DETECT_AMD && !queue.amdHuman(attempt, call, queue.Amd)) {
 					continue
 				}
 
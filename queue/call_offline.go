@@ -67,6 +67,7 @@ func (queue *OfflineCallQueue) DistributeAttempt(attempt *Attempt) *model.AppErr
 }
 
 func (queue *OfflineCallQueue) run(team *agentTeam, attempt *Attempt, agent agent_manager.AgentObject) {
+	display := attempt.Display()
 
 	callRequest := &model.CallRequest{
 		Endpoints:    agent.GetCallEndpoints(),
@@ -151,7 +152,7 @@ func (queue *OfflineCallQueue) run(team *agentTeam, attempt *Attempt, agent agen
 			ParentId:    call.Id(),
 			Name:        attempt.Name(),
 			Destination: attempt.Destination(),
-			Display:     attempt.Display(),
+			Display:     display,
 			Timeout:     queue.OriginateTimeout,
 			Recordings:  queue.Recordings,
 			RecordMono:  queue.RecordMono,
@@ -173,6 +174,7 @@ func (queue *OfflineCallQueue) run(team *agentTeam, attempt *Attempt, agent agen
 				team.Offering(attempt, agent, call, nil)
 			case call_manager.CALL_STATE_ACCEPT:
 				team.Answered(attempt, agent)
+				queue.queueManager.countCallerId(attempt, display)
 			case call_manager.CALL_STATE_BRIDGE:
 				team.Bridged(attempt, agent)
 				if queue.transferAfter != "" {
@@ -19,15 +19,16 @@ type PredictCallQueueSettings struct {
 	RecordMono bool `json:"record_mono"`
 	RecordAll  bool `json:"record_all"`
 
-	MaxWaitTime            uint16                  `json:"max_wait_time"`
-	WaitBetweenRetries     uint64                  `json:"wait_between_retries"`
-	WaitBetweenRetriesDesc bool                    `json:"wait_between_retries_desc"`
-	MaxAttempts            uint                    `json:"max_attempts"`
-	PerNumbers             bool                    `json:"per_numbers"`
-	OriginateTimeout       uint16                  `json:"originate_timeout"`
-	RetryAbandoned         bool                    `json:"retry_abandoned"`
-	AllowGreetingAgent     bool                    `json:"allow_greeting_agent"`
-	Amd                    *model.QueueAmdSettings `json:"amd"`
+	MaxWaitTime            uint16                    `json:"max_wait_time"`
+	WaitBetweenRetries     uint64                    `json:"wait_between_retries"`
+	WaitBetweenRetriesDesc bool                      `json:"wait_between_retries_desc"`
+	MaxAttempts            uint                      `json:"max_attempts"`
+	PerNumbers             bool                      `json:"per_numbers"`
+	OriginateTimeout       uint16                    `json:"originate_timeout"`
+	RetryAbandoned         bool                      `json:"retry_abandoned"`
+	AllowGreetingAgent     bool                      `json:"allow_greeting_agent"`
+	Amd                    *model.QueueAmdSettings   `json:"amd"`
+	VoicemailDrop          *model.QueueVoicemailDrop `json:"voicemail_drop"`
 
 	MinAttempts      uint    `json:"min_attempts"`
 	MaxAbandonedRate uint    `json:"max_abandoned_rate"`
@@ -73,6 +74,7 @@ func (queue *PredictCallQueue) DistributeAttempt(attempt *Attempt) *model.AppErr
 	attempt.waitBetween = queue.WaitBetweenRetries
 	attempt.maxAttempts = queue.MaxAttempts
 	attempt.perNumbers = queue.PerNumbers
+	attempt.applyCallbackRetries()
 
 	go queue.runPark(attempt)
 
@@ -191,7 +193,6 @@ retry_:
 	attempt.Log("make call")
 
 	if allowCall {
-
 		if queue.Recordings {
 			queue.SetRecordings(mCall, queue.RecordAll, queue.RecordMono)
 		}
@@ -200,6 +201,8 @@ retry_:
 			callRequest.Applications = append(callRequest.Applications, &model.CallRequestApplication{
 				AppName: "park",
 			})
+		} else {
+			setVoicemailMachine(callRequest, queue.VoicemailDrop)
 		}
 
 		if attempt.communication.Dtmf != nil {
@@ -215,6 +218,9 @@ retry_:
 	}
 
 	attempt.memberChannel = mCall
+	if allowCall {
+		queue.queueManager.countCallerId(attempt, callerIdNumber)
+	}
 	mCall.Invite()
 
 	var calling = true
@@ -229,7 +235,11 @@ retry_:
 			switch state {
 			case call_manager.CALL_STATE_ACCEPT, call_manager.CALL_STATE_DETECT_AMD:
 				// FIXME
-				if (state == call_manager.CALL_STATE_ACCEPT && !mCall.HasAmdError() && queue.Amd != nil && queue.Amd.Enabled) || (state == call_manager.CALL_STATE_DETECT_AMD && !IsHuman(mCall, queue.Amd)) {
+				if state == call_manager.CALL_STATE_ACCEPT && !mCall.HasAmdError() && queue.Amd != nil && queue.Amd.Enabled {
+					continue
+				}
+				if state == call_manager.CALL_STATE_DETECT_AMD && !queue.amdHuman(attempt, mCall, queue.Amd) {
+					queue.amdMachine(attempt, mCall, queue.VoicemailDrop)
 					continue
 				}
 
@@ -246,6 +256,11 @@ retry_:
 	}
 	queue.CallCheckResourceError(attempt.resource, mCall)
 
+	if drop := attempt.VoicemailDrop(); drop != nil {
+		queue.leavingVoicemail(attempt, nil, nil, drop)
+		return
+	}
+
 last_:
 
 	if res, ok := attempt.AfterDistributeSchema(); ok {
@@ -327,7 +342,7 @@ func (queue *PredictCallQueue) runOfferingAgents(attempt *Attempt, mCall call_ma
 	ags := attempt.On(AttemptHookDistributeAgent)
 
 	//TODO
-	timeout := time.NewTimer(time.Second * time.Duration(queue.MaxWaitTime))
+	timeout := time.NewTimer(queue.RoutingTimeout(attempt, time.Second*time.Duration(queue.MaxWaitTime)))
 
 	for calling {
 		select {
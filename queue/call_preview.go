@@ -20,12 +20,13 @@ type PreviewSettings struct {
 	RecordMono bool `json:"record_mono"`
 	RecordAll  bool `json:"record_all"`
 
-	OriginateTimeout       uint16 `json:"originate_timeout"`
-	WaitBetweenRetries     uint64 `json:"wait_between_retries"`
-	MaxAttempts            uint   `json:"max_attempts"`
-	PerNumbers             bool   `json:"per_numbers"`
-	WaitBetweenRetriesDesc bool   `json:"wait_between_retries_desc"`
-	AllowGreetingAgent     bool   `json:"allow_greeting_agent"`
+	OriginateTimeout       uint16                    `json:"originate_timeout"`
+	WaitBetweenRetries     uint64                    `json:"wait_between_retries"`
+	MaxAttempts            uint                      `json:"max_attempts"`
+	PerNumbers             bool                      `json:"per_numbers"`
+	WaitBetweenRetriesDesc bool                      `json:"wait_between_retries_desc"`
+	AllowGreetingAgent     bool                      `json:"allow_greeting_agent"`
+	VoicemailDrop          *model.QueueVoicemailDrop `json:"voicemail_drop"`
 	transferAfter          string
 	usePark                bool
 }
@@ -74,6 +75,7 @@ func (queue *PreviewCallQueue) DistributeAttempt(attempt *Attempt) *model.AppErr
 	attempt.waitBetween = queue.WaitBetweenRetries
 	attempt.maxAttempts = queue.MaxAttempts
 	attempt.perNumbers = queue.PerNumbers
+	attempt.applyCallbackRetries()
 
 	go queue.run(team, attempt, attempt.Agent())
 
@@ -214,6 +216,7 @@ func (queue *PreviewCallQueue) run(team *agentTeam, attempt *Attempt, agent agen
 
 			case call_manager.CALL_STATE_ACCEPT:
 				team.Answered(attempt, agent)
+				queue.queueManager.countCallerId(attempt, display)
 			case call_manager.CALL_STATE_BRIDGE:
 				team.Bridged(attempt, agent)
 				if queue.AllowGreetingAgent {
@@ -254,7 +257,9 @@ func (queue *PreviewCallQueue) run(team *agentTeam, attempt *Attempt, agent agen
 		}
 	}
 
-	if call.AcceptAt() > 0 && call.BridgeAt() == 0 && !queue.Processing() {
+	if drop := attempt.VoicemailDrop(); drop != nil {
+		queue.leavingVoicemail(attempt, team, agent, drop)
+	} else if call.AcceptAt() > 0 && call.BridgeAt() == 0 && !queue.Processing() {
 		team.SetWrap(queue, attempt, agent, AttemptResultAbandoned)
 	} else if call.AcceptAt() > 0 || attempt.Callback() != nil {
 		team.Reporting(queue, attempt, agent, call.ReportingAt() > 0, call.Transferred())
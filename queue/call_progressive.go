@@ -20,14 +20,15 @@ type ProgressiveCallQueueSettings struct {
 	RecordMono bool `json:"record_mono"`
 	RecordAll  bool `json:"record_all"`
 
-	WaitBetweenRetries     uint64                  `json:"wait_between_retries"`
-	WaitBetweenRetriesDesc bool                    `json:"wait_between_retries_desc"`
-	MaxAttempts            uint                    `json:"max_attempts"`
-	PerNumbers             bool                    `json:"per_numbers"`
-	OriginateTimeout       uint16                  `json:"originate_timeout"`
-	AllowGreetingAgent     bool                    `json:"allow_greeting_agent"`
-	Amd                    *model.QueueAmdSettings `json:"amd"`
-	AutoAnswerTone         *string                 `json:"auto_answer_tone"`
+	WaitBetweenRetries     uint64                    `json:"wait_between_retries"`
+	WaitBetweenRetriesDesc bool                      `json:"wait_between_retries_desc"`
+	MaxAttempts            uint                      `json:"max_attempts"`
+	PerNumbers             bool                      `json:"per_numbers"`
+	OriginateTimeout       uint16                    `json:"originate_timeout"`
+	AllowGreetingAgent     bool                      `json:"allow_greeting_agent"`
+	Amd                    *model.QueueAmdSettings   `json:"amd"`
+	VoicemailDrop          *model.QueueVoicemailDrop `json:"voicemail_drop"`
+	AutoAnswerTone         *string                   `json:"auto_answer_tone"`
 	transferAfter          string
 }
 
@@ -67,6 +68,7 @@ func (queue *ProgressiveCallQueue) DistributeAttempt(attempt *Attempt) *model.Ap
 	attempt.waitBetween = queue.WaitBetweenRetries
 	attempt.maxAttempts = queue.MaxAttempts
 	attempt.perNumbers = queue.PerNumbers
+	attempt.applyCallbackRetries()
 
 	go queue.run(attempt, team, attempt.Agent())
 
@@ -164,6 +166,8 @@ func (queue *ProgressiveCallQueue) run(attempt *Attempt, team *agentTeam, agent
 		callRequest.Applications = append(callRequest.Applications, &model.CallRequestApplication{
 			AppName: "park",
 		})
+	} else {
+		setVoicemailMachine(callRequest, queue.VoicemailDrop)
 	}
 
 	if attempt.Canceled() {
@@ -175,6 +179,7 @@ func (queue *ProgressiveCallQueue) run(attempt *Attempt, team *agentTeam, agent
 	//FIXME update member call id
 	team.Distribute(queue, agent, NewDistributeEvent(attempt, agent.UserId(), queue, agent, queue.Processing(), nil, mCall))
 	attempt.memberChannel = mCall
+	queue.queueManager.countCallerId(attempt, callerIdNumber)
 	mCall.Invite()
 
 	var calling = true
@@ -187,7 +192,11 @@ func (queue *ProgressiveCallQueue) run(attempt *Attempt, team *agentTeam, agent
 			switch state {
 			case call_manager.CALL_STATE_ACCEPT, call_manager.CALL_STATE_DETECT_AMD:
 				// FIXME
-				if (state == call_manager.CALL_STATE_ACCEPT && queue.Amd != nil && queue.Amd.Enabled) || (state == call_manager.CALL_STATE_DETECT_AMD && !IsHuman(mCall, queue.Amd)) {
+				if state == call_manager.CALL_STATE_ACCEPT && queue.Amd != nil && queue.Amd.Enabled {
+					continue
+				}
+				if state == call_manager.CALL_STATE_DETECT_AMD && !queue.amdHuman(attempt, mCall, queue.Amd) {
+					queue.amdMachine(attempt, mCall, queue.VoicemailDrop)
 					continue
 				}
 
@@ -343,7 +352,9 @@ func (queue *ProgressiveCallQueue) run(attempt *Attempt, team *agentTeam, agent
 
 	queue.CallCheckResourceError(attempt.resource, mCall)
 
-	if agentCall == nil {
+	if drop := attempt.VoicemailDrop(); drop != nil && agentCall == nil {
+		queue.leavingVoicemail(attempt, team, agent, drop)
+	} else if agentCall == nil {
 		team.Cancel(attempt, agent)
 		queue.queueManager.LeavingMember(attempt)
 	} else {
@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/webitel/call_center/call_manager"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	AttemptResultCallback = "callback"
+)
+
+// callbackTicker repeats the offer every Timeout seconds while the caller is waiting
+func (queue *InboundQueue) callbackTicker() *time.Ticker {
+	if !queue.props.Callback.Allow() || queue.props.Callback.Timeout <= 0 {
+		return nil
+	}
+
+	return time.NewTicker(time.Second * time.Duration(queue.props.Callback.Timeout))
+}
+
+func (queue *InboundQueue) offerCallback(attempt *Attempt, mCall call_manager.Call) {
+	attempt.Log("offer callback")
+	if queue.props.Callback.OfferFile == nil {
+		return
+	}
+
+	if err := mCall.BroadcastPlaybackFile(queue.domainId, queue.props.Callback.OfferFile, "aleg"); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+// acceptCallback creates member in the callback queue, the inbound attempt is finished with the callback result
+func (queue *InboundQueue) acceptCallback(attempt *Attempt, mCall call_manager.Call) bool {
+	cb := queue.props.Callback
+	vars := map[string]string{
+		model.CallbackVariable:          "true",
+		model.CallbackAttemptIdVariable: strconv.Itoa(int(attempt.Id())),
+		model.CallbackQueueIdVariable:   strconv.Itoa(queue.id),
+	}
+
+	if cb.MaxAttempts > 0 {
+		vars[model.CallbackMaxAttemptsVariable] = strconv.Itoa(int(cb.MaxAttempts))
+	}
+	if cb.WaitBetweenRetries > 0 {
+		vars[model.CallbackWaitBetweenVariable] = strconv.Itoa(int(cb.WaitBetweenRetries))
+	}
+
+	position, ewt, appErr := queue.queueManager.WaitingPosition(attempt)
+	if appErr != nil {
+		attempt.log.Error(appErr.Error(),
+			wlog.Err(appErr),
+		)
+	}
+	vars[model.QueuePositionVariable] = strconv.Itoa(position)
+	vars[model.QueueEwtVariable] = strconv.Itoa(ewt)
+
+	memberId, err := queue.queueManager.store.Member().CreateCallbackMember(attempt.Id(), *cb.QueueId, attempt.Name(),
+		attempt.Destination(), cb.Priority, vars)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return false
+	}
+
+	attempt.Log(fmt.Sprintf("callback member %d created in queue %d, position %d", memberId, *cb.QueueId, position))
+
+	attemptVars := map[string]string{
+		model.CallbackMemberIdCallVariable: strconv.Itoa(int(memberId)),
+	}
+
+	if err = mCall.SerVariables(map[string]string{
+		"cc_result":                        AttemptResultCallback,
+		model.CallbackMemberIdCallVariable: attemptVars[model.CallbackMemberIdCallVariable],
+	}); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+
+	res, err := queue.queueManager.store.Member().SetAttemptResult(attempt.Id(), AttemptResultCallback, "", 0, attemptVars,
+		0, 0, false, attempt.description, nil)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	} else if res.MemberStopCause != nil {
+		attempt.SetMemberStopCause(res.MemberStopCause)
+	}
+	attempt.SetResult(AttemptResultCallback)
+
+	return true
+}
+
+// finishCallback the caller who accepted the callback returns to the flow or hears the confirmation and is hung up
+func (queue *InboundQueue) finishCallback(attempt *Attempt, mCall call_manager.Call) {
+	if mCall.HangupAt() != 0 {
+		return
+	}
+
+	cb := queue.props.Callback
+	if cb.ReturnFlow {
+		attempt.Log("callback accepted, return to the flow")
+		printfIfErr(mCall.StopPlayback())
+		return
+	}
+
+	go func() {
+		if cb.ConfirmFile != nil {
+			if err := mCall.BroadcastPlaybackFile(queue.domainId, cb.ConfirmFile, "aleg"); err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			} else {
+				select {
+				case <-mCall.HangupChan():
+					return
+				case <-time.After(cb.ConfirmDuration()):
+				}
+			}
+		}
+
+		attempt.Log("callback accepted, hangup")
+		printfIfErr(mCall.Hangup(model.CALL_HANGUP_NORMAL_CLEARING, false, nil))
+	}()
+}
+
+// applyCallbackRetries overrides the retry policy of the outbound queue for members created by the callback offer
+func (a *Attempt) applyCallbackRetries() {
+	if v, ok := a.GetVariable(model.CallbackVariable); !ok || v != "true" {
+		return
+	}
+
+	if v, ok := a.GetVariable(model.CallbackMaxAttemptsVariable); ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			a.maxAttempts = uint(n)
+		}
+	}
+
+	if v, ok := a.GetVariable(model.CallbackWaitBetweenVariable); ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			a.waitBetween = uint64(n)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func TestCallbackSettings(t *testing.T) {
+	t.Log("CallbackSettings")
+
+	cb := model.QueueCallbackSettings{Enabled: true}
+	if cb.Allow() {
+		t.Errorf("queue_id is not set")
+	}
+	cb.QueueId = model.NewInt(1)
+	if !cb.Allow() || !cb.IsDigit('1') || cb.IsDigit('2') {
+		t.Errorf("default digit: got %v %v", cb.Allow(), cb.IsDigit('1'))
+	}
+	cb.Digit = "9"
+	if !cb.IsDigit('9') || cb.IsDigit('1') {
+		t.Errorf("digit 9")
+	}
+	if cb.ConfirmDuration() != 10*time.Second {
+		t.Errorf("default confirm duration: got %s", cb.ConfirmDuration())
+	}
+}
+
+func TestCallbackRetries(t *testing.T) {
+	t.Log("CallbackRetries")
+
+	attempt := NewAttempt(context.Background(), &model.MemberAttempt{
+		Id: 1,
+		Variables: map[string]string{
+			model.CallbackVariable:            "true",
+			model.CallbackMaxAttemptsVariable: "3",
+			model.CallbackWaitBetweenVariable: "60",
+		},
+	}, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	attempt.applyCallbackRetries()
+
+	if attempt.maxAttempts != 3 || attempt.waitBetween != 60 {
+		t.Errorf("got max_attempts %d, wait_between %d", attempt.maxAttempts, attempt.waitBetween)
+	}
+
+	attempt = NewAttempt(context.Background(), &model.MemberAttempt{
+		Id:        2,
+		Variables: map[string]string{model.CallbackMaxAttemptsVariable: "3"},
+	}, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	attempt.applyCallbackRetries()
+	if attempt.maxAttempts == 3 {
+		t.Errorf("not a callback member")
+	}
+}
@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+// localNumbers display numbers of the longest prefix of the table that matches the destination,
+// e.g. the area code before the country
+func localNumbers(prefixes []string, destination string, numbers []string) ([]string, string) {
+	destination = model.NormalizeNumber(destination)
+	matched := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		p = model.NormalizeNumber(p)
+		if p != "" && strings.HasPrefix(destination, p) {
+			matched = append(matched, p)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return len(matched[i]) > len(matched[j])
+	})
+
+	for _, p := range matched {
+		res := make([]string, 0, len(numbers))
+		for _, n := range numbers {
+			if strings.HasPrefix(model.NormalizeNumber(n), p) {
+				res = append(res, n)
+			}
+		}
+		if len(res) > 0 {
+			return res, p
+		}
+	}
+
+	return nil, ""
+}
+
+// rotateNumbers orders the numbers by the calls of the day, the same usage in the random order
+func rotateNumbers(numbers []string, usage map[string]int) []string {
+	res := make([]string, len(numbers))
+	copy(res, numbers)
+	rand.Shuffle(len(res), func(i, j int) {
+		res[i], res[j] = res[j], res[i]
+	})
+	sort.SliceStable(res, func(i, j int) bool {
+		return usage[res[i]] < usage[res[j]]
+	})
+
+	return res
+}
+
+// callerIdDisplay chooses the display number of the resource for the attempt by the caller id settings of the resource,
+// the daily cap is counted by the placed calls
+func (qm *Manager) callerIdDisplay(attempt *Attempt, resource ResourceObject) string {
+	settings := resource.CallerId()
+	numbers := resource.DisplayNumbers()
+	if !settings.Allow() || len(numbers) == 0 {
+		return resource.GetDisplay()
+	}
+
+	usage, err := qm.store.OutboundResource().DisplayUsage(resource.Gateway().DomainId, numbers, settings.Location())
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return resource.GetDisplay()
+	}
+
+	var last string
+	if settings.Sticky && attempt.MemberId() != nil {
+		if last, err = qm.store.Member().LastDisplay(*attempt.MemberId(), numbers); err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		}
+	}
+
+	display, reason := selectCallerId(settings, attempt.Destination(), numbers, usage, last)
+	if display == "" {
+		resource.Log().Warn(fmt.Sprintf("resource %s [%d] display numbers reached the daily cap %d", resource.Name(), resource.Id(), settings.DailyCap))
+		display = resource.GetDisplay()
+	}
+
+	attempt.Log(fmt.Sprintf("caller id %s: %s", display, reason))
+	attempt.AddVariables(map[string]string{
+		model.QueueCallerIdReasonVariable: reason,
+	})
+
+	return display
+}
+
+// selectCallerId the number under the daily cap, returns the empty number when all numbers reached the cap
+func selectCallerId(settings *model.CallerIdSettings, destination string, numbers []string, usage map[string]int, last string) (string, string) {
+	underCap := func(n string) bool {
+		return settings.DailyCap == 0 || uint32(usage[n]) < settings.DailyCap
+	}
+
+	if last != "" && underCap(last) {
+		return last, "sticky"
+	}
+
+	candidates := numbers
+	reason := "rotate"
+	if settings.Strategy == model.CallerIdStrategyLocal {
+		if local, prefix := localNumbers(settings.Prefixes, destination, numbers); len(local) > 0 {
+			candidates = local
+			reason = "local " + prefix
+		} else {
+			reason = "no local"
+		}
+	}
+
+	sets := [][]string{candidates}
+	if len(candidates) != len(numbers) {
+		sets = append(sets, numbers)
+	}
+
+	for i, set := range sets {
+		if i > 0 {
+			reason = "local daily cap"
+		}
+
+		for _, n := range rotateNumbers(set, usage) {
+			if underCap(n) {
+				return n, reason
+			}
+		}
+	}
+
+	return "", "daily cap"
+}
+
+// setCallerIdDisplay sets the display of the attempt by the caller id settings of the resource
+func (qm *Manager) setCallerIdDisplay(attempt *Attempt) {
+	if attempt.resource == nil || !attempt.resource.CallerId().Allow() {
+		return
+	}
+
+	display := qm.callerIdDisplay(attempt, attempt.resource)
+	attempt.communication.Display = model.NewString(display)
+	attempt.callerId = display
+}
+
+// countCallerId counts the placed call of the display chosen by the caller id settings
+func (qm *Manager) countCallerId(attempt *Attempt, display string) {
+	if attempt.resource == nil || attempt.callerId == "" || attempt.callerId != display {
+		return
+	}
+
+	settings := attempt.resource.CallerId()
+	if !settings.Allow() || settings.DailyCap == 0 {
+		return
+	}
+
+	if err := qm.store.OutboundResource().CountDisplay(attempt.resource.Gateway().DomainId, display, settings.Location()); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
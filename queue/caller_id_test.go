@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestLocalNumbers(t *testing.T) {
+	t.Log("LocalNumbers")
+
+	prefixes := []string{"1", "1415", "1212", "44"}
+	numbers := []string{"+12125550100", "+14155550100", "+14155550101", "+18005550100"}
+
+	res, prefix := localNumbers(prefixes, "+1 (415) 555-9999", numbers)
+	if prefix != "1415" || len(res) != 2 {
+		t.Errorf("area: got %s %v", prefix, res)
+	}
+
+	res, prefix = localNumbers(prefixes, "+1 303 555 9999", numbers)
+	if prefix != "1" || len(res) != 4 {
+		t.Errorf("country: got %s %v", prefix, res)
+	}
+
+	res, prefix = localNumbers(prefixes, "+44 20 7946 0000", numbers)
+	if prefix != "" || res != nil {
+		t.Errorf("no local: got %s %v", prefix, res)
+	}
+}
+
+func TestRotateNumbers(t *testing.T) {
+	t.Log("RotateNumbers")
+
+	usage := map[string]int{
+		"a": 10,
+		"b": 2,
+		"c": 5,
+	}
+
+	res := rotateNumbers([]string{"a", "b", "c", "d"}, usage)
+	if res[0] != "d" || res[1] != "b" || res[2] != "c" || res[3] != "a" {
+		t.Errorf("rotate: got %v", res)
+	}
+}
+
+func TestSelectCallerId(t *testing.T) {
+	t.Log("SelectCallerId")
+
+	settings := &model.CallerIdSettings{
+		Strategy: model.CallerIdStrategyLocal,
+		Prefixes: []string{"1415"},
+		DailyCap: 10,
+	}
+	numbers := []string{"+14155550100", "+12125550100"}
+
+	display, _ := selectCallerId(settings, "+14155559999", numbers, map[string]int{}, "")
+	if display != "+14155550100" {
+		t.Errorf("local: got %s", display)
+	}
+
+	display, reason := selectCallerId(settings, "+14155559999", numbers, map[string]int{"+14155550100": 10}, "")
+	if display != "+12125550100" || reason != "local daily cap" {
+		t.Errorf("local cap: got %s %s", display, reason)
+	}
+
+	display, _ = selectCallerId(settings, "+14155559999", numbers, map[string]int{"+14155550100": 1}, "+12125550100")
+	if display != "+12125550100" {
+		t.Errorf("sticky: got %s", display)
+	}
+
+	display, reason = selectCallerId(settings, "+14155559999", numbers, map[string]int{"+14155550100": 10, "+12125550100": 12}, "+12125550100")
+	if display != "" || reason != "daily cap" {
+		t.Errorf("cap: got %s %s", display, reason)
+	}
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/webitel/call_center/chat"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const chatAlertTextLimit = 256
+
+// monitorConversation analyzes the messages of the conversation and notifies the supervisors of the queue
+func (queue *InboundChatQueue) monitorConversation(attempt *Attempt, conv *chat.Conversation) {
+	monitor := queue.alertRules.NewMonitor()
+
+	conv.OnMessage(func(sess *chat.ChatSession, msg *model.ChatMessage) {
+		if msg.Text == "" {
+			return
+		}
+
+		sender := model.ChatTranscriptSenderClient
+		var agentUserId int64
+		if sess != nil && sess.Direction == chat.ChatDirectionOutbound {
+			sender = model.ChatTranscriptSenderAgent
+			agentUserId = sess.UserId
+		} else if aSess := conv.AgentSession(); aSess.Direction == chat.ChatDirectionOutbound {
+			agentUserId = aSess.UserId
+		}
+
+		score, fired := monitor.Analyze(sender, msg.Text)
+		attempt.AddVariables(monitor.Variables())
+
+		for _, rule := range fired {
+			attempt.Log(fmt.Sprintf("chat alert \"%s\" score %.2f", rule, score))
+			alert := &model.ChatAlert{
+				DomainId:       queue.domainId,
+				QueueId:        queue.Id(),
+				AttemptId:      attempt.Id(),
+				ConversationId: *attempt.MemberCallId(),
+				AgentUserId:    agentUserId,
+				Rule:           rule,
+				Sender:         sender,
+				Text:           alertText(queue.redactor.Redact(msg.Text)),
+				Score:          score,
+				CreatedAt:      msg.CreatedAt,
+			}
+
+			go func() {
+				if err := queue.queueManager.app.NotificationChatAlert(alert); err != nil {
+					attempt.log.Error(err.Error(),
+						wlog.Err(err),
+					)
+				}
+			}()
+		}
+	})
+}
+
+func alertText(text string) string {
+	r := []rune(text)
+	if len(r) > chatAlertTextLimit {
+		return string(r[:chatAlertTextLimit])
+	}
+
+	return text
+}
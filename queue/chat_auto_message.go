@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/webitel/call_center/chat"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+	"math"
+	"strconv"
+	"time"
+)
+
+// chatIdleNotice keeps sent idle messages of the dialog, reset after a new activity
+type chatIdleNotice struct {
+	clientWarned  bool
+	agentNotified bool
+}
+
+func (queue *InboundChatQueue) sendAutoMessage(attempt *Attempt, conv *chat.Conversation, tpl *model.ChatTemplate, vars map[string]string) {
+	if !tpl.Allow() {
+		return
+	}
+
+	vars = model.UnionStringMaps(queue.variables, attempt.ExportVariables(), vars)
+	text := tpl.Render(queue.settings.AutoMessages.LanguageOf(vars), vars)
+	if text == "" {
+		return
+	}
+
+	if err := conv.SendText(text); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+func (queue *InboundChatQueue) sendGreeting(attempt *Attempt, conv *chat.Conversation) {
+	if queue.settings.AutoMessages != nil {
+		queue.sendAutoMessage(attempt, conv, queue.settings.AutoMessages.Greeting, nil)
+	}
+}
+
+func (queue *InboundChatQueue) positionTicker() *time.Ticker {
+	s := queue.settings.AutoMessages
+	if s == nil || !s.Position.Allow() {
+		return nil
+	}
+
+	return time.NewTicker(time.Second * time.Duration(s.PositionIntervalSec()))
+}
+
+func (queue *InboundChatQueue) sendPosition(attempt *Attempt, conv *chat.Conversation) {
+	position, ewt, err := queue.queueManager.WaitingPosition(attempt)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	if position == 0 {
+		return
+	}
+
+	attempt.Log(fmt.Sprintf("chat position %d, ewt %d", position, ewt))
+	vars := map[string]string{
+		model.QueuePositionVariable: strconv.Itoa(position),
+		model.QueueEwtVariable:      strconv.Itoa(ewt),
+	}
+	attempt.AddVariables(vars)
+
+	queue.sendAutoMessage(attempt, conv, queue.settings.AutoMessages.Position, map[string]string{
+		"position": strconv.Itoa(position),
+		"ewt":      strconv.Itoa(ewt),
+		"ewt_min":  strconv.Itoa(int(math.Ceil(float64(ewt) / 60))),
+	})
+}
+
+// checkIdleNotice sends the warning to the idle client before the dialog is closed
+// and the notice to the client when the agent does not answer
+func (queue *InboundChatQueue) checkIdleNotice(attempt *Attempt, conv *chat.Conversation, agent string, mSess, aSess *chat.ChatSession,
+	notice *chatIdleNotice) {
+	s := queue.settings.AutoMessages
+	if s == nil || aSess == nil {
+		return
+	}
+
+	clientIdle := mSess.IdleSec()
+	agentIdle := aSess.IdleSec()
+	vars := map[string]string{
+		"agent_name":  agent,
+		"client_idle": strconv.FormatInt(clientIdle, 10),
+		"agent_idle":  strconv.FormatInt(agentIdle, 10),
+	}
+
+	if s.IdleClientWarning.Allow() && queue.settings.MaxIdleClient > 0 && clientIdle > agentIdle {
+		if !notice.clientWarned && clientIdle >= queue.settings.MaxIdleClient-s.WarningBeforeSec() {
+			attempt.Log("idle client warning")
+			vars["close_after"] = strconv.FormatInt(queue.settings.MaxIdleClient-clientIdle, 10)
+			queue.sendAutoMessage(attempt, conv, s.IdleClientWarning, vars)
+			notice.clientWarned = true
+		}
+	} else {
+		notice.clientWarned = false
+	}
+
+	if s.AgentIdle.Allow() && agentIdle > clientIdle {
+		if !notice.agentNotified && agentIdle >= s.AgentIdleAfterSec() {
+			attempt.Log("agent idle notice")
+			queue.sendAutoMessage(attempt, conv, s.AgentIdle, vars)
+			notice.agentNotified = true
+		}
+	} else {
+		notice.agentNotified = false
+	}
+}
@@ -0,0 +1,173 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"github.com/webitel/call_center/chat"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+	"sync"
+	"time"
+)
+
+// botStage talks to the client before the agent search,
+// returns true and the variables for the agent invite when the conversation must be escalated
+func (queue *InboundChatQueue) botStage(attempt *Attempt, conv *chat.Conversation) (bool, map[string]string) {
+	var mx sync.Mutex
+	var stopped bool
+	var res *chat.BotResponse
+	var err *model.AppError
+
+	settings := queue.settings.Bot
+	messages := make(chan *model.ChatMessage, 10)
+	collected := make(map[string]string)
+	transcript := make([]chat.BotMessage, 0, 10)
+	mSess := conv.MemberSession()
+
+	conv.OnMessage(func(sess *chat.ChatSession, msg *model.ChatMessage) {
+		mx.Lock()
+		defer mx.Unlock()
+
+		if stopped || sess != mSess || msg.Text == "" {
+			return
+		}
+		// skip own answers of the bot, they are sent from the account of the member session
+		if msg.FromId != 0 && msg.FromId == mSess.UserId {
+			return
+		}
+
+		select {
+		case messages <- msg:
+		default:
+			attempt.Log("bot skip message, buffer is full")
+		}
+	})
+
+	defer func() {
+		mx.Lock()
+		stopped = true
+		mx.Unlock()
+	}()
+
+	attempt.Log(fmt.Sprintf("bot %s start", queue.bot.Name()))
+
+	req := &chat.BotRequest{
+		ConversationId: *attempt.MemberCallId(),
+		DomainId:       queue.domainId,
+		QueueId:        queue.Id(),
+		AttemptId:      attempt.Id(),
+		Start:          true,
+	}
+
+	maxDuration := time.NewTimer(time.Second * time.Duration(settings.MaxDurationSec()))
+	defer maxDuration.Stop()
+
+	var turns uint16
+
+	for {
+		req.Variables = model.UnionStringMaps(attempt.ExportVariables(), collected)
+		req.Transcript = transcript
+
+		ctx, cancel := context.WithTimeout(attempt.Context, time.Second*time.Duration(settings.TimeoutSec()))
+		res, err = queue.bot.Handle(ctx, req)
+		cancel()
+
+		if err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			attempt.Log("bot error, escalate")
+			return true, queue.botEscalateVariables(transcript, "", collected)
+		}
+
+		if len(res.Variables) != 0 {
+			for k, v := range res.Variables {
+				collected[k] = v
+			}
+			attempt.AddVariables(res.Variables)
+		}
+
+		for _, text := range res.Messages {
+			if text == "" {
+				continue
+			}
+			if err = conv.SendText(text); err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			}
+			transcript = append(transcript, chat.BotMessage{
+				From:      chat.BotMessageFromBot,
+				Text:      text,
+				CreatedAt: model.GetMillis(),
+			})
+		}
+
+		if res.Close {
+			attempt.Log("bot resolved")
+			if err = mSess.Close(model.FlowEnd); err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			}
+			conv.SetStop()
+			queue.queueManager.SetAttemptSuccess(attempt, collected)
+			queue.queueManager.LeavingMember(attempt)
+			return false, nil
+		}
+
+		if res.Escalate {
+			attempt.Log("bot escalate")
+			return true, queue.botEscalateVariables(transcript, res.Summary, collected)
+		}
+
+		turns++
+		if turns > settings.MaxTurnsCount() {
+			attempt.Log("bot max turns, escalate")
+			return true, queue.botEscalateVariables(transcript, "", collected)
+		}
+
+		select {
+		case msg := <-messages:
+			transcript = append(transcript, chat.BotMessage{
+				From:      chat.BotMessageFromClient,
+				Text:      msg.Text,
+				CreatedAt: msg.CreatedAt,
+			})
+			req.Start = false
+			req.Text = msg.Text
+
+		case <-maxDuration.C:
+			attempt.Log("bot max duration, escalate")
+			return true, queue.botEscalateVariables(transcript, "", collected)
+
+		case <-attempt.Cancel():
+			conv.SetStop()
+			queue.queueManager.Abandoned(attempt)
+			return false, nil
+
+		case <-attempt.Context.Done():
+			conv.SetStop()
+			queue.queueManager.Abandoned(attempt)
+			return false, nil
+
+		case state := <-conv.State():
+			if state == chat.ChatStateClose {
+				attempt.Log("closed in bot, cause:" + conv.Cause())
+				conv.SetStop()
+				queue.queueManager.Abandoned(attempt)
+				return false, nil
+			}
+		}
+	}
+}
+
+func (queue *InboundChatQueue) botEscalateVariables(transcript []chat.BotMessage, summary string, collected map[string]string) map[string]string {
+	if summary == "" {
+		summary = chat.BotSummary(transcript)
+	}
+
+	return model.UnionStringMaps(collected, map[string]string{
+		model.QueueBotSummaryVariable: summary,
+	})
+}
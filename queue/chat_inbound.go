@@ -28,11 +28,20 @@ type InboundChatQueueSettings struct {
 	MaxWaitTime        uint32 `json:"max_wait_time"`
 	ManualDistribution bool   `json:"manual_distribution"`
 	LastMessageTimeout bool   `json:"last_message_timeout"`
+
+	Bot        *chat.BotSettings             `json:"bot"`
+	Transcript *model.ChatTranscriptSettings `json:"transcript"`
+
+	AutoMessages *model.ChatAutoMessagesSettings `json:"auto_messages"`
+	Alerts       *model.ChatAlertSettings        `json:"alerts"`
 }
 
 type InboundChatQueue struct {
 	BaseQueue
-	settings InboundChatQueueSettings
+	settings   InboundChatQueueSettings
+	bot        chat.Bot
+	redactor   *model.ChatRedactor
+	alertRules *chat.AlertRules
 }
 
 func InboundChatQueueFromBytes(data []byte) InboundChatQueueSettings {
@@ -41,15 +50,36 @@ func InboundChatQueueFromBytes(data []byte) InboundChatQueueSettings {
 	return settings
 }
 
-func NewInboundChatQueue(base BaseQueue, settings InboundChatQueueSettings) QueueObject {
+func NewInboundChatQueue(base BaseQueue, settings InboundChatQueueSettings) (QueueObject, *model.AppError) {
 	if settings.MaxWaitTime == 0 {
 		settings.MaxWaitTime = 300
 	}
 
-	return &InboundChatQueue{
+	queue := &InboundChatQueue{
 		BaseQueue: base,
 		settings:  settings,
 	}
+
+	var err *model.AppError
+	if settings.Bot.Allow() {
+		if queue.bot, err = chat.NewBot(settings.Bot); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Transcript.Allow() {
+		if queue.redactor, err = settings.Transcript.Redactor(); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.Alerts.Allow() {
+		if queue.alertRules, err = chat.NewAlertRules(settings.Alerts); err != nil {
+			return nil, err
+		}
+	}
+
+	return queue, nil
 }
 
 func (queue *InboundChatQueue) DistributeAttempt(attempt *Attempt) *model.AppError {
@@ -86,19 +116,6 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 
 	queue.Hook(HookJoined, attempt)
 
-	attempt.Log("wait agent")
-	if err = queue.queueManager.SetFindAgentState(attempt.Id()); err != nil {
-		//FIXME
-		panic(err.Error())
-	}
-	attempt.SetState(model.MemberStateWaitAgent)
-
-	var agent agent_manager.AgentObject
-	ags := attempt.On(AttemptHookDistributeAgent)
-
-	var timeSec = queue.settings.MaxWaitTime
-	timeout := time.NewTimer(time.Second * time.Duration(timeSec))
-
 	var conv *chat.Conversation
 	conv, err = queue.ChatManager().NewConversation(queue.domainId, *attempt.MemberCallId(), inviterId, invUserId,
 		model.UnionStringMaps(attempt.ExportVariables(), queue.variables))
@@ -112,6 +129,48 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 		return
 	}
 
+	if queue.settings.Transcript.Allow() {
+		conv.SetTranscript(attempt.Id(), queue.Id(), queue.redactor)
+	}
+
+	if queue.alertRules != nil {
+		queue.monitorConversation(attempt, conv)
+	}
+
+	queue.sendGreeting(attempt, conv)
+
+	var botVars map[string]string
+	if queue.bot != nil {
+		var escalate bool
+		if escalate, botVars = queue.botStage(attempt, conv); !escalate {
+			go func() {
+				attempt.Emit(AttemptHookLeaving)
+				attempt.Off("*")
+			}()
+			queue.queueManager.app.ChatManager().RemoveConversation(conv)
+			return
+		}
+	}
+
+	attempt.Log("wait agent")
+	if err = queue.queueManager.SetFindAgentState(attempt.Id()); err != nil {
+		//FIXME
+		panic(err.Error())
+	}
+	attempt.SetState(model.MemberStateWaitAgent)
+
+	var agent agent_manager.AgentObject
+	ags := attempt.On(AttemptHookDistributeAgent)
+
+	timeout := time.NewTimer(queue.RoutingTimeout(attempt, time.Second*time.Duration(queue.settings.MaxWaitTime)))
+
+	var position <-chan time.Time
+	if t := queue.positionTicker(); t != nil {
+		defer t.Stop()
+		position = t.C
+	}
+	var idleNotice chatIdleNotice
+
 	loop := conv.Active()
 
 	mSess := conv.MemberSession()
@@ -128,6 +187,7 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 			conv.SetStop()
 
 		case <-ags:
+			idleNotice = chatIdleNotice{}
 			agent = attempt.Agent()
 			team, err = queue.GetTeam(attempt)
 			if err != nil {
@@ -140,6 +200,7 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 
 			vars := model.UnionStringMaps(
 				queue.variables,
+				botVars,
 				map[string]string{
 					model.QUEUE_AGENT_ID_FIELD:   fmt.Sprintf("%d", agent.Id()),
 					model.QUEUE_TEAM_ID_FIELD:    fmt.Sprintf("%d", team.Id()),
@@ -192,6 +253,7 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 					break
 				case <-timeout.C:
 					if conv.BridgedAt() > 0 {
+						queue.checkIdleNotice(attempt, conv, agent.Name(), mSess, aSess, &idleNotice)
 						//wlog.Debug(fmt.Sprintf("attempt [%d] agent_idle=%d member_idle=%d dialog=%d", attempt.Id(), aSess.IdleSec(), mSess.IdleSec(), conv.SilentSec()))
 
 						if queue.settings.LastMessageTimeout {
@@ -260,6 +322,15 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 					case chat.ChatStateClose:
 						attempt.Log("closed cause:" + conv.Cause())
 						conv.SetStop()
+					case chat.ChatStateHandover:
+						aSess = conv.AgentSession()
+						attempt.Log(fmt.Sprintf("conversation handover to user %d", aSess.UserId))
+						if a, t := queue.handoverAgent(attempt, agent, team, aSess.UserId); a != nil {
+							agent, team = a, t
+						}
+						attempt.AddVariables(map[string]string{
+							model.QueueChatHandoverVariable: fmt.Sprintf("%d", aSess.UserId),
+						})
 
 					default:
 						fmt.Println("QUEUE ERROR state ", state)
@@ -267,6 +338,9 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 				}
 			}
 
+		case <-position:
+			queue.sendPosition(attempt, conv)
+
 		case <-timeout.C:
 			if conv.BridgedAt() > 0 {
 				timeout.Reset(time.Second * time.Duration(timerCheckIdle))
@@ -308,3 +382,37 @@ func (queue *InboundChatQueue) process(attempt *Attempt, inviterId, invUserId st
 
 	queue.queueManager.app.ChatManager().RemoveConversation(conv)
 }
+
+// handoverAgent the agent of the user becomes the agent of the attempt, the previous agent goes to the wrap time of the team
+func (queue *InboundChatQueue) handoverAgent(attempt *Attempt, agent agent_manager.AgentObject, team *agentTeam,
+	userId int64) (agent_manager.AgentObject, *agentTeam) {
+	res, err := queue.queueManager.store.Member().HandoverAgent(attempt.Id(), userId, uint32(team.WrapUpTime()))
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return nil, nil
+	}
+
+	newAgent, err := queue.AgentManager().GetAgent(res.AgentId, res.AgentUpdatedAt)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return nil, nil
+	}
+
+	newTeam, err := queue.TeamManager().GetTeam(res.TeamId, res.TeamUpdatedAt)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return nil, nil
+	}
+
+	team.Transfer(attempt, agent)
+	attempt.SetAgent(newAgent)
+	newTeam.Bridged(attempt, newAgent)
+
+	return newAgent, newTeam
+}
@@ -23,6 +23,7 @@ type DialingImpl struct {
 	resourceManager   *ResourceManager
 	statisticsManager *StatisticsManager
 	expiredManager    *ExpiredManager
+	leadScoring       *LeadScoringManager
 	agentManager      agent_manager.AgentManager
 	callManager       call_manager.CallManager
 	startOnce         sync.Once
@@ -37,6 +38,7 @@ func NewDialing(app App, m mq.MQ, callManager call_manager.CallManager, agentMan
 	dialing.resourceManager = NewResourceManager(app)
 	dialing.statisticsManager = NewStatisticsManager(s)
 	dialing.expiredManager = NewExpiredManager(app, s)
+	dialing.leadScoring = NewLeadScoringManager(s)
 	dialing.queueManager = NewQueueManager(app, s, m, callManager, dialing.resourceManager, agentManager, bridgeSleep)
 	dialing.log = dialing.queueManager.log.With(
 		wlog.Namespace("context"),
@@ -58,6 +60,7 @@ func (d *DialingImpl) Start() {
 		go d.queueManager.Start()
 		go d.statisticsManager.Start()
 		go d.expiredManager.Start()
+		go d.leadScoring.Start()
 	})
 }
 
@@ -66,6 +69,7 @@ func (d *DialingImpl) Stop() {
 	d.watcher.Stop()
 	d.statisticsManager.Stop()
 	d.expiredManager.Stop()
+	d.leadScoring.Stop()
 }
 
 func (d *DialingImpl) routeData() {
@@ -119,6 +123,16 @@ func (d *DialingImpl) routeIdleAgents() {
 		return
 	}
 
+	if expired, err := d.store.Member().ExpiredWrapUp(d.app.GetInstanceId()); err == nil {
+		for _, v := range expired {
+			d.queueManager.forceWrapUpEnd(v)
+		}
+	} else {
+		d.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+
 	//// FIXME engine
 	if attempts, err := d.store.Member().GetTimeouts(d.app.GetInstanceId()); err == nil {
 		for _, v := range attempts {
@@ -127,11 +141,13 @@ func (d *DialingImpl) routeIdleAgents() {
 
 			if a, ok := d.queueManager.GetAttempt(v.AttemptId); ok {
 				a.SetResult(AttemptResultTimeout)
+				d.queueManager.storeProcessingFields(a)
 
 				if v.AfterSchemaId == nil {
 					d.queueManager.LeavingMember(a)
-				} else {
-					d.queueManager.TimeoutLeavingMember(a)
+				} else if err = d.queueManager.TimeoutLeavingMember(a); err != nil {
+					a.Log(err.Error())
+					d.queueManager.LeavingMember(a)
 				}
 			} else {
 				// TODO
@@ -159,6 +175,8 @@ func (d *DialingImpl) routeIdleAgents() {
 		time.Sleep(time.Second)
 	}
 
+	d.queueManager.manualReleaseOffers()
+
 	result, err := d.store.Agent().ReservedForAttemptByNode(d.app.GetInstanceId())
 	if err != nil {
 		d.log.Error(err.Error(),
@@ -184,7 +202,15 @@ func (d *DialingImpl) routeIdleAgents() {
 func (d *DialingImpl) routeAgentToAttempt(attemptId int64, agent agent_manager.AgentObject) {
 	if attempt, ok := d.queueManager.membersCache.Get(attemptId); ok {
 		att := attempt.(*Attempt)
-		if _, err := d.queueManager.GetQueue(att.QueueId(), att.QueueUpdatedAt()); err == nil {
+		if q, err := d.queueManager.GetQueue(att.QueueId(), att.QueueUpdatedAt()); err == nil {
+			if !q.AllowAgent(att, agent) {
+				d.queueManager.rejectAgent(att, agent, true)
+				return
+			}
+			if !q.AllowAffinityAgent(att, agent) {
+				d.queueManager.rejectAgent(att, agent, false)
+				return
+			}
 			att.DistributeAgent(agent)
 		} else {
 			att.log.Error(fmt.Sprintf("Not found queue AttemptId=%d for agent %s", attemptId, agent.Name()),
@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+type retryPolicy struct {
+	maxAttempts uint
+	waitBetween uint64
+	perNumbers  bool
+	stopCause   string // the member is stopped with the code of the disposition
+	dnc         bool   // the destination is added to the dnc list of the queue
+}
+
+// dispositionPolicy the retry policy of the disposition, retries - the previous retries of the disposition,
+// seq - the number of the attempt of the member
+func dispositionPolicy(d *model.QueueDisposition, result *model.AttemptCallback, retries int, seq int, p retryPolicy) retryPolicy {
+	if result.Variables == nil {
+		result.Variables = make(map[string]string)
+	}
+	result.Variables[model.QueueDispositionVariable] = d.Code
+	result.Variables[model.QueueDispositionCategoryVariable] = d.Category
+
+	if d.Final() || (d.MaxRetries > 0 && retries >= int(d.MaxRetries)) {
+		dnc := d.Category == model.DispositionCategoryDnc
+		if dnc {
+			result.ExcludeCurrentCommunication = model.NewBool(true)
+		}
+		result.NextCallAt = nil
+		result.WaitBetweenRetries = nil
+
+		return retryPolicy{maxAttempts: 1, stopCause: d.Code, dnc: dnc}
+	}
+
+	result.Variables[d.RetriesVariable()] = strconv.Itoa(retries + 1)
+
+	if d.MaxRetries > 0 {
+		// the retries of the disposition are not limited by the max attempts of the queue
+		if m := uint(seq + 1); p.maxAttempts < m {
+			p.maxAttempts = m
+		}
+		p.perNumbers = false
+	}
+
+	if d.WaitBetweenRetries > 0 {
+		wait := int32(d.WaitBetweenRetries)
+		p.waitBetween = uint64(d.WaitBetweenRetries)
+		result.WaitBetweenRetries = &wait
+	}
+
+	switch d.NextCommunication {
+	case model.DispositionNextCurrent:
+		result.OnlyCurrentCommunication = model.NewBool(true)
+	case model.DispositionNextOther:
+		result.ExcludeCurrentCommunication = model.NewBool(true)
+	}
+
+	return p
+}
+
+// validateDisposition the result of the agent must be in the strict catalog of the queue
+func (qm *Manager) validateDisposition(attempt *Attempt, status string) *model.AppError {
+	if attempt == nil || attempt.queue == nil || attempt.queue.Dispositions() == nil {
+		return nil
+	}
+
+	_, err := attempt.queue.Dispositions().Get(status)
+	return err
+}
+
+// applyDisposition the retry policy of the disposition overrides the retry settings of the queue
+func (qm *Manager) applyDisposition(attempt *Attempt, result *model.AttemptCallback, p retryPolicy) retryPolicy {
+	if attempt == nil || attempt.queue == nil || attempt.queue.Dispositions() == nil {
+		return p
+	}
+
+	d, err := attempt.queue.Dispositions().Get(result.Status)
+	if err != nil {
+		attempt.Log(err.Error())
+		return p
+	} else if d == nil {
+		return p
+	}
+
+	retries := 0
+	if v, ok := attempt.GetVariable(d.RetriesVariable()); ok {
+		retries, _ = strconv.Atoi(v)
+	}
+
+	seq := 1
+	if attempt.member.Seq != nil {
+		seq = *attempt.member.Seq
+	}
+
+	p = dispositionPolicy(d, result, retries, seq, p)
+
+	attempt.Log(fmt.Sprintf("disposition %s [%s] retries %d", d.Code, d.Category, retries))
+	attempt.Journal(model.AttemptJournalDisposition, map[string]interface{}{
+		"code":         d.Code,
+		"category":     d.Category,
+		"retries":      retries,
+		"max_attempts": p.maxAttempts,
+		"wait_between": p.waitBetween,
+	})
+
+	if p.dnc {
+		go qm.addDnc(attempt, d)
+	}
+
+	go attempt.queue.DispositionHook(d, attempt)
+
+	return p
+}
+
+// stopDisposition the member of the final disposition is stopped with the code of the disposition
+func (qm *Manager) stopDisposition(attempt *Attempt, p retryPolicy) bool {
+	if attempt == nil || p.stopCause == "" || attempt.MemberId() == nil {
+		return false
+	}
+
+	if err := qm.store.Member().StopWithCause(*attempt.MemberId(), p.stopCause); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return false
+	}
+
+	attempt.SetMemberStopCause(model.NewString(p.stopCause))
+	return true
+}
+
+func (qm *Manager) addDnc(attempt *Attempt, d *model.QueueDisposition) {
+	destination := attempt.Destination()
+	if destination == "" {
+		return
+	}
+
+	err := qm.store.Member().AddDnc(attempt.QueueId(), destination, fmt.Sprintf("disposition %s, attempt %d", d.Code, attempt.Id()))
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
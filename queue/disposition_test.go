@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestDispositionPolicy(t *testing.T) {
+	t.Log("DispositionPolicy")
+
+	queue := retryPolicy{maxAttempts: 3, waitBetween: 60, perNumbers: true}
+	busy := &model.QueueDisposition{
+		Code:               "busy",
+		Category:           model.DispositionCategoryRetry,
+		MaxRetries:         5,
+		WaitBetweenRetries: 600,
+		NextCommunication:  model.DispositionNextOther,
+	}
+
+	result := &model.AttemptCallback{Status: "busy"}
+	p := dispositionPolicy(busy, result, 2, 4, queue)
+	if p.maxAttempts != 5 || p.waitBetween != 600 || p.perNumbers {
+		t.Errorf("retry: got %+v", p)
+	}
+	if result.Variables[busy.RetriesVariable()] != "3" || result.ExcludeCurrentCommunication == nil || *result.WaitBetweenRetries != 600 {
+		t.Errorf("retry result: got %+v", result)
+	}
+
+	result = &model.AttemptCallback{Status: "busy"}
+	p = dispositionPolicy(busy, result, 5, 7, queue)
+	if p.maxAttempts != 1 || p.stopCause != "busy" || p.dnc || result.WaitBetweenRetries != nil {
+		t.Errorf("max retries: got %+v", p)
+	}
+
+	dnc := &model.QueueDisposition{Code: "dnc", Category: model.DispositionCategoryDnc}
+	result = &model.AttemptCallback{Status: "dnc"}
+	p = dispositionPolicy(dnc, result, 0, 1, queue)
+	if p.maxAttempts != 1 || p.stopCause != "dnc" || !p.dnc || result.ExcludeCurrentCommunication == nil || result.Variables[model.QueueDispositionCategoryVariable] != "dnc" {
+		t.Errorf("dnc: got %+v %+v", p, result)
+	}
+}
+
+func TestDispositionsStrict(t *testing.T) {
+	t.Log("DispositionsStrict")
+
+	d := model.QueueDispositionsFromBytes([]byte(`{"dispositions": {"strict": true, "items": [
+		{"code": "sale", "category": "success"}, {"code": "bad", "category": "unknown"}]}}`))
+
+	if v, err := d.Get("sale"); err != nil || v == nil {
+		t.Errorf("sale: got %v %v", v, err)
+	}
+	if _, err := d.Get("bad"); err == nil {
+		t.Errorf("bad category must be skipped")
+	}
+	if _, err := d.Get("other"); err == nil {
+		t.Errorf("strict catalog must reject unknown result")
+	}
+	if model.QueueDispositionsFromBytes([]byte(`{}`)) != nil {
+		t.Errorf("empty catalog")
+	}
+}
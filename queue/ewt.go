@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/call_center/call_manager"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	inboundStatsExpireSec = 30
+
+	announcementDefaultInterval = 60
+)
+
+// EstimateWaitTime expected wait in seconds of the caller at the position: the queue ahead is handled
+// by the available agents, without agents the last hour average wait is used
+func EstimateWaitTime(position int, stats *model.InboundQueueStats) int {
+	if stats == nil || position < 1 {
+		return 0
+	}
+
+	if stats.Agents > 0 && stats.AvgHandleSec > 0 {
+		return int(math.Ceil(float64(position) * stats.AvgHandleSec / float64(stats.Agents)))
+	}
+
+	return int(math.Ceil(stats.AvgWaitSec))
+}
+
+func (qm *Manager) inboundQueueStats(queueId int) (*model.InboundQueueStats, *model.AppError) {
+	if stats, ok := qm.statsCache.Get(queueId); ok {
+		return stats.(*model.InboundQueueStats), nil
+	}
+
+	stats, err := qm.store.Statistic().InboundQueueStats(queueId)
+	if err != nil {
+		return nil, err
+	}
+	qm.statsCache.AddWithExpiresInSecs(queueId, stats, inboundStatsExpireSec)
+
+	return stats, nil
+}
+
+// WaitingPosition returns position and expected wait time (sec) of the waiting attempt
+func (qm *Manager) WaitingPosition(attempt *Attempt) (int, int, *model.AppError) {
+	position, err := qm.store.Member().WaitingPosition(attempt.Id())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stats, err := qm.inboundQueueStats(attempt.QueueId())
+	if err != nil {
+		return position, 0, err
+	}
+
+	return position, EstimateWaitTime(position, stats), nil
+}
+
+// EstimateJoin position and expected wait time (sec) of the caller that joins the queue with the priority,
+// used by the flow before the join
+func (qm *Manager) EstimateJoin(queueId int, priority int) (*model.QueueEstimate, *model.AppError) {
+	ahead, err := qm.store.Member().WaitingAhead(queueId, priority)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := qm.inboundQueueStats(queueId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.QueueEstimate{
+		QueueId:  queueId,
+		Waiting:  ahead,
+		Position: ahead + 1,
+		Ewt:      EstimateWaitTime(ahead+1, stats),
+		Agents:   stats.Agents,
+	}, nil
+}
+
+func (queue *InboundQueue) setWaitingVariables(attempt *Attempt, mCall call_manager.Call) (int, int) {
+	position, ewt, err := queue.queueManager.WaitingPosition(attempt)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return 0, 0
+	}
+
+	vars := map[string]string{
+		model.QueuePositionVariable: strconv.Itoa(position),
+		model.QueueEwtVariable:      strconv.Itoa(ewt),
+	}
+	attempt.AddVariables(vars)
+	if err = mCall.SerVariables(vars); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+
+	return position, ewt
+}
+
+func (queue *InboundQueue) announcementTicker() *time.Ticker {
+	a := queue.props.Announcement
+	if !a.Enabled || (a.File == nil && a.Tts == nil) {
+		return nil
+	}
+
+	interval := a.Interval
+	if interval == 0 {
+		interval = announcementDefaultInterval
+	}
+
+	return time.NewTicker(time.Second * time.Duration(interval))
+}
+
+func (queue *InboundQueue) announce(attempt *Attempt, mCall call_manager.Call) {
+	position, ewt := queue.setWaitingVariables(attempt, mCall)
+	a := queue.props.Announcement
+	var err *model.AppError
+
+	attempt.Log(fmt.Sprintf("announcement position %d, ewt %d", position, ewt))
+
+	if a.Tts != nil && position > 0 {
+		err = mCall.BroadcastPlaybackTts(queue.domainId, a.Tts, announcementText(a.Tts.Text, position, ewt), "aleg")
+	} else {
+		err = mCall.BroadcastPlaybackFile(queue.domainId, a.File, "aleg")
+	}
+
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+func announcementText(text string, position, ewt int) string {
+	return strings.NewReplacer(
+		"${position}", strconv.Itoa(position),
+		"${ewt}", strconv.Itoa(ewt),
+		"${ewt_min}", strconv.Itoa(int(math.Ceil(float64(ewt)/60))),
+	).Replace(text)
+}
@@ -0,0 +1,28 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"testing"
+)
+
+func TestEstimateWaitTime(t *testing.T) {
+	t.Log("EstimateWaitTime")
+
+	cases := []struct {
+		position int
+		stats    *model.InboundQueueStats
+		ewt      int
+	}{
+		{1, nil, 0},
+		{0, &model.InboundQueueStats{Agents: 1, AvgHandleSec: 60}, 0},
+		{3, &model.InboundQueueStats{Agents: 2, AvgHandleSec: 60}, 90},
+		{1, &model.InboundQueueStats{Agents: 4, AvgHandleSec: 30.5}, 8},
+		{5, &model.InboundQueueStats{Agents: 0, AvgHandleSec: 60, AvgWaitSec: 42.2}, 43},
+	}
+
+	for _, c := range cases {
+		if ewt := EstimateWaitTime(c.position, c.stats); ewt != c.ewt {
+			t.Errorf("position %d: expected %d, got %d", c.position, c.ewt, ewt)
+		}
+	}
+}
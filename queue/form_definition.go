@@ -0,0 +1,251 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webitel/call_center/expression"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+var formPhoneRegex = regexp.MustCompile(`^\+?[0-9][0-9()\-\s.]{2,31}$`)
+
+// the fields of the attempt set by the system, not by the form
+var formSystemFieldPrefixes = []string{"cc_", "wbt_"}
+
+type formField struct {
+	*model.FormFieldDefinition
+	regex   *regexp.Regexp
+	visible *expression.Program
+	options map[string]struct{}
+}
+
+type queueForm struct {
+	strict            bool
+	noValidateActions []string
+	fields            []*formField
+	ids               map[string]*formField
+}
+
+// newQueueForm the invalid field of the definition is skipped, so that the bad definition does not stop the queue
+func newQueueForm(def *model.QueueFormDefinition, log *wlog.Logger) *queueForm {
+	if def == nil {
+		return nil
+	}
+
+	form := &queueForm{
+		strict:            def.Strict,
+		noValidateActions: def.NoValidateActions,
+		ids:               make(map[string]*formField),
+	}
+
+	for _, v := range def.Fields {
+		f, err := newFormField(v)
+		if err != nil {
+			log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			continue
+		}
+
+		form.fields = append(form.fields, f)
+		form.ids[v.Id] = f
+	}
+
+	return form
+}
+
+func newFormField(v *model.FormFieldDefinition) (*formField, *model.AppError) {
+	if err := v.IsValid(); err != nil {
+		return nil, err
+	}
+
+	f := &formField{
+		FormFieldDefinition: v,
+		options:             make(map[string]struct{}),
+	}
+
+	if v.Regex != "" {
+		r, err := regexp.Compile(v.Regex)
+		if err != nil {
+			return nil, model.NewAppError("Queue.FormDefinition", "queue.form_definition.regex.invalid", nil,
+				fmt.Sprintf("field \"%s\": %s", v.Id, err.Error()), http.StatusBadRequest)
+		}
+		f.regex = r
+	}
+
+	if v.Visible != "" {
+		p, err := expression.Compile(v.Visible)
+		if err != nil {
+			return nil, model.NewAppError("Queue.FormDefinition", "queue.form_definition.visible.invalid", nil,
+				fmt.Sprintf("field \"%s\": %s", v.Id, err.Error()), http.StatusBadRequest)
+		}
+		f.visible = p
+	}
+
+	for _, o := range v.Options {
+		f.options[o] = struct{}{}
+	}
+
+	return f, nil
+}
+
+// validate the fields of the form, partial - the required fields are not checked,
+// schema - the fields of the processing schema, not checked by the strict form as the system fields
+func (form *queueForm) validate(fields, vars, schema map[string]string, partial bool) []*model.FieldError {
+	var errs []*model.FieldError
+	env := expression.EnvFromStrings(vars, fields)
+
+	for _, f := range form.fields {
+		if f.visible != nil {
+			if ok, err := f.visible.Bool(env); err == nil && !ok {
+				continue
+			}
+		}
+
+		v := strings.TrimSpace(fields[f.Id])
+		if v == "" {
+			if f.Required && !partial {
+				errs = append(errs, &model.FieldError{Field: f.Id, Code: model.FormFieldErrorRequired, Message: "is required"})
+			}
+			continue
+		}
+
+		if e := f.check(v); e != nil {
+			errs = append(errs, e)
+		}
+	}
+
+	if form.strict {
+		var unknown []string
+		for k := range fields {
+			if _, ok := form.ids[k]; ok || isSystemFormField(k, schema) {
+				continue
+			}
+			unknown = append(unknown, k)
+		}
+		sort.Strings(unknown)
+		for _, k := range unknown {
+			errs = append(errs, &model.FieldError{Field: k, Code: model.FormFieldErrorUnknown, Message: "is not defined"})
+		}
+	}
+
+	return errs
+}
+
+func isSystemFormField(k string, schema map[string]string) bool {
+	if _, ok := schema[k]; ok {
+		return true
+	}
+
+	for _, p := range formSystemFieldPrefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *formField) check(v string) *model.FieldError {
+	if f.MaxLength > 0 && len([]rune(v)) > f.MaxLength {
+		return f.error(model.FormFieldErrorLength, fmt.Sprintf("max length is %d", f.MaxLength))
+	}
+
+	var err error
+	switch f.Type {
+	case model.FormFieldNumber:
+		_, err = strconv.ParseFloat(v, 64)
+	case model.FormFieldInteger:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case model.FormFieldBool:
+		_, err = strconv.ParseBool(v)
+	case model.FormFieldEmail:
+		var addr *mail.Address
+		if addr, err = mail.ParseAddress(v); err == nil && addr.Address != v {
+			err = fmt.Errorf("bad email")
+		}
+	case model.FormFieldPhone:
+		if !formPhoneRegex.MatchString(v) {
+			err = fmt.Errorf("bad phone")
+		}
+	case model.FormFieldDate:
+		_, err = time.Parse("2006-01-02", v)
+	case model.FormFieldDateTime:
+		_, err = time.Parse(time.RFC3339, v)
+	case model.FormFieldSelect:
+		if _, ok := f.options[v]; !ok {
+			return f.error(model.FormFieldErrorOption, fmt.Sprintf("\"%s\" is not in the options", v))
+		}
+	case model.FormFieldMultiSelect:
+		for _, o := range strings.Split(v, ",") {
+			if _, ok := f.options[strings.TrimSpace(o)]; !ok {
+				return f.error(model.FormFieldErrorOption, fmt.Sprintf("\"%s\" is not in the options", strings.TrimSpace(o)))
+			}
+		}
+	}
+
+	if err != nil {
+		return f.error(model.FormFieldErrorType, fmt.Sprintf("must be %s", f.Type))
+	}
+
+	if f.regex != nil && !f.regex.MatchString(v) {
+		return f.error(model.FormFieldErrorRegex, fmt.Sprintf("must match %s", f.Regex))
+	}
+
+	return nil
+}
+
+func (f *formField) error(code, msg string) *model.FieldError {
+	return &model.FieldError{
+		Field:   f.Id,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+// ValidateForm the fields of the processing form by the definition of the queue, the fields of the attempt are merged,
+// partial - the save of the draft, the required fields are not checked
+func (queue *BaseQueue) ValidateForm(attempt *Attempt, action string, fields map[string]string, partial bool) *model.AppError {
+	if queue.form == nil {
+		return nil
+	}
+
+	for _, v := range queue.form.noValidateActions {
+		if v == action && !partial {
+			return nil
+		}
+	}
+
+	merged := make(map[string]string)
+	for k, v := range attempt.ProcessingFields() {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	var schema map[string]string
+	if attempt.processingForm != nil {
+		schema = attempt.processingForm.Fields()
+	}
+
+	errs := queue.form.validate(merged, attempt.ExportVariables(), schema, partial)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	err := model.NewFormValidationError("Queue.ValidateForm", errs)
+	attempt.log.Debug(err.Error(),
+		wlog.String("action", action),
+	)
+
+	return err
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func TestQueueFormValidate(t *testing.T) {
+	t.Log("QueueFormValidate")
+
+	form := newQueueForm(model.QueueFormDefinitionFromBytes([]byte(`{"form_definition": {"strict": true, "fields": [
+		{"id": "email", "type": "email", "required": true},
+		{"id": "amount", "type": "number"},
+		{"id": "result", "type": "select", "options": ["sale", "no_sale"], "required": true},
+		{"id": "reason", "required": true, "visible": "result == \"no_sale\"", "max_length": 10},
+		{"id": "code", "regex": "^[A-Z]{3}$"}
+	]}}`)), wlog.NewLogger(&wlog.LoggerConfiguration{}))
+
+	errs := form.validate(map[string]string{
+		"email":          "bad",
+		"amount":         "ten",
+		"result":         "sale",
+		"code":           "ab",
+		"extra":          "1",
+		"cc_disposition": "sale",
+		"step":           "2",
+	}, nil, map[string]string{"step": "1"}, false)
+
+	exp := []model.FieldError{
+		{Field: "email", Code: model.FormFieldErrorType},
+		{Field: "amount", Code: model.FormFieldErrorType},
+		{Field: "code", Code: model.FormFieldErrorRegex},
+		{Field: "extra", Code: model.FormFieldErrorUnknown},
+	}
+	if len(errs) != len(exp) {
+		t.Fatalf("errors: got %d, expected %d", len(errs), len(exp))
+	}
+	for i, e := range exp {
+		if errs[i].Field != e.Field || errs[i].Code != e.Code {
+			t.Errorf("error %d: got %s %s, expected %s %s", i, errs[i].Field, errs[i].Code, e.Field, e.Code)
+		}
+	}
+
+	fields := map[string]string{"email": "a@b.com", "result": "no_sale"}
+	if errs = form.validate(fields, nil, nil, false); len(errs) != 1 || errs[0].Field != "reason" || errs[0].Code != model.FormFieldErrorRequired {
+		t.Errorf("visible required: got %v", errs)
+	}
+	if errs = form.validate(fields, nil, nil, true); len(errs) != 0 {
+		t.Errorf("partial: got %v", errs)
+	}
+
+	form = newQueueForm(&model.QueueFormDefinition{Fields: []*model.FormFieldDefinition{
+		{Id: "x", Type: model.FormFieldSelect},
+		{Id: "y", Regex: "^[A-Z"},
+		{Id: "z", Visible: "result =="},
+		{Id: "ok"},
+	}}, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	if len(form.fields) != 1 || form.fields[0].Id != "ok" {
+		t.Errorf("invalid fields: expected to be skipped, got %d", len(form.fields))
+	}
+}
@@ -63,9 +63,22 @@ func (q *BaseQueue) Hook(name string, at *Attempt) {
 		return
 	}
 
+	q.startHook(name, h.SchemaId, at)
+}
+
+// DispositionHook the schema of the disposition of the attempt result
+func (q *BaseQueue) DispositionHook(d *model.QueueDisposition, at *Attempt) {
+	if d.SchemaId == nil {
+		return
+	}
+
+	q.startHook("disposition "+d.Code, *d.SchemaId, at)
+}
+
+func (q *BaseQueue) startHook(name string, schemaId uint32, at *Attempt) {
 	// add params last attempt
 	req := &workflow.StartFlowRequest{
-		SchemaId: h.SchemaId,
+		SchemaId: schemaId,
 		DomainId: q.DomainId(),
 		Variables: model.UnionStringMaps(
 			at.ExportSchemaVariables(),
@@ -81,9 +94,16 @@ func (q *BaseQueue) Hook(name string, at *Attempt) {
 
 	//call_manager.DUMP(req.Variables)
 
+	data := map[string]interface{}{
+		"name":      name,
+		"schema_id": schemaId,
+	}
 	if err != nil {
 		at.Log(fmt.Sprintf("hook \"%s\", error: %s", name, err.Error()))
+		data["error"] = err.Error()
 	} else {
 		at.Log(fmt.Sprintf("hook \"%s\" external job_id: %s", name, id))
+		data["job_id"] = id
 	}
+	at.Journal(model.AttemptJournalHook, data)
 }
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const attemptJournalMaxEvents = 500
+
+// Journal adds the event to the journal of the attempt, it is stored when the attempt leaves the queue,
+// the events added after the leaving are stored on the reporting and the end of the wrap up
+func (a *Attempt) Journal(event string, data map[string]interface{}) {
+	a.journalMx.Lock()
+	if len(a.journal) < attemptJournalMaxEvents {
+		a.journal = append(a.journal, model.AttemptJournalEvent{
+			At:    model.GetMillis(),
+			Event: event,
+			Data:  data,
+		})
+	}
+	a.journalMx.Unlock()
+}
+
+func (a *Attempt) JournalEvents() []model.AttemptJournalEvent {
+	a.journalMx.Lock()
+	res := make([]model.AttemptJournalEvent, len(a.journal))
+	copy(res, a.journal)
+	a.journalMx.Unlock()
+
+	return res
+}
+
+// pendingJournal the events that are not stored yet, they are marked as stored
+func (a *Attempt) pendingJournal() []model.AttemptJournalEvent {
+	a.journalMx.Lock()
+	res := make([]model.AttemptJournalEvent, len(a.journal)-a.journalStored)
+	copy(res, a.journal[a.journalStored:])
+	a.journalStored = len(a.journal)
+	a.journalMx.Unlock()
+
+	return res
+}
+
+func (a *Attempt) journalState(state string) {
+	data := map[string]interface{}{
+		"state": state,
+	}
+
+	if agent := a.Agent(); agent != nil {
+		data["agent_id"] = agent.Id()
+		data["agent_name"] = agent.Name()
+	}
+
+	a.Journal(model.AttemptJournalState, data)
+}
+
+// storeJournal appends the events that are not stored yet to the journal of the attempt,
+// the stores of one attempt are serialized so the events keep the order
+func (qm *Manager) storeJournal(attempt *Attempt) {
+	attempt.journalStoreMx.Lock()
+	defer attempt.journalStoreMx.Unlock()
+
+	events := attempt.pendingJournal()
+	if len(events) == 0 {
+		return
+	}
+
+	j := &model.AttemptJournal{
+		AttemptId: attempt.Id(),
+		DomainId:  attempt.domainId,
+		QueueId:   attempt.QueueId(),
+		MemberId:  attempt.MemberId(),
+		Channel:   attempt.channel,
+		Result:    attempt.Result(),
+		JoinedAt:  attempt.JoinedAt(),
+		LeavingAt: model.GetMillis(),
+		Events:    events,
+	}
+
+	if err := qm.store.Member().SaveJournal(j); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
@@ -0,0 +1,32 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"testing"
+)
+
+func TestPendingJournal(t *testing.T) {
+	t.Log("PendingJournal")
+
+	a := &Attempt{}
+	a.Journal(model.AttemptJournalState, nil)
+	a.Journal(model.AttemptJournalRouting, nil)
+
+	if events := a.pendingJournal(); len(events) != 2 {
+		t.Fatalf("leaving: expected 2 events, got %d", len(events))
+	}
+
+	a.Journal(model.AttemptJournalDisposition, nil)
+	events := a.pendingJournal()
+	if len(events) != 1 || events[0].Event != model.AttemptJournalDisposition {
+		t.Fatalf("reporting: expected the disposition, got %+v", events)
+	}
+
+	if events = a.pendingJournal(); len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+
+	if all := a.JournalEvents(); len(all) != 3 {
+		t.Errorf("expected 3 events in the journal, got %d", len(all))
+	}
+}
@@ -0,0 +1,294 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/webitel/call_center/expression"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/store"
+	"github.com/webitel/call_center/utils"
+	"github.com/webitel/wlog"
+)
+
+const (
+	LeadScoringPollingInterval = 1 * 1000 * 10
+)
+
+type LeadScoringManager struct {
+	store     store.Store
+	watcher   *utils.Watcher
+	startOnce sync.Once
+	log       *wlog.Logger
+}
+
+type leadScorer struct {
+	strategy *model.QueueLeadStrategy
+	program  *expression.Program
+}
+
+type scoredLead struct {
+	member *model.LeadMember
+	score  float64
+	tier   int
+	reason []string
+}
+
+func NewLeadScoringManager(store store.Store) *LeadScoringManager {
+	var manager LeadScoringManager
+	manager.store = store
+	manager.log = wlog.GlobalLogger().With(
+		wlog.Namespace("context"),
+		wlog.String("name", "lead_scoring"),
+	)
+	return &manager
+}
+
+func (s *LeadScoringManager) Start() {
+	s.log.Debug("starting lead scoring service")
+	s.watcher = utils.MakeWatcher("LeadScoring", LeadScoringPollingInterval, s.job)
+	s.startOnce.Do(func() {
+		go s.watcher.Start()
+	})
+}
+
+func (s *LeadScoringManager) Stop() {
+	s.watcher.Stop()
+}
+
+func (s *LeadScoringManager) job() {
+	queues, err := s.store.Lead().ClaimQueues()
+	if err != nil {
+		s.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	for _, q := range queues {
+		s.scoreQueue(q)
+	}
+}
+
+func (s *LeadScoringManager) scoreQueue(q *model.LeadStrategyQueue) {
+	st := time.Now()
+	log := s.log.With(
+		wlog.Int("queue_id", q.QueueId),
+		wlog.Int64("domain_id", q.DomainId),
+	)
+
+	scorer, err := newLeadScorer(model.QueueLeadStrategyFromBytes(q.Strategy))
+	if err != nil {
+		log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	for _, r := range scorer.strategy.Recycle {
+		cnt, err := s.store.Lead().Recycle(q.QueueId, &r)
+		if err != nil {
+			log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		} else if cnt > 0 {
+			log.Debug(fmt.Sprintf("recycled %d members with disposition \"%s\"", cnt, r.Disposition))
+		}
+	}
+
+	members, err := s.store.Lead().Members(q.QueueId, scorer.strategy.MembersLimit())
+	if err != nil {
+		log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	if len(members) == 0 {
+		return
+	}
+
+	scores := scorer.score(q.QueueId, members, model.GetMillis())
+	if err = s.store.Lead().SetScores(q.QueueId, scores); err != nil {
+		log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	log.Debug(fmt.Sprintf("scored %d members, time %s", len(scores), time.Since(st)))
+}
+
+func newLeadScorer(strategy *model.QueueLeadStrategy) (*leadScorer, *model.AppError) {
+	if strategy == nil {
+		return nil, model.NewAppError("LeadScoring", "queue.lead_scoring.strategy.not_found", nil,
+			"lead strategy is not configured", http.StatusBadRequest)
+	}
+
+	if err := strategy.IsValid(); err != nil {
+		return nil, err
+	}
+
+	scorer := &leadScorer{
+		strategy: strategy,
+	}
+
+	if strategy.Score != "" {
+		p, err := expression.Compile(strategy.Score)
+		if err != nil {
+			return nil, model.NewAppError("LeadScoring", "queue.lead_scoring.score.compile", nil,
+				err.Error(), http.StatusBadRequest)
+		}
+		scorer.program = p
+	}
+
+	return scorer, nil
+}
+
+func leadEnv(m *model.LeadMember, now int64) expression.Env {
+	env := expression.EnvFromStrings(m.Variables)
+	env["member.name"] = m.Name
+	env["member.priority"] = float64(m.Priority)
+	env["member.attempts"] = float64(m.Attempts)
+	env["member.age"] = float64(now-m.CreatedAt) / float64(time.Hour/time.Millisecond)
+	if m.LastHangupAt > 0 {
+		env["member.idle"] = float64(now-m.LastHangupAt) / float64(time.Hour/time.Millisecond)
+	}
+	if m.BucketId != nil {
+		env["member.bucket_id"] = float64(*m.BucketId)
+	}
+
+	return env
+}
+
+func (ls *leadScorer) evaluate(m *model.LeadMember, now int64) *scoredLead {
+	l := &scoredLead{
+		member: m,
+		score:  float64(m.Priority),
+	}
+
+	if ls.program != nil {
+		if v, err := ls.program.Float(leadEnv(m, now)); err != nil {
+			l.reason = append(l.reason, fmt.Sprintf("score error: %s, priority %d", err.Error(), m.Priority))
+		} else {
+			l.score = v
+			l.reason = append(l.reason, fmt.Sprintf("score %s = %v", ls.program.String(), v))
+		}
+	} else {
+		l.reason = append(l.reason, fmt.Sprintf("priority %d", m.Priority))
+	}
+
+	fresh := m.Attempts == 0
+	switch {
+	case ls.strategy.Order == model.LeadOrderFreshFirst && fresh:
+		l.tier = 1
+		l.reason = append(l.reason, "fresh lead first")
+	case ls.strategy.Order == model.LeadOrderRetriesFirst && !fresh:
+		l.tier = 1
+		l.reason = append(l.reason, fmt.Sprintf("retry %d first", m.Attempts))
+	}
+
+	return l
+}
+
+// score orders the members of each bucket by the tier and the score, the buckets are interleaved by the smooth
+// weighted round-robin, the priority is the reverse position
+func (ls *leadScorer) score(queueId int, members []*model.LeadMember, now int64) []*model.LeadScore {
+	type bucket struct {
+		weight  int
+		current int
+		leads   []*scoredLead
+	}
+
+	buckets := make(map[int32]*bucket)
+	keys := make([]int32, 0, 4)
+	for _, m := range members {
+		var key int32 = -1
+		if m.BucketId != nil {
+			key = *m.BucketId
+		}
+
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{weight: ls.strategy.BucketWeight(m.BucketId)}
+			buckets[key] = b
+			keys = append(keys, key)
+		}
+		b.leads = append(b.leads, ls.evaluate(m, now))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	for _, b := range buckets {
+		sort.SliceStable(b.leads, func(i, j int) bool {
+			if b.leads[i].tier != b.leads[j].tier {
+				return b.leads[i].tier > b.leads[j].tier
+			}
+			if b.leads[i].score != b.leads[j].score {
+				return b.leads[i].score > b.leads[j].score
+			}
+			return b.leads[i].member.CreatedAt < b.leads[j].member.CreatedAt
+		})
+	}
+
+	ordered := make([]*scoredLead, 0, len(members))
+	for {
+		var best *bucket
+		var bestKey int32
+		total := 0
+		for _, k := range keys {
+			b := buckets[k]
+			if len(b.leads) == 0 || b.weight == 0 {
+				continue
+			}
+			total += b.weight
+			b.current += b.weight
+			if best == nil || b.current > best.current {
+				best = b
+				bestKey = k
+			}
+		}
+
+		if best == nil {
+			break
+		}
+
+		best.current -= total
+		l := best.leads[0]
+		best.leads = best.leads[1:]
+		if bestKey >= 0 {
+			l.reason = append(l.reason, fmt.Sprintf("bucket %d weight %d of %d", bestKey, best.weight, total))
+		}
+		ordered = append(ordered, l)
+	}
+
+	// the buckets with the zero weight are the last
+	for _, k := range keys {
+		for _, l := range buckets[k].leads {
+			l.reason = append(l.reason, fmt.Sprintf("bucket %d weight 0", k))
+			ordered = append(ordered, l)
+		}
+	}
+
+	res := make([]*model.LeadScore, 0, len(ordered))
+	for i, l := range ordered {
+		l.reason = append(l.reason, fmt.Sprintf("position %d of %d", i+1, len(ordered)))
+		res = append(res, &model.LeadScore{
+			MemberId:     l.member.Id,
+			QueueId:      queueId,
+			BasePriority: l.member.Priority,
+			Tier:         l.tier,
+			Score:        l.score,
+			Priority:     len(ordered) - i,
+			Explanation:  l.reason,
+			UpdatedAt:    now,
+		})
+	}
+
+	return res
+}
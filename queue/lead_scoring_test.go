@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestLeadScore(t *testing.T) {
+	t.Log("LeadScore")
+
+	var b1, b2 int32 = 1, 2
+	scorer, err := newLeadScorer(&model.QueueLeadStrategy{
+		Order: model.LeadOrderFreshFirst,
+		Score: "num(amount) / 100 + member.priority",
+		Buckets: []model.LeadBucket{
+			{BucketId: b1, Weight: 2},
+			{BucketId: b2, Weight: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	members := []*model.LeadMember{
+		{Id: 1, BucketId: &b1, Attempts: 1, Variables: map[string]string{"amount": "900"}},
+		{Id: 2, BucketId: &b1, Variables: map[string]string{"amount": "100"}},
+		{Id: 3, BucketId: &b1, Variables: map[string]string{"amount": "500"}},
+		{Id: 4, BucketId: &b2, Priority: 10},
+		{Id: 5, BucketId: &b2, Variables: map[string]string{"amount": "bad"}},
+	}
+
+	res := scorer.score(1, members, model.GetMillis())
+	order := make([]int64, 0, len(res))
+	for _, v := range res {
+		order = append(order, v.MemberId)
+	}
+
+	// bucket 1: 3, 2 (fresh by the score), 1 (retry); bucket 2: 4, 5; weights 2:1
+	expected := []int64{3, 4, 2, 1, 5}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("order: got %v, expected %v", order, expected)
+		}
+	}
+
+	if res[0].Priority != 5 || res[4].Priority != 1 || res[0].Score != 5 {
+		t.Errorf("priority: got %+v", res[0])
+	}
+
+	if res[0].Tier != 1 || res[3].Tier != 0 {
+		t.Errorf("tier: got %d %d", res[0].Tier, res[3].Tier)
+	}
+
+	if len(res[0].Explanation) == 0 {
+		t.Errorf("explanation is empty")
+	}
+}
+
+func TestLeadScorerInvalid(t *testing.T) {
+	t.Log("LeadScorerInvalid")
+
+	if _, err := newLeadScorer(&model.QueueLeadStrategy{Score: "amount +"}); err == nil {
+		t.Errorf("expected compile error")
+	}
+
+	if _, err := newLeadScorer(&model.QueueLeadStrategy{Order: "random"}); err == nil {
+		t.Errorf("expected order error")
+	}
+}
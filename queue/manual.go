@@ -1,8 +1,13 @@
 package queue
 
 import (
+	"context"
+	"fmt"
+	"github.com/webitel/call_center/call_manager"
+	"github.com/webitel/call_center/model"
 	"github.com/webitel/call_center/utils"
 	"github.com/webitel/wlog"
+	"net/http"
 	"sync"
 )
 
@@ -46,3 +51,131 @@ func (qm *Manager) listWaiting() {
 		}
 	}
 }
+
+func (qm *Manager) ManualAttempts(ctx context.Context, search *model.SearchManualAttempt) ([]*model.ManualAttempt, *model.AppError) {
+	if err := search.IsValid(); err != nil {
+		return nil, err
+	}
+
+	return qm.store.Member().ManualAttempts(ctx, search)
+}
+
+// ManualReserve the attempt is reserved by the agent, the node of the attempt offers it to the agent
+func (qm *Manager) ManualReserve(ctx context.Context, r *model.ManualReservation) (*model.ManualReserved, *model.AppError) {
+	if err := r.IsValid(); err != nil {
+		return nil, err
+	}
+
+	res, err := qm.store.Member().ManualReserve(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if a, ok := qm.membersCache.Get(res.AttemptId); ok {
+		attempt := a.(*Attempt)
+		attempt.Log(fmt.Sprintf("manual reserved by agent %d", r.AgentId))
+		attempt.Journal(model.AttemptJournalRouting, map[string]interface{}{
+			"manual_reserved_agent_id": r.AgentId,
+		})
+	}
+
+	if err = qm.app.NotificationInterceptAttempt(r.DomainId, res.QueueId, res.Channel, res.AttemptId, int32(r.AgentId)); err != nil {
+		qm.log.Error(fmt.Sprintf("manual reserve attempt %d notification, error : %s", res.AttemptId, err.Error()),
+			wlog.Err(err),
+			wlog.Int64("attempt_id", res.AttemptId),
+			wlog.Int("agent_id", r.AgentId),
+		)
+	}
+
+	return res, nil
+}
+
+// ManualRelease returns the reserved attempt to the queue, the offered call of the agent is canceled by the node of the attempt
+func (qm *Manager) ManualRelease(ctx context.Context, r *model.ManualReservation) *model.AppError {
+	if err := r.IsValid(); err != nil {
+		return err
+	}
+
+	released, err := qm.store.Member().ManualRelease(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if released {
+		if a, ok := qm.membersCache.Get(r.AttemptId); ok {
+			a.(*Attempt).Log(fmt.Sprintf("manual released by agent %d", r.AgentId))
+		}
+		return nil
+	}
+
+	requested, err := qm.store.Member().ManualReleaseOffer(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if !requested {
+		return model.NewAppError("Queue.ManualRelease", "queue.manual.release.conflict", nil,
+			fmt.Sprintf("attempt %d is not reserved by the agent %d", r.AttemptId, r.AgentId), http.StatusConflict)
+	}
+
+	return nil
+}
+
+// manualReleaseOffers cancels the offers of the attempts of the node released by the agents
+func (qm *Manager) manualReleaseOffers() {
+	list, err := qm.store.Member().ManualReleasedByNode(qm.app.GetInstanceId())
+	if err != nil {
+		qm.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	for _, r := range list {
+		if err = qm.cancelManualOffer(r); err != nil {
+			qm.log.Warn(err.Error(),
+				wlog.Err(err),
+				wlog.Int64("attempt_id", r.AttemptId),
+			)
+		}
+	}
+}
+
+func (qm *Manager) cancelManualOffer(r *model.ManualReservation) *model.AppError {
+	a, ok := qm.membersCache.Get(r.AttemptId)
+	if !ok {
+		return model.NewAppError("Queue.ManualRelease", "queue.manual.release.not_found", nil,
+			fmt.Sprintf("attempt %d not found", r.AttemptId), http.StatusNotFound)
+	}
+
+	attempt := a.(*Attempt)
+	attempt.Lock()
+	agent, agentChannel := attempt.agent, attempt.agentChannel
+	if agent == nil || agent.Id() != r.AgentId || attempt.bridgedAt != 0 {
+		attempt.Unlock()
+		return model.NewAppError("Queue.ManualRelease", "queue.manual.release.conflict", nil,
+			fmt.Sprintf("attempt %d is not offered to the agent %d", r.AttemptId, r.AgentId), http.StatusConflict)
+	}
+
+	call, ok := agentChannel.(call_manager.Call)
+	if !ok {
+		attempt.Unlock()
+		return model.NewAppError("Queue.ManualRelease", "queue.manual.release.not_call", nil,
+			fmt.Sprintf("attempt %d: the offer is released by the decline of the agent", r.AttemptId), http.StatusConflict)
+	}
+	attempt.manualReleased = true
+	attempt.Unlock()
+
+	attempt.Log(fmt.Sprintf("manual released by agent %d, cancel offer", r.AgentId))
+	return call.Hangup(model.CALL_HANGUP_ORIGINATOR_CANCEL, false, nil)
+}
+
+// takeManualReleased the offer was canceled by the manual release, the agent did not miss the call
+func (a *Attempt) takeManualReleased() bool {
+	a.Lock()
+	defer a.Unlock()
+	res := a.manualReleased
+	a.manualReleased = false
+
+	return res
+}
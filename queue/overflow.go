@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const (
+	overflowCheckInterval = time.Second * 5
+)
+
+func (queue *InboundQueue) hasOverflow() bool {
+	for _, r := range queue.props.Overflow {
+		if r.Valid() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (queue *InboundQueue) overflowTicker() *time.Ticker {
+	if !queue.hasOverflow() {
+		return nil
+	}
+
+	return time.NewTicker(overflowCheckInterval)
+}
+
+// checkOverflow applies the matched rules, returns new queue if the attempt moved
+func (queue *InboundQueue) checkOverflow(attempt *Attempt, fired []bool) QueueObject {
+	waitSec := int(time.Since(attempt.member.CreatedAt).Seconds())
+	ewt, agents := -1, -1
+
+	for i, r := range queue.props.Overflow {
+		if fired[i] || !r.Valid() || waitSec < int(r.After) {
+			continue
+		}
+
+		if r.EwtAbove > 0 && ewt == -1 {
+			ewt = 0
+			if _, e, err := queue.queueManager.WaitingPosition(attempt); err == nil {
+				ewt = e
+			}
+		}
+
+		if r.NoAgents && agents == -1 {
+			agents = 0
+			if stats, err := queue.queueManager.inboundQueueStats(queue.id); err == nil {
+				agents = stats.Agents
+			}
+		}
+
+		if !r.Match(waitSec, ewt, agents) {
+			continue
+		}
+
+		switch r.Action {
+		case model.OverflowActionPriority:
+			fired[i] = true
+			if res, err := queue.queueManager.store.Member().OverflowAttempt(attempt.Id(), nil, r.Priority); err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			} else {
+				attempt.Log(fmt.Sprintf("overflow set priority %d", res.Weight))
+			}
+
+		case model.OverflowActionTeam:
+			agentId, err := queue.queueManager.store.Member().OverflowTeam(attempt.Id(), *r.TeamId)
+			if err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+			} else if agentId != nil {
+				fired[i] = true
+				attempt.Log(fmt.Sprintf("overflow to team %d, reserved agent %d", *r.TeamId, *agentId))
+				return nil
+			}
+
+		case model.OverflowActionQueue:
+			fired[i] = true
+			q, err := queue.queueManager.OverflowToQueue(attempt, *r.QueueId)
+			if err != nil {
+				attempt.log.Error(err.Error(),
+					wlog.Err(err),
+				)
+				continue
+			}
+
+			return q
+		}
+	}
+
+	return nil
+}
+
+// OverflowToQueue moves the waiting call attempt to the inbound queue, the original join time is kept
+func (qm *Manager) OverflowToQueue(attempt *Attempt, queueId int) (QueueObject, *model.AppError) {
+	queue, err := qm.GetQueue(queueId, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := queue.(*InboundQueue); !ok {
+		return nil, model.NewAppError("Queue.OverflowToQueue", "queue.overflow.valid.type", nil,
+			fmt.Sprintf("queue %d is not inbound call queue", queueId), http.StatusBadRequest)
+	}
+
+	res, err := qm.store.Member().OverflowAttempt(attempt.Id(), &queueId, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if queue, err = qm.GetQueue(res.QueueId, res.QueueUpdatedAt); err != nil {
+		return nil, err
+	}
+
+	from := attempt.QueueId()
+	attempt.setQueue(queue, res.QueueUpdatedAt)
+	attempt.AddVariables(map[string]string{
+		model.QueueOverflowFromVariable: strconv.Itoa(from),
+	})
+	attempt.Log(fmt.Sprintf("overflow from queue %d to queue %d", from, queue.Id()))
+
+	return queue, nil
+}
+
+func (a *Attempt) setQueue(queue QueueObject, updatedAt int64) {
+	a.Lock()
+	a.queue = queue
+	a.member.QueueId = queue.Id()
+	a.member.QueueUpdatedAt = updatedAt
+	a.Unlock()
+}
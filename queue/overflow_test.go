@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func TestOverflowWaitTimeout(t *testing.T) {
+	t.Log("OverflowWaitTimeout")
+
+	log := wlog.NewLogger(&wlog.LoggerConfiguration{})
+	newAttempt := func(vars map[string]string) *Attempt {
+		return NewAttempt(context.Background(), &model.MemberAttempt{
+			Id:        1,
+			QueueId:   1,
+			CreatedAt: time.Now().Add(-time.Minute),
+			Variables: vars,
+		}, log)
+	}
+
+	plain := &InboundQueue{props: model.QueueInboundSettings{MaxWaitTime: 120}}
+	if d := plain.waitTimeout(newAttempt(nil)); d != 2*time.Minute {
+		t.Errorf("no overflow rules: got %s", d)
+	}
+
+	if d := plain.waitTimeout(newAttempt(map[string]string{model.QueueOverflowFromVariable: "2"})); d > time.Minute+time.Second {
+		t.Errorf("overflowed attempt: got %s", d)
+	}
+
+	rules := &InboundQueue{props: model.QueueInboundSettings{
+		MaxWaitTime: 120,
+		Overflow:    []model.QueueOverflowRule{{Action: model.OverflowActionPriority, Priority: 10, After: 30}},
+	}}
+	if d := rules.waitTimeout(newAttempt(nil)); d > time.Minute+time.Second {
+		t.Errorf("queue with overflow rules: got %s", d)
+	}
+
+	invalid := &InboundQueue{props: model.QueueInboundSettings{
+		MaxWaitTime: 120,
+		Overflow:    []model.QueueOverflowRule{{Action: model.OverflowActionTeam}},
+	}}
+	if invalid.hasOverflow() || invalid.overflowTicker() != nil {
+		t.Errorf("invalid rule is used")
+	}
+	if d := invalid.waitTimeout(newAttempt(nil)); d != 2*time.Minute {
+		t.Errorf("invalid overflow rules: got %s", d)
+	}
+}
+
+func TestOverflowRuleMatch(t *testing.T) {
+	t.Log("OverflowRuleMatch")
+
+	cases := []struct {
+		rule   model.QueueOverflowRule
+		wait   int
+		ewt    int
+		agents int
+		match  bool
+	}{
+		{model.QueueOverflowRule{After: 30}, 10, 0, 0, false},
+		{model.QueueOverflowRule{After: 30}, 30, 0, 1, true},
+		{model.QueueOverflowRule{After: 10, EwtAbove: 60}, 20, 60, 1, false},
+		{model.QueueOverflowRule{After: 10, EwtAbove: 60}, 20, 61, 1, true},
+		{model.QueueOverflowRule{NoAgents: true}, 0, 0, 2, false},
+		{model.QueueOverflowRule{NoAgents: true}, 0, 0, 0, true},
+	}
+
+	for i, c := range cases {
+		if m := c.rule.Match(c.wait, c.ewt, c.agents); m != c.match {
+			t.Errorf("case %d: expected %v, got %v", i, c.match, m)
+		}
+	}
+}
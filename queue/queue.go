@@ -9,6 +9,7 @@ import (
 	"github.com/webitel/flow_manager/client"
 	"github.com/webitel/wlog"
 	"net/http"
+	"time"
 )
 
 type QueueObject interface {
@@ -43,6 +44,20 @@ type QueueObject interface {
 	AutoAnswerValue() interface{}
 	RingtoneUri() string
 	AmdPlaybackUri() *string // todo move to amd
+	Sla() *model.QueueSlaSettings
+	ResourceStrategy() *model.QueueResourceStrategy
+	Dispositions() *model.QueueDispositions
+	DispositionHook(d *model.QueueDisposition, at *Attempt)
+	AllowAgent(attempt *Attempt, agent agent_manager.AgentObject) bool
+	AllowAffinityAgent(attempt *Attempt, agent agent_manager.AgentObject) bool
+	DistributeAffinity(attempt *Attempt)
+	VipPriority(attempt *Attempt) int
+	PriorityBoost(attempt *Attempt) int
+	RoutingTimeout(attempt *Attempt, def time.Duration) time.Duration
+	WrapUp() *model.QueueWrapUp
+	WrapUpTime(result string) (uint32, bool)
+	ValidateForm(attempt *Attempt, action string, fields map[string]string, partial bool) *model.AppError
+	TeamId() *int
 	Log() *wlog.Logger
 }
 
@@ -70,6 +85,13 @@ type BaseQueue struct {
 	endless              bool
 	hooks                HookHub
 	amdPlaybackFileUri   *string
+	sla                  *model.QueueSlaSettings
+	resourceStrategy     *model.QueueResourceStrategy
+	dispositions         *model.QueueDispositions
+	routing              *queueRouting
+	affinity             *queueAffinity
+	wrapUp               *model.QueueWrapUp
+	form                 *queueForm
 	log                  *wlog.Logger
 }
 
@@ -95,6 +117,10 @@ func NewBaseQueue(queueManager *Manager, resourceManager *ResourceManager, setti
 		processingRenewalSec: settings.ProcessingRenewalSec,
 		endless:              settings.Endless,
 		hooks:                NewHookHub(settings.Hooks),
+		sla:                  model.QueueSlaSettingsFromBytes(settings.Payload),
+		resourceStrategy:     model.QueueResourceStrategyFromBytes(settings.Payload),
+		dispositions:         model.QueueDispositionsFromBytes(settings.Payload),
+		wrapUp:               model.QueueWrapUpFromBytes(settings.Payload),
 		log: queueManager.log.With(
 			wlog.Int("queue_id", settings.Id),
 			wlog.Int64("domain_id", settings.DomainId),
@@ -124,6 +150,20 @@ func NewBaseQueue(queueManager *Manager, resourceManager *ResourceManager, setti
 func NewQueue(queueManager *Manager, resourceManager *ResourceManager, settings *model.Queue) (QueueObject, *model.AppError) {
 	base := NewBaseQueue(queueManager, resourceManager, settings)
 
+	routing, err := newQueueRouting(model.QueueRoutingFromBytes(settings.Payload))
+	if err != nil {
+		return nil, err
+	}
+	base.routing = routing
+
+	affinity, err := newQueueAffinity(model.QueueAffinityFromBytes(settings.Payload))
+	if err != nil {
+		return nil, err
+	}
+	base.affinity = affinity
+
+	base.form = newQueueForm(model.QueueFormDefinitionFromBytes(settings.Payload), base.log)
+
 	switch settings.Type {
 	case model.QueueTypeOfflineCall:
 		return NewOfflineCallQueue(CallingQueue{
@@ -175,7 +215,7 @@ func NewQueue(queueManager *Manager, resourceManager *ResourceManager, settings
 		}, PredictCallQueueSettingsFromBytes(settings.Payload)), nil
 
 	case model.QueueTypeInboundChat:
-		return NewInboundChatQueue(base, InboundChatQueueFromBytes(settings.Payload)), nil
+		return NewInboundChatQueue(base, InboundChatQueueFromBytes(settings.Payload))
 
 	case model.QueueTypeAgentTask:
 		return NewTaskInboundQueue(base, TaskInboundSettingsFromBytes(settings.Payload)), nil
@@ -202,6 +242,22 @@ func (queue *BaseQueue) Log() *wlog.Logger {
 	return queue.log
 }
 
+func (queue *BaseQueue) Sla() *model.QueueSlaSettings {
+	return queue.sla
+}
+
+func (queue *BaseQueue) ResourceStrategy() *model.QueueResourceStrategy {
+	return queue.resourceStrategy
+}
+
+func (queue *BaseQueue) Dispositions() *model.QueueDispositions {
+	return queue.dispositions
+}
+
+func (queue *BaseQueue) TeamId() *int {
+	return queue.teamId
+}
+
 func (queue *BaseQueue) Manager() *Manager {
 	return queue.queueManager
 }
@@ -25,6 +25,7 @@ const (
 	maxExpireCache = 0 //60 * 60 * 24 //day
 
 	timeoutWaitBeforeStop = time.Second * 10
+	maxHealthFlipResource = 5
 )
 
 type Manager struct {
@@ -37,11 +38,17 @@ type Manager struct {
 	input            chan *Attempt
 	queuesCache      utils.ObjectCache
 	membersCache     utils.ObjectCache
+	statsCache       utils.ObjectCache
+	candidatesCache  utils.ObjectCache
 	store            store.Store
 	resourceManager  *ResourceManager
 	agentManager     agent_manager.AgentManager
 	callManager      call_manager.CallManager
 	teamManager      *teamManager
+	slaManager       *SlaManager
+	resourceHealth   *ResourceHealthManager
+	resourceSelector *ResourceSelectorManager
+	wallboard        *Wallboard
 	waitChannelClose bool
 	bridgeSleep      time.Duration
 	log              *wlog.Logger
@@ -58,7 +65,7 @@ var (
 
 func NewQueueManager(app App, s store.Store, m mq.MQ, callManager call_manager.CallManager, resourceManager *ResourceManager,
 	agentManager agent_manager.AgentManager, bridgeSleep time.Duration) *Manager {
-	return &Manager{
+	qm := &Manager{
 		store:            s,
 		app:              app,
 		callManager:      callManager,
@@ -73,11 +80,19 @@ func NewQueueManager(app App, s store.Store, m mq.MQ, callManager call_manager.C
 		waitChannelClose: app.QueueSettings().WaitChannelClose,
 		queuesCache:      utils.NewLruWithParams(maxQueueCache, "QueueManager", maxExpireCache, ""),
 		membersCache:     utils.NewLruWithParams(maxMemberCache, "Members", maxExpireCache, ""),
+		statsCache:       utils.NewLruWithParams(maxQueueCache, "InboundStats", inboundStatsExpireSec, ""),
+		candidatesCache:  utils.NewLruWithParams(maxQueueCache, "ResourceCandidates", resourceCandidatesExpireSec, ""),
 		log: wlog.GlobalLogger().With(
 			wlog.Namespace("context"),
 			wlog.String("name", "queue_manager"),
 		),
 	}
+	qm.slaManager = NewSlaManager(app.GetInstanceId(), s, m, qm.log)
+	qm.resourceHealth = NewResourceHealthManager(app.GetInstanceId(), s, m, qm.log)
+	qm.resourceSelector = NewResourceSelectorManager(qm.log)
+	qm.wallboard = NewWallboard(qm)
+
+	return qm
 }
 
 func (qm *Manager) Start() {
@@ -89,6 +104,8 @@ func (qm *Manager) Start() {
 	}()
 
 	qm.listenWaitingList()
+	qm.wallboard.Start()
+	qm.resourceHealth.Start()
 
 	for {
 		select {
@@ -129,6 +146,8 @@ func (qm *Manager) closeAttempts() {
 func (qm *Manager) Stop() {
 	qm.log.Debug("queueManager Stopping")
 	qm.stopWaitingList()
+	qm.wallboard.Stop()
+	qm.resourceHealth.Stop()
 	qm.log.Debug(fmt.Sprintf("wait %v for close attempts %d", timeoutWaitBeforeStop, qm.membersCache.Len()))
 
 	if waitTimeout(&qm.wg, timeoutWaitBeforeStop) {
@@ -139,6 +158,14 @@ func (qm *Manager) Stop() {
 	<-qm.stopped
 }
 
+func (qm *Manager) Wallboard() *Wallboard {
+	return qm.wallboard
+}
+
+func (qm *Manager) ResourceHealth() *ResourceHealthManager {
+	return qm.resourceHealth
+}
+
 func (qm *Manager) GetNodeId() string {
 	return qm.app.GetInstanceId()
 }
@@ -253,6 +280,52 @@ func (qm *Manager) SetResourceError(resource ResourceObject, errorId string) {
 	}
 }
 
+// checkResourceHealth moves the attempt to the healthy resource when the breaker of the current one does not allow the call,
+// returns false when there is no healthy resource
+func (qm *Manager) checkResourceHealth(attempt *Attempt) bool {
+	skip := make([]int, 0, 1)
+	for attempt.resource != nil && !qm.resourceHealth.Allow(attempt.resource) {
+		attempt.Log(fmt.Sprintf("resource %s [%d] is unhealthy", attempt.resource.Name(), attempt.resource.Id()))
+		skip = append(skip, attempt.resource.Id())
+		if len(skip) > maxHealthFlipResource {
+			return false
+		}
+
+		res, err := qm.FlipAttemptResource(attempt, skip)
+		if err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			return false
+		}
+
+		if res.ResourceId == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deferAttempt the attempt without the healthy resource returns to the member, the member is ready after the open timeout
+// of the breaker, the attempt is not counted
+func (qm *Manager) deferAttempt(attempt *Attempt) {
+	var delay uint32
+	if attempt.resource != nil {
+		delay = attempt.resource.Health().OpenTimeoutSec()
+	}
+
+	attempt.Log(fmt.Sprintf("no healthy resource, deferred for %d sec", delay))
+	if err := qm.store.Member().DeferAttempt(attempt.Id(), delay); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+
+	attempt.SetResult(model.MemberResultDeferred)
+	qm.LeavingMember(attempt)
+}
+
 func (qm *Manager) SetResourceSuccessful(resource ResourceObject) {
 	if resource.SuccessivelyErrors() > 0 {
 		if err := qm.store.OutboundResource().SetSuccessivelyErrorsById(int64(resource.Id()), 0); err != nil {
@@ -308,7 +381,18 @@ func (qm *Manager) DistributeAttempt(attempt *Attempt) (QueueObject, *model.AppE
 
 	//todo new event instance
 
+	memberDisplay := attempt.communication.Display != nil && *attempt.communication.Display != ""
 	attempt.resource = qm.GetAttemptResource(attempt)
+	if attempt.resource == nil || qm.selectAttemptResource(queue, attempt, nil, false) == nil {
+		if !qm.checkResourceHealth(attempt) {
+			qm.deferAttempt(attempt)
+			return nil, nil
+		}
+	}
+
+	if !memberDisplay {
+		qm.setCallerIdDisplay(attempt)
+	}
 
 	if err = queue.DistributeAttempt(attempt); err != nil {
 		attempt.log.Error(err.Error(),
@@ -429,6 +513,9 @@ func (qm *Manager) DistributeCall(_ context.Context, in *cc.CallJoinToQueueReque
 		BucketId:            bucketId,
 	})
 
+	qm.boostAttempt(q, attempt)
+	q.DistributeAffinity(attempt)
+
 	if _, err = qm.DistributeAttempt(attempt); err != nil {
 		printfIfErr(qm.store.Member().DistributeCallToQueueCancel(res.AttemptId))
 		return nil, err
@@ -759,6 +846,11 @@ func (qm *Manager) DistributeChatToQueue(_ context.Context, in *cc.ChatJoinToQue
 		BucketId:            bucketId,
 	})
 
+	if q, qErr := qm.GetQueue(res.QueueId, res.QueueUpdatedAt); qErr == nil {
+		qm.boostAttempt(q, attempt)
+		q.DistributeAffinity(attempt)
+	}
+
 	if _, err = qm.DistributeAttempt(attempt); err != nil {
 		printfIfErr(qm.store.Member().DistributeCallToQueueCancel(res.AttemptId))
 		return nil, err
@@ -815,12 +907,11 @@ func (qm *Manager) InterceptAttempt(ctx context.Context, domainId int64, attempt
 	return nil
 }
 
-func (qm *Manager) TimeoutLeavingMember(attempt *Attempt) {
+// TimeoutLeavingMember the result of the after distribute schema, the error when the result is not in the disposition catalog
+func (qm *Manager) TimeoutLeavingMember(attempt *Attempt) *model.AppError {
 	queue := attempt.queue
 	if queue != nil {
-		var waitBetween uint64 = 0
-		var maxAttempts uint = 0
-		var perNumbers = false
+		var p retryPolicy
 
 		result := model.AttemptCallback{
 			Status: "timeout",
@@ -837,26 +928,33 @@ func (qm *Manager) TimeoutLeavingMember(attempt *Attempt) {
 				ExpireAt:      nil,
 			}
 
-			waitBetween = attempt.waitBetween
-			maxAttempts = attempt.maxAttempts
-			perNumbers = attempt.perNumbers
-
 			if callback.AgentId > 0 {
 				result.StickyAgentId = model.NewInt(int(callback.AgentId))
 			}
+
+			if err := qm.validateDisposition(attempt, result.Status); err != nil {
+				return err
+			}
+
+			p = qm.applyDisposition(attempt, &result, retryPolicy{
+				maxAttempts: attempt.maxAttempts,
+				waitBetween: attempt.waitBetween,
+				perNumbers:  attempt.perNumbers,
+			})
 		}
 
-		res, err := qm.store.Member().SchemaResult(attempt.Id(), &result, maxAttempts, waitBetween, perNumbers)
+		res, err := qm.store.Member().SchemaResult(attempt.Id(), &result, p.maxAttempts, p.waitBetween, p.perNumbers)
 		if err != nil {
 			attempt.log.Error(err.Error(),
 				wlog.Err(err),
 			)
 
-			return
+			return nil
 		}
 		if res.MemberStopCause != nil {
 			attempt.SetMemberStopCause(res.MemberStopCause)
 		}
+		qm.stopDisposition(attempt, p)
 
 		if res.Result != nil {
 			attempt.SetResult(*res.Result)
@@ -865,6 +963,8 @@ func (qm *Manager) TimeoutLeavingMember(attempt *Attempt) {
 		}
 		qm.LeavingMember(attempt)
 	}
+
+	return nil
 }
 
 func (qm *Manager) LeavingMember(attempt *Attempt) {
@@ -888,6 +988,15 @@ func (qm *Manager) LeavingMember(attempt *Attempt) {
 	attempt.SetState(HookLeaving)
 	attempt.Close()
 	qm.membersCache.Remove(attempt.Id())
+
+	switch attempt.queue.(type) {
+	case *InboundQueue, *InboundChatQueue:
+		go qm.slaManager.Leaving(attempt.queue)
+	}
+	go qm.storeJournal(attempt)
+	if qm.app.FileSettings().AttemptLogs {
+		go qm.storeAttemptLog(attempt)
+	}
 	qm.wg.Done()
 
 	attempt.log.Info(fmt.Sprintf("[%s] leaving member %s[%v] AttemptId=%d  from queue \"%s\" [%d]", attempt.queue.TypeName(), attempt.Name(),
@@ -975,6 +1084,9 @@ func (qm *Manager) SaveFormFields(ctx context.Context, domainId int64, id int64,
 	}
 
 	if att.processingFormStarted {
+		if err := att.queue.ValidateForm(att, "", fields, true); err != nil {
+			return err
+		}
 		att.UpdateProcessingFields(fields)
 		att.processingForm.Update(form, fields)
 	}
@@ -1124,6 +1236,10 @@ func (qm *Manager) RenewalAttempt(domainId, attemptId int64, renewal uint32) (er
 		return err
 	}
 
+	if err = renewalWrapUp(attemptId, data); err != nil {
+		return err
+	}
+
 	ev := NewRenewalProcessingEvent(data.AttemptId, data.UserId, data.Channel, data.Timeout, data.Timestamp, data.RenewalSec)
 	return qm.mq.AgentChannelEvent(data.Channel, data.DomainId, data.QueueId, data.UserId, ev)
 }
@@ -1139,10 +1255,17 @@ func (qm *Manager) ReportingAttempt(attemptId int64, result model.AttemptCallbac
 	)
 
 	attempt, _ := qm.GetAttempt(attemptId)
+	if !system {
+		if err := qm.validateDisposition(attempt, result.Status); err != nil {
+			return err
+		}
+	}
+
+	if v := result.Variables[model.QueueAmdCorrectionVariable]; v != "" && attempt != nil {
+		go qm.reportAmdCorrection(attempt.domainId, attemptId, v)
+	}
 
-	var waitBetween uint64 = 0
-	var maxAttempts uint = 0
-	var perNumbers = false
+	var p retryPolicy
 
 	if attempt != nil {
 		// TODO [biz]
@@ -1166,20 +1289,32 @@ func (qm *Manager) ReportingAttempt(attemptId int64, result model.AttemptCallbac
 				result.Variables = model.UnionStringMaps(result.Variables, r.Variables)
 			}
 		}
-		waitBetween = attempt.waitBetween
-		maxAttempts = attempt.maxAttempts
-		perNumbers = attempt.perNumbers
+		p = qm.applyDisposition(attempt, &result, retryPolicy{
+			maxAttempts: attempt.maxAttempts,
+			waitBetween: attempt.waitBetween,
+			perNumbers:  attempt.perNumbers,
+		})
 	}
 
-	res, err := qm.store.Member().CallbackReporting(attemptId, &result, maxAttempts, waitBetween, perNumbers)
+	res, err := qm.store.Member().CallbackReporting(attemptId, &result, p.maxAttempts, p.waitBetween, p.perNumbers)
 	if err != nil {
 		return err
 	}
 
+	if qm.stopDisposition(attempt, p) {
+		res.MemberStopCause = model.NewString(p.stopCause)
+	}
+
 	if !system {
 		err = qm.closeBeforeReporting(attemptId, res, result.Status, attempt)
 	}
 
+	qm.setWrapUpTime(attempt, res, result.Status)
+	qm.endWrapUp(attemptId, res, &result)
+	if attempt != nil {
+		go qm.storeJournal(attempt)
+	}
+
 	return qm.doLeavingReporting(attemptId, attempt, res, &result)
 }
 
@@ -1338,6 +1473,13 @@ func (qm *Manager) CancelAgentDistribute(agentId int32) *model.AppError {
 }
 
 func (qm *Manager) FlipAttemptResource(attempt *Attempt, skipp []int) (*model.AttemptFlipResource, *model.AppError) {
+	if attempt.queue != nil {
+		if res := qm.selectAttemptResource(attempt.queue, attempt, skipp, true); res != nil {
+			qm.setCallerIdDisplay(attempt)
+			return res, nil
+		}
+	}
+
 	res, err := qm.store.Member().FlipResource(attempt.Id(), skipp)
 	if err != nil {
 		return nil, err
@@ -1349,7 +1491,7 @@ func (qm *Manager) FlipAttemptResource(attempt *Attempt, skipp []int) (*model.At
 
 	attempt.FlipResource(res)
 	attempt.resource = qm.GetAttemptResource(attempt)
-	attempt.communication.Display = model.NewString(attempt.resource.GetDisplay())
+	qm.setCallerIdDisplay(attempt)
 
 	return res, nil
 }
@@ -1373,3 +1515,12 @@ func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
 		return true // timed out
 	}
 }
+
+func (qm *Manager) storeAttemptLog(attempt *Attempt) {
+	path := fmt.Sprintf("attempts/%d/%s/%d.json", attempt.domainId, time.Now().UTC().Format("2006/01/02"), attempt.Id())
+	if _, err := qm.app.StoreFile(path, attempt.LogsData()); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
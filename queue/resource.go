@@ -5,6 +5,8 @@ import (
 	"github.com/webitel/call_center/utils"
 	"github.com/webitel/wlog"
 	"math/rand"
+	"sync"
+	"time"
 )
 
 const (
@@ -23,6 +25,13 @@ type ResourceObject interface {
 	Take()
 	Gateway() *model.SipGateway
 	Log() *wlog.Logger
+	Health() *model.ResourceHealthSettings
+	Weight() int
+	Rates() []model.ResourceRate
+	DisplayNumbers() []string
+	Rps() uint16
+	CurrentRps() uint16
+	CallerId() *model.CallerIdSettings
 }
 
 //type Gateway interface {
@@ -46,7 +55,14 @@ type Resource struct {
 	gatewayId             *int64
 	emailProfileId        *int
 	gateway               model.SipGateway
+	health                *model.ResourceHealthSettings
+	weight                int
+	rates                 []model.ResourceRate
+	callerId              *model.CallerIdSettings
+	rpsSecond             int64
+	rpsCount              uint16
 	log                   *wlog.Logger
+	sync.Mutex
 }
 
 func NewResource(config *model.OutboundResource, gw model.SipGateway, log *wlog.Logger) (ResourceObject, *model.AppError) {
@@ -61,6 +77,10 @@ func NewResource(config *model.OutboundResource, gw model.SipGateway, log *wlog.
 		variables:             model.MapStringInterfaceToString(config.Variables),
 		displayNumbers:        config.DisplayNumbers,
 		gateway:               gw,
+		health:                config.Parameters.Health,
+		weight:                int(config.Parameters.Weight),
+		rates:                 config.Parameters.Rates,
+		callerId:              config.Parameters.CallerId,
 		log: log.With(
 			wlog.String("scope", "resource"),
 			wlog.Int("resource_id", config.Id),
@@ -122,10 +142,57 @@ func (r *Resource) Gateway() *model.SipGateway {
 	return &r.gateway
 }
 
+func (r *Resource) Health() *model.ResourceHealthSettings {
+	return r.health
+}
+
+func (r *Resource) Weight() int {
+	if r.weight == 0 {
+		return 1
+	}
+	return r.weight
+}
+
+func (r *Resource) Rates() []model.ResourceRate {
+	return r.rates
+}
+
+func (r *Resource) DisplayNumbers() []string {
+	return r.displayNumbers
+}
+
+func (r *Resource) CallerId() *model.CallerIdSettings {
+	return r.callerId
+}
+
+func (r *Resource) Rps() uint16 {
+	return r.rps
+}
+
+// CurrentRps calls of the resource in the current second
+func (r *Resource) CurrentRps() uint16 {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.rpsSecond != time.Now().Unix() {
+		return 0
+	}
+	return r.rpsCount
+}
+
 func (r *Resource) Take() {
 	if r.rateLimiter != nil {
 		r.rateLimiter.Take()
 	}
+
+	now := time.Now().Unix()
+	r.Lock()
+	if r.rpsSecond != now {
+		r.rpsSecond = now
+		r.rpsCount = 0
+	}
+	r.rpsCount++
+	r.Unlock()
 }
 
 func (r *Resource) CheckCodeError(errorCode string) bool {
@@ -133,8 +200,12 @@ func (r *Resource) CheckCodeError(errorCode string) bool {
 		return false
 	}
 
+	return matchCodeMask(r.errorIds, errorCode)
+}
+
+func matchCodeMask(masks []string, errorCode string) bool {
 	e := []rune(errorCode)
-	for _, v := range r.errorIds {
+	for _, v := range masks {
 		if !checkCodeMask(v, e) {
 			return true
 		}
@@ -0,0 +1,545 @@
+package queue
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/mq"
+	"github.com/webitel/call_center/store"
+	"github.com/webitel/call_center/utils"
+	"github.com/webitel/wlog"
+)
+
+const (
+	gatewayHealthWindow           = 100
+	resourceHealthPollingInterval = 2000
+)
+
+type healthSample struct {
+	answered bool
+	failure  bool
+	setupMs  int64
+	code     string
+}
+
+// healthWindow ring of the last calls
+type healthWindow struct {
+	samples []healthSample
+	pos     int
+	count   int
+}
+
+func newHealthWindow(size int) *healthWindow {
+	return &healthWindow{
+		samples: make([]healthSample, size),
+	}
+}
+
+func (w *healthWindow) add(s healthSample) {
+	w.samples[w.pos] = s
+	w.pos = (w.pos + 1) % len(w.samples)
+	if w.count < len(w.samples) {
+		w.count++
+	}
+}
+
+func (w *healthWindow) reset() {
+	w.pos = 0
+	w.count = 0
+}
+
+func (w *healthWindow) stats() model.ResourceHealthStats {
+	var res model.ResourceHealthStats
+	var setup, setupCount int64
+
+	for i := 0; i < w.count; i++ {
+		s := &w.samples[i]
+		res.Calls++
+		if s.answered {
+			res.Answered++
+		}
+		if s.failure {
+			res.Failures++
+		}
+		if s.setupMs > 0 {
+			setup += s.setupMs
+			setupCount++
+		}
+		if !s.answered && s.code != "" {
+			if res.Errors == nil {
+				res.Errors = make(map[string]int)
+			}
+			res.Errors[s.code]++
+		}
+	}
+
+	if res.Calls > 0 {
+		res.Asr = float64(res.Answered) * 100 / float64(res.Calls)
+		res.ErrorRate = float64(res.Failures) * 100 / float64(res.Calls)
+	}
+	if setupCount > 0 {
+		res.AvgSetupMs = setup / setupCount
+	}
+
+	return res
+}
+
+// resourceHealth circuit breaker of the resource:
+// closed -> degraded (part of the traffic) -> open (no traffic) -> half_open (probe calls) -> closed
+type resourceHealth struct {
+	id        int
+	name      string
+	gatewayId int64
+	domainId  int64
+	settings  *model.ResourceHealthSettings
+	window    *healthWindow
+	state     string
+	cause     string
+	changedAt time.Time
+	probes    int
+	probesOk  int
+}
+
+func newResourceHealth(resource ResourceObject, now time.Time) *resourceHealth {
+	settings := resource.Health()
+	return &resourceHealth{
+		id:        resource.Id(),
+		name:      resource.Name(),
+		gatewayId: resource.Gateway().Id,
+		domainId:  resource.Gateway().DomainId,
+		settings:  settings,
+		window:    newHealthWindow(settings.WindowSize()),
+		state:     model.ResourceHealthClosed,
+		changedAt: now,
+	}
+}
+
+func (h *resourceHealth) setSettings(settings *model.ResourceHealthSettings) {
+	if h.settings == settings {
+		return
+	}
+
+	if h.settings.WindowSize() != settings.WindowSize() {
+		h.window = newHealthWindow(settings.WindowSize())
+	}
+	h.settings = settings
+}
+
+func (h *resourceHealth) setState(now time.Time, state, cause string) {
+	h.state = state
+	h.cause = cause
+	h.changedAt = now
+	h.probes = 0
+	h.probesOk = 0
+}
+
+// allow returns false when the call must not use the resource, moves the open breaker to half-open after the timeout
+func (h *resourceHealth) allow(now time.Time, rnd int) (bool, bool) {
+	switch h.state {
+	case model.ResourceHealthDegraded:
+		return rnd < h.settings.Weight(), false
+	case model.ResourceHealthOpen:
+		if now.Sub(h.changedAt) < time.Second*time.Duration(h.settings.OpenTimeoutSec()) {
+			return false, false
+		}
+		h.setState(now, model.ResourceHealthHalfOpen, "probe")
+		h.probes++
+		return true, true
+	case model.ResourceHealthHalfOpen:
+		if h.probes >= h.settings.HalfOpenCallsCount() {
+			// the probe calls without the result, e.g. the attempt did not call
+			if now.Sub(h.changedAt) < time.Second*time.Duration(h.settings.OpenTimeoutSec()) {
+				return false, false
+			}
+			h.changedAt = now
+			h.probes = 0
+		}
+		h.probes++
+		return true, false
+	default:
+		return true, false
+	}
+}
+
+// report adds the result of the call, returns true when the state changed
+func (h *resourceHealth) report(now time.Time, s healthSample) bool {
+	h.window.add(s)
+
+	if h.state == model.ResourceHealthHalfOpen {
+		if s.failure {
+			h.setState(now, model.ResourceHealthOpen, "probe failed")
+			return true
+		}
+		h.probesOk++
+		if h.probesOk >= h.settings.HalfOpenCallsCount() {
+			h.window.reset()
+			h.setState(now, model.ResourceHealthClosed, "probe successful")
+			return true
+		}
+		return false
+	}
+
+	if h.state == model.ResourceHealthOpen {
+		return false
+	}
+
+	state, cause := h.evaluate()
+	if state == h.state {
+		return false
+	}
+
+	h.setState(now, state, cause)
+	return true
+}
+
+func (h *resourceHealth) evaluate() (string, string) {
+	st := h.window.stats()
+	if st.Calls < h.settings.MinCallsCount() {
+		return model.ResourceHealthClosed, ""
+	}
+
+	if h.settings.MinAsr > 0 && st.Asr < h.settings.MinAsr {
+		return model.ResourceHealthOpen, fmt.Sprintf("asr %.2f", st.Asr)
+	}
+
+	if h.settings.MaxErrorRate > 0 && st.ErrorRate >= h.settings.MaxErrorRate {
+		return model.ResourceHealthOpen, fmt.Sprintf("error rate %.2f", st.ErrorRate)
+	}
+
+	if h.settings.DegradedAsr > 0 && st.Asr < h.settings.DegradedAsr {
+		return model.ResourceHealthDegraded, fmt.Sprintf("asr %.2f", st.Asr)
+	}
+
+	if h.settings.MaxSetupTime > 0 && st.AvgSetupMs > int64(h.settings.MaxSetupTime) {
+		return model.ResourceHealthDegraded, fmt.Sprintf("setup time %d", st.AvgSetupMs)
+	}
+
+	return model.ResourceHealthClosed, ""
+}
+
+// apply the state changed by the other node, the closed state starts the new window
+func (h *resourceHealth) apply(st *model.ResourceHealthState) bool {
+	changedAt := time.UnixMilli(st.ChangedAt)
+	if !changedAt.After(h.changedAt) {
+		return false
+	}
+
+	if st.State == model.ResourceHealthClosed {
+		h.window.reset()
+	}
+	h.setState(changedAt, st.State, st.Cause)
+
+	return true
+}
+
+func (h *resourceHealth) weight() int {
+	switch h.state {
+	case model.ResourceHealthDegraded:
+		return h.settings.Weight()
+	case model.ResourceHealthOpen:
+		return 0
+	case model.ResourceHealthHalfOpen:
+		return 1
+	default:
+		return 100
+	}
+}
+
+func (h *resourceHealth) health() model.ResourceHealth {
+	return model.ResourceHealth{
+		ResourceId:          h.id,
+		Name:                h.name,
+		GatewayId:           h.gatewayId,
+		DomainId:            h.domainId,
+		State:               h.state,
+		Weight:              h.weight(),
+		Cause:               h.cause,
+		ChangedAt:           h.changedAt.UnixMilli(),
+		ResourceHealthStats: h.window.stats(),
+	}
+}
+
+type gatewayHealth struct {
+	domainId int64
+	window   *healthWindow
+}
+
+// ResourceHealthManager every node counts the statistics of its own calls, the changes of the breakers are stored
+// and applied by the other nodes on the polling, so the breaker opened or reset on one node is shared by all nodes,
+// the changes are published to the mq for the monitoring
+type ResourceHealthManager struct {
+	nodeId    string
+	store     store.Store
+	mq        mq.MQ
+	resources map[int]*resourceHealth
+	gateways  map[int64]*gatewayHealth
+	remote    map[int]*model.ResourceHealthState // the states of the resources that are not used on this node yet
+	polledAt  int64
+	watcher   *utils.Watcher
+	log       *wlog.Logger
+	sync.Mutex
+}
+
+func NewResourceHealthManager(nodeId string, s store.Store, m mq.MQ, log *wlog.Logger) *ResourceHealthManager {
+	return &ResourceHealthManager{
+		nodeId:    nodeId,
+		store:     s,
+		mq:        m,
+		resources: make(map[int]*resourceHealth),
+		gateways:  make(map[int64]*gatewayHealth),
+		remote:    make(map[int]*model.ResourceHealthState),
+		polledAt:  model.GetMillis(),
+		log: log.With(
+			wlog.Namespace("context"),
+			wlog.String("name", "resource_health"),
+		),
+	}
+}
+
+func (m *ResourceHealthManager) Start() {
+	m.watcher = utils.MakeWatcher("ResourceHealth", resourceHealthPollingInterval, m.poll)
+	go m.watcher.Start()
+}
+
+func (m *ResourceHealthManager) Stop() {
+	if m.watcher != nil {
+		m.watcher.Stop()
+	}
+}
+
+// poll applies the states of the breakers changed by the other nodes, the interval overlaps the previous polling
+// for the states stored late, the applied ones are skipped by the time of the change
+func (m *ResourceHealthManager) poll() {
+	list, err := m.store.OutboundResource().HealthStates(m.nodeId, m.polledAt-resourceHealthPollingInterval)
+	if err != nil {
+		m.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	m.Lock()
+	for _, st := range list {
+		if st.ChangedAt > m.polledAt {
+			m.polledAt = st.ChangedAt
+		}
+
+		h, ok := m.resources[st.ResourceId]
+		if !ok {
+			m.remote[st.ResourceId] = st
+			continue
+		}
+
+		previous := h.state
+		if h.apply(st) {
+			m.log.Debug(fmt.Sprintf("resource %s [%d] health %s -> %s from node %s: %s", h.name, h.id, previous, h.state, st.NodeId, h.cause),
+				wlog.Int("resource_id", h.id),
+			)
+		}
+	}
+	m.Unlock()
+}
+
+func (m *ResourceHealthManager) get(resource ResourceObject, now time.Time) *resourceHealth {
+	h, ok := m.resources[resource.Id()]
+	if !ok {
+		h = newResourceHealth(resource, now)
+		if st, ok := m.remote[resource.Id()]; ok {
+			h.apply(st)
+			delete(m.remote, resource.Id())
+		}
+		m.resources[resource.Id()] = h
+	} else {
+		h.setSettings(resource.Health())
+	}
+
+	return h
+}
+
+// Allow checks the breaker of the resource, the resource without the health settings is always allowed
+func (m *ResourceHealthManager) Allow(resource ResourceObject) bool {
+	if !resource.Health().Allow() {
+		return true
+	}
+
+	now := time.Now()
+	m.Lock()
+	h := m.get(resource, now)
+	previous := h.state
+	ok, changed := h.allow(now, rand.Intn(100))
+	var e model.ResourceHealth
+	if changed {
+		e = h.health()
+	}
+	m.Unlock()
+
+	if changed {
+		go m.storeState(e)
+		m.sendEvent(previous, e)
+	}
+
+	return ok
+}
+
+// Report the result of the call of the resource
+func (m *ResourceHealthManager) Report(resource ResourceObject, answered bool, setupMs int64, code string) {
+	settings := resource.Health()
+	s := healthSample{
+		answered: answered,
+		setupMs:  setupMs,
+		code:     code,
+	}
+	if !answered && code != "" {
+		if settings.Allow() && len(settings.ErrorCodes) > 0 {
+			s.failure = matchCodeMask(settings.ErrorCodes, code)
+		} else {
+			s.failure = resource.CheckCodeError(code)
+		}
+	}
+
+	now := time.Now()
+	m.Lock()
+	gw, ok := m.gateways[resource.Gateway().Id]
+	if !ok {
+		gw = &gatewayHealth{
+			domainId: resource.Gateway().DomainId,
+			window:   newHealthWindow(gatewayHealthWindow),
+		}
+		m.gateways[resource.Gateway().Id] = gw
+	}
+	gw.window.add(s)
+
+	if !settings.Allow() {
+		m.Unlock()
+		return
+	}
+
+	h := m.get(resource, now)
+	previous := h.state
+	changed := h.report(now, s)
+	var e model.ResourceHealth
+	if changed {
+		e = h.health()
+	}
+	m.Unlock()
+
+	if changed {
+		go m.storeState(e)
+		m.sendEvent(previous, e)
+	}
+}
+
+// Reset closes the breaker of the resource and clears the statistics, the other nodes apply the reset on the polling,
+// returns false when the resource of the domain is not found
+func (m *ResourceHealthManager) Reset(domainId int64, resourceId int) (bool, *model.AppError) {
+	now := time.Now()
+	ok, err := m.store.OutboundResource().SetHealthState(&model.ResourceHealthState{
+		ResourceId: resourceId,
+		DomainId:   domainId,
+		State:      model.ResourceHealthClosed,
+		Cause:      "reset",
+		NodeId:     m.nodeId,
+		ChangedAt:  now.UnixMilli(),
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+
+	m.Lock()
+	h, ok := m.resources[resourceId]
+	if !ok || h.domainId != domainId {
+		m.Unlock()
+		return true, nil
+	}
+	previous := h.state
+	h.window.reset()
+	h.setState(now, model.ResourceHealthClosed, "reset")
+	e := h.health()
+	m.Unlock()
+
+	if previous != e.State {
+		m.sendEvent(previous, e)
+	}
+
+	return true, nil
+}
+
+func (m *ResourceHealthManager) Resources(domainId int64) []model.ResourceHealth {
+	m.Lock()
+	res := make([]model.ResourceHealth, 0, len(m.resources))
+	for _, h := range m.resources {
+		if h.domainId == domainId {
+			res = append(res, h.health())
+		}
+	}
+	m.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ResourceId < res[j].ResourceId
+	})
+
+	return res
+}
+
+func (m *ResourceHealthManager) Gateways(domainId int64) []model.GatewayHealth {
+	m.Lock()
+	res := make([]model.GatewayHealth, 0, len(m.gateways))
+	for id, gw := range m.gateways {
+		if gw.domainId == domainId {
+			res = append(res, model.GatewayHealth{
+				GatewayId:           id,
+				DomainId:            gw.domainId,
+				ResourceHealthStats: gw.window.stats(),
+			})
+		}
+	}
+	m.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].GatewayId < res[j].GatewayId
+	})
+
+	return res
+}
+
+// storeState shares the state changed by this node with the other nodes
+func (m *ResourceHealthManager) storeState(health model.ResourceHealth) {
+	_, err := m.store.OutboundResource().SetHealthState(&model.ResourceHealthState{
+		ResourceId: health.ResourceId,
+		DomainId:   health.DomainId,
+		State:      health.State,
+		Cause:      health.Cause,
+		NodeId:     m.nodeId,
+		ChangedAt:  health.ChangedAt,
+	})
+	if err != nil {
+		m.log.Error(err.Error(),
+			wlog.Err(err),
+			wlog.Int("resource_id", health.ResourceId),
+		)
+	}
+}
+
+func (m *ResourceHealthManager) sendEvent(previous string, health model.ResourceHealth) {
+	m.log.Info(fmt.Sprintf("resource %s [%d] health %s -> %s: %s", health.Name, health.ResourceId, previous, health.State, health.Cause),
+		wlog.Int("resource_id", health.ResourceId),
+	)
+
+	e := &model.ResourceHealthEvent{
+		Name:     "resource_health_" + health.State,
+		Node:     m.nodeId,
+		Time:     model.GetMillis(),
+		Previous: previous,
+		Health:   health,
+	}
+
+	if err := m.mq.ResourceHealthEvent(health.DomainId, health.ResourceId, e); err != nil {
+		m.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"testing"
+	"time"
+)
+
+func TestResourceHealthBreaker(t *testing.T) {
+	t.Log("ResourceHealthBreaker")
+
+	settings := &model.ResourceHealthSettings{
+		Enabled:       true,
+		Window:        10,
+		MinCalls:      4,
+		MinAsr:        20,
+		DegradedAsr:   50,
+		OpenTimeout:   30,
+		HalfOpenCalls: 2,
+	}
+
+	now := time.Date(2025, 4, 1, 10, 30, 0, 0, time.UTC)
+	h := &resourceHealth{
+		settings:  settings,
+		window:    newHealthWindow(settings.WindowSize()),
+		state:     model.ResourceHealthClosed,
+		changedAt: now,
+	}
+
+	h.report(now, healthSample{answered: true})
+	h.report(now, healthSample{answered: true})
+	h.report(now, healthSample{code: "486"})
+	if h.state != model.ResourceHealthClosed {
+		t.Errorf("min calls: expected closed, got %s", h.state)
+	}
+
+	h.report(now, healthSample{code: "487"})
+	if h.state != model.ResourceHealthClosed {
+		t.Errorf("asr 50: expected closed, got %s", h.state)
+	}
+
+	h.report(now, healthSample{code: "503", failure: true})
+	if h.state != model.ResourceHealthDegraded {
+		t.Errorf("asr 40: expected degraded, got %s", h.state)
+	}
+
+	for i := 0; i < 6; i++ {
+		h.report(now, healthSample{code: "503", failure: true})
+	}
+	if h.state != model.ResourceHealthOpen {
+		t.Errorf("asr 10: expected open, got %s", h.state)
+	}
+
+	if st := h.window.stats(); st.Errors["503"] == 0 || st.Calls != 10 {
+		t.Errorf("stats: %+v", st)
+	}
+
+	if ok, _ := h.allow(now.Add(10*time.Second), 0); ok {
+		t.Errorf("open: expected deny")
+	}
+
+	ok, changed := h.allow(now.Add(31*time.Second), 0)
+	if !ok || !changed || h.state != model.ResourceHealthHalfOpen {
+		t.Errorf("timeout: expected half_open, got %s", h.state)
+	}
+	h.allow(now.Add(31*time.Second), 0)
+	if ok, _ = h.allow(now.Add(31*time.Second), 0); ok {
+		t.Errorf("half_open: expected deny after the probe calls")
+	}
+
+	h.report(now.Add(32*time.Second), healthSample{answered: true})
+	h.report(now.Add(33*time.Second), healthSample{answered: true})
+	if h.state != model.ResourceHealthClosed || h.window.count != 0 {
+		t.Errorf("probes: expected closed, got %s", h.state)
+	}
+}
+
+func TestResourceHealthProbeFailed(t *testing.T) {
+	t.Log("ResourceHealthProbeFailed")
+
+	settings := &model.ResourceHealthSettings{
+		Enabled: true,
+	}
+
+	now := time.Date(2025, 4, 1, 10, 30, 0, 0, time.UTC)
+	h := &resourceHealth{
+		settings:  settings,
+		window:    newHealthWindow(settings.WindowSize()),
+		state:     model.ResourceHealthHalfOpen,
+		changedAt: now,
+	}
+
+	if !h.report(now, healthSample{code: "502", failure: true}) || h.state != model.ResourceHealthOpen {
+		t.Errorf("probe failed: expected open, got %s", h.state)
+	}
+}
+
+func TestResourceHealthApply(t *testing.T) {
+	t.Log("ResourceHealthApply")
+
+	settings := &model.ResourceHealthSettings{
+		Enabled: true,
+		Window:  10,
+	}
+
+	now := time.Date(2025, 4, 1, 10, 30, 0, 0, time.UTC)
+	h := &resourceHealth{
+		settings:  settings,
+		window:    newHealthWindow(settings.WindowSize()),
+		state:     model.ResourceHealthClosed,
+		changedAt: now,
+	}
+	h.window.add(healthSample{code: "502", failure: true})
+
+	if h.apply(&model.ResourceHealthState{State: model.ResourceHealthOpen, ChangedAt: now.Add(-time.Second).UnixMilli()}) {
+		t.Fatalf("the older state is applied")
+	}
+
+	if !h.apply(&model.ResourceHealthState{State: model.ResourceHealthOpen, Cause: "asr 10.00", ChangedAt: now.Add(time.Second).UnixMilli()}) ||
+		h.state != model.ResourceHealthOpen || h.window.count != 1 {
+		t.Fatalf("open from the other node: %s, window %d", h.state, h.window.count)
+	}
+
+	if !h.apply(&model.ResourceHealthState{State: model.ResourceHealthClosed, Cause: "reset", ChangedAt: now.Add(time.Minute).UnixMilli()}) ||
+		h.state != model.ResourceHealthClosed || h.window.count != 0 {
+		t.Errorf("reset from the other node: %s, window %d", h.state, h.window.count)
+	}
+}
@@ -0,0 +1,514 @@
+package queue
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const resourceCandidatesExpireSec = 1
+
+type resourceCandidate struct {
+	*model.ResourceCandidate
+	resource ResourceObject
+}
+
+type ResourceSelectRequest struct {
+	QueueId     int
+	Destination string
+	Candidates  []*resourceCandidate
+}
+
+// ResourceSelection the selected resource and the reason of the choice,
+// Display is set when the strategy chooses the caller id
+type ResourceSelection struct {
+	Candidate *resourceCandidate
+	Reason    string
+	Display   string
+}
+
+type ResourceSelector interface {
+	Name() string
+	Select(req *ResourceSelectRequest) *ResourceSelection
+}
+
+type ResourceSelectorManager struct {
+	selectors map[string]ResourceSelector
+	log       *wlog.Logger
+}
+
+func NewResourceSelectorManager(log *wlog.Logger) *ResourceSelectorManager {
+	m := &ResourceSelectorManager{
+		selectors: make(map[string]ResourceSelector),
+		log: log.With(
+			wlog.Namespace("context"),
+			wlog.String("name", "resource_selector"),
+		),
+	}
+
+	m.Register(newWeightedSelector())
+	m.Register(&leastCostSelector{})
+	m.Register(&loadSelector{})
+	m.Register(&geoSelector{})
+
+	return m
+}
+
+func (m *ResourceSelectorManager) Register(selector ResourceSelector) {
+	m.selectors[selector.Name()] = selector
+}
+
+func (m *ResourceSelectorManager) Get(name string) (ResourceSelector, bool) {
+	s, ok := m.selectors[name]
+	return s, ok
+}
+
+// weightedSelector smooth weighted round-robin per queue
+type weightedSelector struct {
+	current map[int]map[int64]int
+	sync.Mutex
+}
+
+func newWeightedSelector() *weightedSelector {
+	return &weightedSelector{
+		current: make(map[int]map[int64]int),
+	}
+}
+
+func (s *weightedSelector) Name() string {
+	return model.ResourceStrategyWeighted
+}
+
+func (s *weightedSelector) Select(req *ResourceSelectRequest) *ResourceSelection {
+	s.Lock()
+	defer s.Unlock()
+
+	current, ok := s.current[req.QueueId]
+	if !ok {
+		current = make(map[int64]int)
+		s.current[req.QueueId] = current
+	}
+
+	var best *resourceCandidate
+	total := 0
+	for _, c := range req.Candidates {
+		w := c.resource.Weight()
+		total += w
+		current[c.ResourceId] += w
+		if best == nil || current[c.ResourceId] > current[best.ResourceId] {
+			best = c
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+	current[best.ResourceId] -= total
+
+	return &ResourceSelection{
+		Candidate: best,
+		Reason:    fmt.Sprintf("weight %d of %d", best.resource.Weight(), total),
+	}
+}
+
+// leastCostSelector the lowest cost of the longest prefix of the rate table, resources without the rate are the last
+type leastCostSelector struct {
+}
+
+func (s *leastCostSelector) Name() string {
+	return model.ResourceStrategyLeastCost
+}
+
+func (s *leastCostSelector) Select(req *ResourceSelectRequest) *ResourceSelection {
+	var best *resourceCandidate
+	var bestRate *model.ResourceRate
+
+	destination := model.NormalizeNumber(req.Destination)
+	for _, c := range req.Candidates {
+		rate := destinationRate(c.resource.Rates(), destination)
+		if best == nil || rate != nil && (bestRate == nil || rate.Cost < bestRate.Cost) {
+			best = c
+			bestRate = rate
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	if bestRate == nil {
+		return &ResourceSelection{
+			Candidate: best,
+			Reason:    "no rate",
+		}
+	}
+
+	return &ResourceSelection{
+		Candidate: best,
+		Reason:    fmt.Sprintf("cost %v prefix %s", bestRate.Cost, bestRate.Prefix),
+	}
+}
+
+func destinationRate(rates []model.ResourceRate, destination string) *model.ResourceRate {
+	var res *model.ResourceRate
+	for i, r := range rates {
+		prefix := model.NormalizeNumber(r.Prefix)
+		if strings.HasPrefix(destination, prefix) && (res == nil || len(prefix) > len(model.NormalizeNumber(res.Prefix))) {
+			res = &rates[i]
+		}
+	}
+
+	return res
+}
+
+// loadSelector the lowest utilization of the limit of the calls and the rps
+type loadSelector struct {
+}
+
+func (s *loadSelector) Name() string {
+	return model.ResourceStrategyLoad
+}
+
+func (s *loadSelector) Select(req *ResourceSelectRequest) *ResourceSelection {
+	var best *resourceCandidate
+	var bestLoad float64
+
+	for _, c := range req.Candidates {
+		load := candidateLoad(c)
+		if best == nil || load < bestLoad {
+			best = c
+			bestLoad = load
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	return &ResourceSelection{
+		Candidate: best,
+		Reason:    fmt.Sprintf("load %.2f", bestLoad),
+	}
+}
+
+func candidateLoad(c *resourceCandidate) float64 {
+	var load float64
+	if c.Limit > 0 {
+		load = float64(c.Used) / float64(c.Limit)
+	}
+
+	if rps := c.resource.Rps(); rps > 0 {
+		if l := float64(c.resource.CurrentRps()) / float64(rps); l > load {
+			load = l
+		}
+	}
+
+	return load
+}
+
+// geoSelector the caller id with the longest common prefix with the destination
+type geoSelector struct {
+}
+
+func (s *geoSelector) Name() string {
+	return model.ResourceStrategyGeo
+}
+
+func (s *geoSelector) Select(req *ResourceSelectRequest) *ResourceSelection {
+	var best *resourceCandidate
+	var display string
+	bestLen := -1
+
+	destination := model.NormalizeNumber(req.Destination)
+	for _, c := range req.Candidates {
+		for _, d := range c.resource.DisplayNumbers() {
+			if l := commonPrefixLen(destination, model.NormalizeNumber(d)); l > bestLen {
+				best = c
+				display = d
+				bestLen = l
+			}
+		}
+	}
+
+	if best == nil {
+		if len(req.Candidates) == 0 {
+			return nil
+		}
+		return &ResourceSelection{
+			Candidate: req.Candidates[0],
+			Reason:    "no display numbers",
+		}
+	}
+
+	return &ResourceSelection{
+		Candidate: best,
+		Reason:    fmt.Sprintf("caller id %s prefix %d", display, bestLen),
+		Display:   display,
+	}
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// queueCandidates the candidates of the queue are read once per resourceCandidatesExpireSec,
+// the resources selected in this period are counted in the used calls
+type queueCandidates struct {
+	list     []*model.ResourceCandidate
+	patterns map[int64][]*regexp.Regexp
+	used     map[int64]int
+	sync.Mutex
+}
+
+func newQueueCandidates(list []*model.ResourceCandidate) *queueCandidates {
+	qc := &queueCandidates{
+		list:     list,
+		patterns: make(map[int64][]*regexp.Regexp),
+		used:     make(map[int64]int),
+	}
+
+	for _, c := range list {
+		if _, ok := qc.patterns[c.ResourceId]; ok {
+			continue
+		}
+		patterns := make([]*regexp.Regexp, 0, len(c.Patterns))
+		for _, p := range c.Patterns {
+			if re, err := compileDestinationPattern(p); err == nil {
+				patterns = append(patterns, re)
+			}
+		}
+		qc.patterns[c.ResourceId] = patterns
+	}
+
+	return qc
+}
+
+// compileDestinationPattern the "similar to" mask of the resource, x - any digit
+func compileDestinationPattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^(?:")
+	for _, r := range pattern {
+		switch r {
+		case 'x', 'X', '_':
+			b.WriteString(".")
+		case '%':
+			b.WriteString(".*")
+		case '+', '.', '\\', '^', '$':
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(")$")
+
+	return regexp.Compile(b.String())
+}
+
+// candidates of the communication type and the destination, current - the resource of the attempt, it is not counted in the used calls
+func (qc *queueCandidates) candidates(communicationId int, destination string, current *int64) []*model.ResourceCandidate {
+	qc.Lock()
+	defer qc.Unlock()
+
+	res := make([]*model.ResourceCandidate, 0, len(qc.list))
+	for _, v := range qc.list {
+		if v.CommunicationId != communicationId || !qc.match(v.ResourceId, destination) {
+			continue
+		}
+
+		c := *v
+		c.Used += qc.used[c.ResourceId]
+		if current != nil && *current == c.ResourceId && c.Used > 0 {
+			c.Used--
+		}
+		c.AllowCall = c.Limit-c.Used > 0
+		res = append(res, &c)
+	}
+
+	return res
+}
+
+func (qc *queueCandidates) match(resourceId int64, destination string) bool {
+	patterns := qc.patterns[resourceId]
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, re := range patterns {
+		if re.MatchString(destination) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (qc *queueCandidates) reserve(resourceId int64) {
+	qc.Lock()
+	qc.used[resourceId]++
+	qc.Unlock()
+}
+
+func (qm *Manager) queueCandidates(queueId int) (*queueCandidates, *model.AppError) {
+	if c, ok := qm.candidatesCache.Get(queueId); ok {
+		return c.(*queueCandidates), nil
+	}
+
+	list, err := qm.store.OutboundResource().QueueCandidates(queueId)
+	if err != nil {
+		return nil, err
+	}
+
+	qc := newQueueCandidates(list)
+	qm.candidatesCache.AddWithExpiresInSecs(queueId, qc, resourceCandidatesExpireSec)
+
+	return qc, nil
+}
+
+// selectAttemptResource chooses the resource of the attempt by the strategy of the queue or the resource group,
+// the resources over the limit of the calls are skipped, returns nil when the strategy is not configured
+// or there is no healthy candidate
+func (qm *Manager) selectAttemptResource(queue QueueObject, attempt *Attempt, skip []int, newCall bool) *model.AttemptFlipResource {
+	strategy := queue.ResourceStrategy()
+	if strategy == nil {
+		return nil
+	}
+
+	qc, err := qm.queueCandidates(queue.Id())
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return nil
+	}
+
+	candidates := qc.candidates(attempt.communication.Type.Id, attempt.Destination(), attempt.ResourceId())
+	list := make([]*resourceCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if containsInt(skip, int(c.ResourceId)) || !c.AllowCall {
+			continue
+		}
+
+		resource, err := qm.resourceManager.Get(c.ResourceId, c.ResourceUpdatedAt)
+		if err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			continue
+		}
+		list = append(list, &resourceCandidate{
+			ResourceCandidate: c,
+			resource:          resource,
+		})
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	name := strategy.Strategy
+	if name == model.ResourceStrategyGroup {
+		name = list[0].GroupStrategy
+	}
+
+	selector, ok := qm.resourceSelector.Get(name)
+	if !ok {
+		return nil
+	}
+
+	req := &ResourceSelectRequest{
+		QueueId:     queue.Id(),
+		Destination: attempt.Destination(),
+		Candidates:  list,
+	}
+
+	for len(req.Candidates) > 0 {
+		sel := selector.Select(req)
+		if sel == nil {
+			return nil
+		}
+
+		if !qm.resourceHealth.Allow(sel.Candidate.resource) {
+			attempt.Log(fmt.Sprintf("resource %s [%d] is unhealthy", sel.Candidate.resource.Name(), sel.Candidate.ResourceId))
+		} else if res := qm.setAttemptResource(attempt, selector.Name(), sel, newCall); res != nil {
+			qc.reserve(sel.Candidate.ResourceId)
+			return res
+		}
+
+		req.Candidates = removeCandidate(req.Candidates, sel.Candidate)
+	}
+
+	return nil
+}
+
+// setAttemptResource returns nil when the resource is over the limit of the calls
+func (qm *Manager) setAttemptResource(attempt *Attempt, strategy string, sel *ResourceSelection, newCall bool) *model.AttemptFlipResource {
+	res := &model.AttemptFlipResource{
+		ResourceId:        attempt.ResourceId(),
+		ResourceUpdatedAt: attempt.ResourceUpdatedAt(),
+		AllowCall:         model.NewBool(sel.Candidate.AllowCall),
+		CallId:            attempt.MemberCallId(),
+	}
+
+	if newCall || attempt.resource == nil || int64(attempt.resource.Id()) != sel.Candidate.ResourceId {
+		var err *model.AppError
+		res, err = qm.store.Member().SetAttemptResource(attempt.Id(), sel.Candidate.ResourceId, newCall)
+		if err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			return nil
+		}
+		if res.AllowCall == nil || !*res.AllowCall {
+			attempt.Log(fmt.Sprintf("resource %s [%d] is over the limit", sel.Candidate.resource.Name(), sel.Candidate.ResourceId))
+			return nil
+		}
+		attempt.FlipResource(res)
+	}
+
+	attempt.resource = sel.Candidate.resource
+	if sel.Display != "" {
+		attempt.communication.Display = model.NewString(sel.Display)
+	} else if newCall || attempt.communication.Display == nil {
+		attempt.communication.Display = model.NewString(attempt.resource.GetDisplay())
+	}
+
+	attempt.Log(fmt.Sprintf("resource %s [%d] selected by %s: %s", attempt.resource.Name(), attempt.resource.Id(), strategy, sel.Reason))
+	attempt.Journal(model.AttemptJournalResource, map[string]interface{}{
+		"resource_id": sel.Candidate.ResourceId,
+		"strategy":    strategy,
+		"reason":      sel.Reason,
+	})
+	attempt.AddVariables(map[string]string{
+		model.QueueResourceStrategyVariable: strategy,
+		model.QueueResourceReasonVariable:   sel.Reason,
+	})
+
+	return res
+}
+
+func removeCandidate(list []*resourceCandidate, c *resourceCandidate) []*resourceCandidate {
+	res := make([]*resourceCandidate, 0, len(list))
+	for _, v := range list {
+		if v != c {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func containsInt(list []int, v int) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
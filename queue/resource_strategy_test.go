@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"testing"
+)
+
+func testCandidates(resources ...*Resource) []*resourceCandidate {
+	res := make([]*resourceCandidate, 0, len(resources))
+	for _, r := range resources {
+		res = append(res, &resourceCandidate{
+			ResourceCandidate: &model.ResourceCandidate{ResourceId: int64(r.id)},
+			resource:          r,
+		})
+	}
+	return res
+}
+
+func TestWeightedSelector(t *testing.T) {
+	t.Log("WeightedSelector")
+
+	s := newWeightedSelector()
+	req := &ResourceSelectRequest{
+		QueueId:    1,
+		Candidates: testCandidates(&Resource{id: 1, weight: 3}, &Resource{id: 2, weight: 1}),
+	}
+
+	count := make(map[int64]int)
+	for i := 0; i < 8; i++ {
+		count[s.Select(req).Candidate.ResourceId]++
+	}
+
+	if count[1] != 6 || count[2] != 2 {
+		t.Errorf("weighted: expected 6/2, got %v", count)
+	}
+}
+
+func TestLeastCostSelector(t *testing.T) {
+	t.Log("LeastCostSelector")
+
+	s := &leastCostSelector{}
+	req := &ResourceSelectRequest{
+		Destination: "+380 44 123 4567",
+		Candidates: testCandidates(
+			&Resource{id: 1},
+			&Resource{id: 2, rates: []model.ResourceRate{{Prefix: "380", Cost: 0.5}, {Prefix: "38044", Cost: 0.1}}},
+			&Resource{id: 3, rates: []model.ResourceRate{{Prefix: "380", Cost: 0.2}}},
+		),
+	}
+
+	sel := s.Select(req)
+	if sel.Candidate.ResourceId != 2 || sel.Reason != "cost 0.1 prefix 38044" {
+		t.Errorf("least cost: got %d %s", sel.Candidate.ResourceId, sel.Reason)
+	}
+}
+
+func TestGeoSelector(t *testing.T) {
+	t.Log("GeoSelector")
+
+	s := &geoSelector{}
+	req := &ResourceSelectRequest{
+		Destination: "0044 20 7946 0000",
+		Candidates: testCandidates(
+			&Resource{id: 1, displayNumbers: []string{"+1415555000"}},
+			&Resource{id: 2, displayNumbers: []string{"+44161000000", "+44207000000"}},
+		),
+	}
+
+	sel := s.Select(req)
+	if sel.Candidate.ResourceId != 2 || sel.Display != "+44207000000" {
+		t.Errorf("geo: got %d %s", sel.Candidate.ResourceId, sel.Display)
+	}
+}
+
+func TestQueueCandidates(t *testing.T) {
+	t.Log("QueueCandidates")
+
+	qc := newQueueCandidates([]*model.ResourceCandidate{
+		{ResourceId: 1, CommunicationId: 1, Limit: 2, Used: 1, Patterns: model.StringArray{"+380xxxxxxxxx"}},
+		{ResourceId: 2, CommunicationId: 1, Limit: 1, Used: 1},
+		{ResourceId: 3, CommunicationId: 2, Limit: 10},
+	})
+
+	list := qc.candidates(1, "+380441234567", nil)
+	if len(list) != 2 || !list[0].AllowCall || list[1].AllowCall {
+		t.Fatalf("candidates: got %v", list)
+	}
+
+	if list = qc.candidates(1, "+14155552671", nil); len(list) != 1 || list[0].ResourceId != 2 {
+		t.Errorf("pattern: got %v", list)
+	}
+
+	if list = qc.candidates(1, "+14155552671", model.NewInt64(2)); !list[0].AllowCall {
+		t.Errorf("the resource of the attempt must not be counted")
+	}
+
+	qc.reserve(1)
+	if list = qc.candidates(1, "+380441234567", nil); list[0].AllowCall {
+		t.Errorf("reserved resource must be over the limit")
+	}
+}
+
+func TestCompileDestinationPattern(t *testing.T) {
+	t.Log("CompileDestinationPattern")
+
+	tests := []struct {
+		pattern, destination string
+		ok                   bool
+	}{
+		{"+380xxxxxxxxx", "+380441234567", true},
+		{"+380xxxxxxxxx", "380441234567", false},
+		{"380%", "380441234567", true},
+		{"(1|44)%", "44207946000", true},
+		{"1.2", "1x2", false},
+	}
+
+	for _, v := range tests {
+		re, err := compileDestinationPattern(v.pattern)
+		if err != nil {
+			t.Fatalf("%s: %s", v.pattern, err.Error())
+		}
+		if re.MatchString(v.destination) != v.ok {
+			t.Errorf("%s %s: expected %v", v.pattern, v.destination, v.ok)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/webitel/call_center/agent_manager"
+	"github.com/webitel/call_center/expression"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+const maxRoutingTimeout = 24 * time.Hour
+
+type queueRouting struct {
+	agentFilter   *expression.Program
+	priorityBoost *expression.Program
+	timeout       *expression.Program
+}
+
+func newQueueRouting(r *model.QueueRouting) (*queueRouting, *model.AppError) {
+	if r == nil {
+		return nil, nil
+	}
+
+	var err *model.AppError
+	routing := &queueRouting{}
+	if routing.agentFilter, err = compileRouting(model.QueueRoutingAgentFilter, r.AgentFilter); err != nil {
+		return nil, err
+	}
+	if routing.priorityBoost, err = compileRouting(model.QueueRoutingPriorityBoost, r.PriorityBoost); err != nil {
+		return nil, err
+	}
+	if routing.timeout, err = compileRouting(model.QueueRoutingTimeout, r.Timeout); err != nil {
+		return nil, err
+	}
+
+	return routing, nil
+}
+
+func compileRouting(name, src string) (*expression.Program, *model.AppError) {
+	if src == "" {
+		return nil, nil
+	}
+
+	p, err := expression.Compile(src)
+	if err != nil {
+		return nil, model.NewAppError("Queue.Routing", "queue.routing."+name+".invalid", nil,
+			fmt.Sprintf("routing.%s: %s", name, err.Error()), http.StatusBadRequest)
+	}
+
+	return p, nil
+}
+
+// routingEnv the variables of the attempt as is, the variables of the queue and the agent with the prefix "queue." and "agent."
+func routingEnv(queue *BaseQueue, attempt *Attempt, agent agent_manager.AgentObject) expression.Env {
+	env := expression.EnvFromStrings(attempt.ExportVariables())
+	for k, v := range queue.variables {
+		env["queue."+k] = v
+	}
+	env["queue.id"] = float64(queue.id)
+	env["queue.name"] = queue.name
+	env["queue.type"] = float64(queue.typeId)
+
+	env["attempt.id"] = float64(attempt.Id())
+	env["attempt.name"] = attempt.Name()
+	env["attempt.destination"] = attempt.Destination()
+	if attempt.member != nil && !attempt.member.CreatedAt.IsZero() {
+		env["attempt.wait"] = time.Since(attempt.member.CreatedAt).Seconds()
+	}
+
+	if agent != nil {
+		for k, v := range agent.Variables() {
+			env["agent."+k] = v
+		}
+		env["agent.id"] = float64(agent.Id())
+		env["agent.name"] = agent.Name()
+		env["agent.team_id"] = float64(agent.TeamId())
+	}
+
+	return env
+}
+
+// AllowAgent the agent filter of the routing, the agent is allowed when the filter is not set or failed
+func (queue *BaseQueue) AllowAgent(attempt *Attempt, agent agent_manager.AgentObject) bool {
+	if queue.routing == nil || queue.routing.agentFilter == nil {
+		return true
+	}
+
+	ok, err := queue.routing.agentFilter.Bool(routingEnv(queue, attempt, agent))
+	if err != nil {
+		attempt.log.Error(fmt.Sprintf("routing.agent_filter: %s", err.Error()),
+			wlog.Int("agent_id", agent.Id()),
+		)
+		return true
+	}
+
+	return ok
+}
+
+// PriorityBoost the boost of the weight of the attempt, zero when not set or failed
+func (queue *BaseQueue) PriorityBoost(attempt *Attempt) int {
+	if queue.routing == nil || queue.routing.priorityBoost == nil {
+		return 0
+	}
+
+	v, err := queue.routing.priorityBoost.Float(routingEnv(queue, attempt, nil))
+	if err != nil {
+		attempt.log.Error(fmt.Sprintf("routing.priority_boost: %s", err.Error()))
+		return 0
+	}
+
+	return int(v)
+}
+
+// RoutingTimeout the wait time of the attempt by the expression, def when not set, failed or not positive
+func (queue *BaseQueue) RoutingTimeout(attempt *Attempt, def time.Duration) time.Duration {
+	if queue.routing == nil || queue.routing.timeout == nil {
+		return def
+	}
+
+	v, err := queue.routing.timeout.Float(routingEnv(queue, attempt, nil))
+	if err != nil {
+		attempt.log.Error(fmt.Sprintf("routing.timeout: %s", err.Error()))
+		return def
+	}
+
+	if v <= 0 {
+		return def
+	}
+
+	t := time.Duration(v * float64(time.Second))
+	if t > maxRoutingTimeout {
+		t = maxRoutingTimeout
+	}
+
+	return t
+}
+
+// rejectAgent returns the agent filtered by the routing to waiting, the excluded agent is excluded before the release
+// so the distributor does not reserve it for the attempt again, the attempt waits for the next agent
+func (qm *Manager) rejectAgent(attempt *Attempt, agent agent_manager.AgentObject, exclude bool) {
+	if exclude {
+		if err := qm.store.Member().ExcludeAttemptAgent(attempt.Id(), agent.Id()); err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		}
+	}
+
+	if err := qm.store.Member().SetAttemptWaitingAgent(attempt.Id(), 0); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	attempt.Log(fmt.Sprintf("routing rejected agent \"%s\"", agent.Name()))
+	attempt.Journal(model.AttemptJournalRouting, map[string]interface{}{
+		"rejected_agent_id": agent.Id(),
+	})
+
+	e := NewWaitingChannelEvent(attempt.channel, agent.UserId(), model.NewInt64(attempt.Id()), model.GetMillis())
+	if err := qm.mq.AgentChannelEvent(attempt.channel, agent.DomainId(), attempt.QueueId(), agent.UserId(), e); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+// boostAttempt adds the priority boost of the routing and the vip priority to the weight of the inbound attempt
+func (qm *Manager) boostAttempt(queue QueueObject, attempt *Attempt) {
+	boost := queue.PriorityBoost(attempt) + queue.VipPriority(attempt)
+	if boost == 0 {
+		return
+	}
+
+	if err := qm.store.Member().AddAttemptWeight(attempt.Id(), boost); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	attempt.Journal(model.AttemptJournalRouting, map[string]interface{}{
+		"priority_boost": boost,
+	})
+}
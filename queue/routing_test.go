@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func TestQueueRoutingCompile(t *testing.T) {
+	t.Log("QueueRoutingCompile")
+
+	r, err := newQueueRouting(model.QueueRoutingFromBytes([]byte(`{"routing": {"timeout": "usr_vip ? 600 :"}}`)))
+	if err == nil || r != nil {
+		t.Fatalf("expected error, got %+v", r)
+	}
+	if err.Id != "queue.routing.timeout.invalid" || !strings.HasPrefix(err.DetailedError, "routing.timeout:") {
+		t.Errorf("error: got %s %s", err.Id, err.DetailedError)
+	}
+
+	if r, err = newQueueRouting(model.QueueRoutingFromBytes([]byte(`{"routing": {}}`))); err != nil || r != nil {
+		t.Errorf("empty routing: got %+v %v", r, err)
+	}
+}
+
+func TestQueueRoutingEval(t *testing.T) {
+	t.Log("QueueRoutingEval")
+
+	routing, err := newQueueRouting(&model.QueueRouting{
+		PriorityBoost: `usr_vip == "true" ? num(queue.vip_boost, 10) : 0`,
+		Timeout:       `queue.name == "support" && usr_vip == "true" ? 600 : -1`,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	queue := &BaseQueue{
+		id:        1,
+		name:      "support",
+		channel:   model.QueueChannelCall,
+		variables: map[string]string{"vip_boost": "50"},
+		routing:   routing,
+	}
+
+	attempt := NewAttempt(context.Background(), &model.MemberAttempt{
+		Id:        1,
+		QueueId:   1,
+		Variables: map[string]string{"vip": "true"},
+	}, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	attempt.channel = model.QueueChannelCall
+
+	if b := queue.PriorityBoost(attempt); b != 50 {
+		t.Errorf("boost: got %d", b)
+	}
+	if d := queue.RoutingTimeout(attempt, time.Minute); d != 10*time.Minute {
+		t.Errorf("timeout: got %s", d)
+	}
+
+	attempt.member.Variables["vip"] = "false"
+	if b := queue.PriorityBoost(attempt); b != 0 {
+		t.Errorf("boost: got %d", b)
+	}
+	if d := queue.RoutingTimeout(attempt, time.Minute); d != time.Minute {
+		t.Errorf("default timeout: got %s", d)
+	}
+	if !queue.AllowAgent(attempt, nil) {
+		t.Errorf("agent filter is not set")
+	}
+}
@@ -79,6 +79,9 @@ func (qm *Manager) attemptProcessingActionForm(attemptId int64, action string, f
 	}
 
 	if attempt.processingForm != nil && attempt.agent != nil {
+		if appErr := attempt.queue.ValidateForm(attempt, action, fields, false); appErr != nil {
+			return appErr
+		}
 		attempt.UpdateProcessingFields(fields)
 		_, err := attempt.processingForm.ActionForm(attempt.Context, action, fields)
 		if err != nil {
@@ -166,6 +169,13 @@ func (qm *Manager) DoDistributeSchema(queue *BaseQueue, att *Attempt) bool {
 		att.AddVariables(res.Variables)
 	}
 
+	att.Journal(model.AttemptJournalSchema, map[string]interface{}{
+		"type":      "distribute",
+		"schema_id": *queue.doSchema,
+		"job_id":    res.Id,
+		"confirm":   confirm,
+	})
+
 	return confirm
 }
 
@@ -239,6 +249,11 @@ func (qm *Manager) AfterDistributeSchema(att *Attempt) (*model.SchemaResult, boo
 	}
 
 	att.Log(fmt.Sprintf("AfterDistributeSchema [%d] job_id=%s duration=%s", att.Id(), res.Id, time.Since(st)))
+	att.Journal(model.AttemptJournalSchema, map[string]interface{}{
+		"type":      "result",
+		"schema_id": *att.queue.AfterSchemaId(),
+		"job_id":    res.Id,
+	})
 
 	switch v := res.Result.(type) {
 	case *flow.ResultAttemptResponse_Success_:
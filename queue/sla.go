@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/mq"
+	"github.com/webitel/call_center/store"
+	"github.com/webitel/wlog"
+)
+
+var slaWindows = []string{model.SlaWindow15m, model.SlaWindow1h, model.SlaWindowDay}
+
+// slaLevel percent of the calls answered within threshold, short abandoned calls may be excluded
+func slaLevel(settings *model.QueueSlaSettings, s model.QueueSla) float64 {
+	total := s.Offered
+	if settings.ExcludeShortAbandon {
+		total -= s.ShortAbandoned
+	}
+
+	if total <= 0 {
+		return 100
+	}
+
+	return float64(s.AnsweredInThreshold) * 100 / float64(total)
+}
+
+// slaList the windows of the queue in the order 15m, 1h, day with the level, the missing window is empty
+func slaList(settings *model.QueueSlaSettings, rows []*model.QueueSla) []model.QueueSla {
+	res := make([]model.QueueSla, 0, len(slaWindows))
+	for _, window := range slaWindows {
+		s := model.QueueSla{Window: window}
+		for _, r := range rows {
+			if r.Window == window {
+				s = *r
+				break
+			}
+		}
+		s.Level = slaLevel(settings, s)
+		res = append(res, s)
+	}
+
+	return res
+}
+
+func slaWindow(list []model.QueueSla, window string) model.QueueSla {
+	for _, s := range list {
+		if s.Window == window {
+			return s
+		}
+	}
+
+	return model.QueueSla{Window: window, Level: 100}
+}
+
+// SlaManager the windows are counted from the attempts of all nodes and the status of the queue is stored,
+// so the level event is sent once by the node that changed the status
+type SlaManager struct {
+	nodeId string
+	store  store.Store
+	mq     mq.MQ
+	log    *wlog.Logger
+}
+
+func NewSlaManager(nodeId string, s store.Store, m mq.MQ, log *wlog.Logger) *SlaManager {
+	return &SlaManager{
+		nodeId: nodeId,
+		store:  s,
+		mq:     m,
+		log: log.With(
+			wlog.Namespace("context"),
+			wlog.String("name", "sla_manager"),
+		),
+	}
+}
+
+// Leaving checks the level of the queue after the inbound attempt, when the level crosses warning or critical the event is sent
+func (s *SlaManager) Leaving(queue QueueObject) {
+	settings := queue.Sla()
+	if settings == nil {
+		return
+	}
+
+	list, err := s.QueuesSla([]QueueObject{queue})
+	if err != nil {
+		s.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	current := slaWindow(list[queue.Id()], settings.Window)
+	status := settings.Status(current.Level)
+
+	previous, err := s.store.Statistic().SetQueueSlaStatus(queue.Id(), status)
+	if err != nil {
+		s.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	} else if previous == nil {
+		return
+	}
+
+	e := &model.QueueSlaEvent{
+		QueueEvent: model.QueueEvent{
+			Name:    "queue_sla_" + status,
+			Node:    s.nodeId,
+			Domain:  queue.Domain(),
+			Time:    model.GetMillis(),
+			QueueId: int64(queue.Id()),
+		},
+		DomainId:  queue.DomainId(),
+		Status:    status,
+		Previous:  *previous,
+		Target:    settings.Target,
+		Threshold: settings.Threshold,
+		Sla:       list[queue.Id()],
+	}
+
+	s.log.Debug(fmt.Sprintf("queue %d sla %s -> %s (%.2f)", queue.Id(), *previous, status, current.Level))
+	if err = s.mq.QueueSlaEvent(queue.DomainId(), queue.Id(), e); err != nil {
+		s.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+// QueuesSla the windows of the queues with the sla settings by the queue id, one query for all queues
+func (s *SlaManager) QueuesSla(queues []QueueObject) (map[int][]model.QueueSla, *model.AppError) {
+	search := make([]*model.SearchQueueSla, 0, len(queues))
+	settings := make(map[int]*model.QueueSlaSettings)
+	for _, q := range queues {
+		if st := q.Sla(); st != nil {
+			settings[q.Id()] = st
+			search = append(search, &model.SearchQueueSla{
+				QueueId:      q.Id(),
+				Threshold:    st.Threshold,
+				ShortAbandon: st.ShortAbandon,
+			})
+		}
+	}
+
+	res := make(map[int][]model.QueueSla)
+	if len(search) == 0 {
+		return res, nil
+	}
+
+	rows, err := s.store.Statistic().QueuesSla(search)
+	if err != nil {
+		return nil, err
+	}
+
+	byQueue := make(map[int][]*model.QueueSla)
+	for _, r := range rows {
+		byQueue[r.QueueId] = append(byQueue[r.QueueId], r)
+	}
+
+	for id, st := range settings {
+		res[id] = slaList(st, byQueue[id])
+	}
+
+	return res, nil
+}
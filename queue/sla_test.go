@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"github.com/webitel/call_center/model"
+	"testing"
+)
+
+func TestSlaList(t *testing.T) {
+	t.Log("SlaList")
+
+	settings := &model.QueueSlaSettings{
+		Enabled:             true,
+		Target:              80,
+		Threshold:           20,
+		ShortAbandon:        5,
+		ExcludeShortAbandon: true,
+		Warning:             80,
+		Critical:            50,
+	}
+
+	list := slaList(settings, []*model.QueueSla{
+		{QueueId: 1, Window: model.SlaWindow1h, Offered: 5, AnsweredInThreshold: 2, ShortAbandoned: 1},
+		{QueueId: 1, Window: model.SlaWindow15m, Offered: 4, AnsweredInThreshold: 1, ShortAbandoned: 1, Abandoned: 1},
+	})
+
+	if len(list) != 3 || list[0].Window != model.SlaWindow15m || list[2].Window != model.SlaWindowDay {
+		t.Fatalf("windows: %+v", list)
+	}
+
+	m15 := slaWindow(list, model.SlaWindow15m)
+	if m15.Level < 33.3 || m15.Level > 33.4 {
+		t.Errorf("15m level: expected 33.3, got %.2f", m15.Level)
+	}
+	if s := settings.Status(m15.Level); s != model.SlaStatusCritical {
+		t.Errorf("15m status: expected critical, got %s", s)
+	}
+
+	if h1 := slaWindow(list, model.SlaWindow1h); h1.Level != 50 {
+		t.Errorf("1h level: expected 50, got %.2f", h1.Level)
+	}
+
+	if day := slaWindow(list, model.SlaWindowDay); day.Offered != 0 || day.Level != 100 {
+		t.Errorf("empty day window: %+v", day)
+	}
+}
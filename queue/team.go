@@ -202,11 +202,6 @@ func (tm *agentTeam) Bridged(attempt *Attempt, agent agent_manager.AgentObject)
 func (tm *agentTeam) SetWrap(queue QueueObject, attempt *Attempt, agent agent_manager.AgentObject, result string) {
 	var vars map[string]string = nil
 
-	t := int(tm.WrapUpTime())
-	if agent.IsOnDemand() {
-		t = 0
-	}
-
 	if queue.Endless() && result != AttemptResultTransfer {
 		result = AttemptResultEndless
 	}
@@ -219,6 +214,14 @@ func (tm *agentTeam) SetWrap(queue QueueObject, attempt *Attempt, agent agent_ma
 		vars = res.Variables
 	}
 
+	t := int(tm.WrapUpTime())
+	if sec, ok := queue.WrapUpTime(result); ok {
+		t = int(sec)
+	}
+	if agent.IsOnDemand() {
+		t = 0
+	}
+
 	if res, err := tm.teamManager.store.Member().SetAttemptResult(attempt.Id(), result,
 		model.ChannelStateWrapTime, t, vars, attempt.maxAttempts, attempt.waitBetween, attempt.perNumbers, attempt.description, attempt.stickyAgentId); err == nil {
 		if res.MemberStopCause != nil {
@@ -226,8 +229,9 @@ func (tm *agentTeam) SetWrap(queue QueueObject, attempt *Attempt, agent agent_ma
 		}
 
 		attempt.SetResult(result)
+		tm.endWrapUp(attempt, agent, result, t)
 
-		e := NewWrapTimeEventEvent(attempt.channel, model.NewInt64(attempt.Id()), agent.UserId(), res.Timestamp, res.Timestamp+(int64(tm.WrapUpTime()*1000)))
+		e := NewWrapTimeEventEvent(attempt.channel, model.NewInt64(attempt.Id()), agent.UserId(), res.Timestamp, res.Timestamp+(int64(t*1000)))
 		err = tm.teamManager.mq.AgentChannelEvent(attempt.channel, attempt.domainId, attempt.QueueId(), agent.UserId(), e)
 		if err != nil {
 			attempt.log.Error(err.Error(),
@@ -293,6 +297,11 @@ func (tm *agentTeam) Reporting(queue QueueObject, attempt *Attempt, agent agent_
 	}
 
 	attempt.SetState(model.MemberStateProcessing)
+	if err = tm.teamManager.store.Member().StartWrapUp(attempt.Id()); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
 
 	e := NewProcessingEventEvent(attempt, agent.UserId(), timestamp, timeoutSec, queue.ProcessingRenewalSec())
 	err = tm.teamManager.mq.AgentChannelEvent(attempt.channel, attempt.domainId, attempt.QueueId(), agent.UserId(), e)
@@ -386,6 +395,23 @@ func (tm *agentTeam) WaitingAgentAndWaitingAttempt(attempt *Attempt, agent agent
 	}
 }
 
+// endWrapUp stores the metric of the wrap time without the processing
+func (tm *agentTeam) endWrapUp(attempt *Attempt, agent agent_manager.AgentObject, result string, wrapTimeSec int) {
+	err := tm.teamManager.store.Member().EndWrapUp(&model.AttemptWrapUp{
+		AttemptId:   attempt.Id(),
+		DomainId:    attempt.domainId,
+		QueueId:     model.NewInt(attempt.QueueId()),
+		AgentId:     model.NewInt(agent.Id()),
+		Disposition: result,
+		WrapTimeSec: uint32(wrapTimeSec),
+	})
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
 func (tm *agentTeam) SetAgentMaxNoAnswer(agent agent_manager.AgentObject) {
 	if err := tm.teamManager.app.SetAgentBreakOut(agent); err != nil {
 		agent.Log().Error(fmt.Sprintf("agent \"%s\" change to [break_out] error %s", agent.Name(), err.Error()),
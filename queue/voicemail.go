@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/webitel/call_center/agent_manager"
+	"github.com/webitel/call_center/call_manager"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func (a *Attempt) VoicemailDrop() *model.QueueVoicemailDrop {
+	a.RLock()
+	defer a.RUnlock()
+	return a.voicemailDrop
+}
+
+func (a *Attempt) setVoicemailDrop(drop *model.QueueVoicemailDrop) {
+	a.Lock()
+	a.voicemailDrop = drop
+	a.Unlock()
+}
+
+// setVoicemailMachine the call is parked on the machine instead of the hangup, the message is left by the voicemailDrop
+// after the greeting of the machine
+func setVoicemailMachine(callRequest *model.CallRequest, drop *model.QueueVoicemailDrop) {
+	if !drop.Allow() {
+		return
+	}
+
+	for _, k := range []string{model.CALL_AMD_MACHINE_VARIABLE, model.CALL_AMD_NOT_SURE_VARIABLE} {
+		if callRequest.Variables[k] == amdMachineApplication {
+			callRequest.Variables[k] = "park"
+		}
+	}
+}
+
+// amdMachine the machine action of the queue after the detection, the message is left after the greeting
+func (queue *CallingQueue) amdMachine(attempt *Attempt, call call_manager.Call, drop *model.QueueVoicemailDrop) {
+	if !drop.Allow() {
+		return
+	}
+
+	queue.voicemailDrop(attempt, call, drop, "aleg", model.VoicemailDropAmd, true)
+}
+
+// waitVoicemailGreeting waits for the end of the greeting by the timeout, the beep is not reported by the media server,
+// false when the call is hung up
+func waitVoicemailGreeting(hangup <-chan struct{}, timeout time.Duration) bool {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-hangup:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// voicemailDrop plays the message after the greeting of the machine or right away when the agent drops it,
+// the call is hung up after the max duration of the message
+func (queue *CallingQueue) voicemailDrop(attempt *Attempt, call call_manager.Call, drop *model.QueueVoicemailDrop, leg, source string, waitGreeting bool) {
+	attempt.setVoicemailDrop(drop)
+	attempt.AddVariables(map[string]string{
+		model.QueueVoicemailDropVariable: source,
+	})
+
+	go func() {
+		var silence uint
+		if waitGreeting {
+			if !waitVoicemailGreeting(call.HangupChan(), drop.WaitGreeting()) {
+				return
+			}
+			silence = drop.SilenceMs()
+		}
+
+		if err := call.BroadcastPlaybackSilenceBeforeFile(queue.domainId, silence, drop.File, leg); err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+			printfIfErr(call.Hangup(model.CALL_HANGUP_NORMAL_UNSPECIFIED, false, nil))
+			return
+		}
+
+		attempt.Log(fmt.Sprintf("voicemail drop by %s, file %d", source, drop.File.Id))
+		attempt.Journal(model.AttemptJournalVoicemail, map[string]interface{}{
+			"source":  source,
+			"file_id": drop.File.Id,
+			"success": drop.Success,
+		})
+
+		select {
+		case <-call.HangupChan():
+		case <-time.After(drop.Duration()):
+			printfIfErr(call.Hangup(model.CALL_HANGUP_NORMAL_CLEARING, false, nil))
+		}
+	}()
+}
+
+// leavingVoicemail sets the voicemail result of the attempt, the agent of the attempt becomes waiting
+func (queue *CallingQueue) leavingVoicemail(attempt *Attempt, team *agentTeam, agent agent_manager.AgentObject, drop *model.QueueVoicemailDrop) {
+	res, err := queue.queueManager.SetAttemptVoicemail(attempt, drop)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	} else if team != nil && agent != nil {
+		e := NewWaitingChannelEvent(attempt.channel, agent.UserId(), model.NewInt64(attempt.Id()), res.Timestamp)
+		if err = team.teamManager.mq.AgentChannelEvent(attempt.channel, attempt.domainId, attempt.QueueId(), agent.UserId(), e); err != nil {
+			attempt.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		}
+	}
+
+	queue.queueManager.LeavingMember(attempt)
+}
+
+// SetAttemptVoicemail the success stops the member with the voicemail cause, otherwise the member is retried
+func (qm *Manager) SetAttemptVoicemail(attempt *Attempt, drop *model.QueueVoicemailDrop) (*model.MissedAgent, *model.AppError) {
+	maxAttempts, waitBetween, perNumbers := attempt.maxAttempts, attempt.waitBetween, attempt.perNumbers
+	if drop.Success {
+		maxAttempts, waitBetween, perNumbers = 1, 0, false
+	} else if drop.WaitBetweenRetries > 0 {
+		waitBetween = drop.WaitBetweenRetries
+	}
+
+	res, err := qm.store.Member().SetAttemptResult(attempt.Id(), model.MemberResultVoicemail, "", 0, nil,
+		maxAttempts, waitBetween, perNumbers, attempt.description, attempt.stickyAgentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.MemberStopCause != nil {
+		attempt.SetMemberStopCause(res.MemberStopCause)
+	}
+	attempt.SetResult(model.MemberResultVoicemail)
+
+	return res, nil
+}
+
+// DropVoicemail the agent leaves the message of the queue to the answering machine of the preview call
+func (qm *Manager) DropVoicemail(req *model.VoicemailDropRequest) *model.AppError {
+	if err := req.IsValid(); err != nil {
+		return err
+	}
+
+	attempt, ok := qm.GetAttempt(req.AttemptId)
+	if !ok || attempt.domainId != req.DomainId {
+		return model.NewAppError("DropVoicemail", "queue.manager.voicemail_drop.not_found", nil,
+			fmt.Sprintf("attempt %d not found", req.AttemptId), http.StatusNotFound)
+	}
+
+	queue, ok := attempt.queue.(*PreviewCallQueue)
+	if !ok {
+		return model.NewAppError("DropVoicemail", "queue.manager.voicemail_drop.queue", nil,
+			"voicemail drop is allowed for the preview queue", http.StatusBadRequest)
+	}
+
+	if !queue.VoicemailDrop.Allow() {
+		return model.NewAppError("DropVoicemail", "queue.manager.voicemail_drop.file", nil,
+			"voicemail drop file is not configured", http.StatusBadRequest)
+	}
+
+	if attempt.VoicemailDrop() != nil {
+		return model.NewAppError("DropVoicemail", "queue.manager.voicemail_drop.dropped", nil,
+			"voicemail already dropped", http.StatusBadRequest)
+	}
+
+	call, ok := attempt.memberChannel.(call_manager.Call)
+	if !ok || call.BridgeAt() == 0 || call.HangupAt() > 0 {
+		return model.NewAppError("DropVoicemail", "queue.manager.voicemail_drop.call", nil,
+			"member call is not bridged", http.StatusBadRequest)
+	}
+
+	queue.voicemailDrop(attempt, call, queue.VoicemailDrop, "bleg", model.VoicemailDropManual, false)
+
+	return nil
+}
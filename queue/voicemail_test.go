@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestWaitVoicemailGreeting(t *testing.T) {
+	t.Log("WaitVoicemailGreeting")
+
+	hangup := make(chan struct{})
+	if !waitVoicemailGreeting(hangup, time.Millisecond*10) {
+		t.Errorf("timeout: expected true")
+	}
+
+	close(hangup)
+	if waitVoicemailGreeting(hangup, time.Second) {
+		t.Errorf("hangup: expected false")
+	}
+}
+
+func TestSetVoicemailMachine(t *testing.T) {
+	t.Log("SetVoicemailMachine")
+
+	drop := &model.QueueVoicemailDrop{File: &model.RingtoneFile{Id: 1}}
+	req := &model.CallRequest{Variables: map[string]string{
+		model.CALL_AMD_MACHINE_VARIABLE: amdMachineApplication,
+	}}
+
+	setVoicemailMachine(req, drop)
+	if req.Variables[model.CALL_AMD_MACHINE_VARIABLE] != "park" {
+		t.Errorf("machine: got %s", req.Variables[model.CALL_AMD_MACHINE_VARIABLE])
+	}
+}
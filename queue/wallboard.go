@@ -0,0 +1,315 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/utils"
+	"github.com/webitel/wlog"
+)
+
+const (
+	wallboardPollingInterval   = 1000
+	wallboardDefaultInterval   = 10 * time.Second
+	wallboardSubscriptionQueue = 1
+)
+
+type WallboardSubscription struct {
+	domainId int64
+	queues   []QueueObject
+	queueIds []int
+	teamIds  []int
+	interval time.Duration
+	sentAt   time.Time
+	c        chan *model.WallboardSnapshot
+}
+
+func (s *WallboardSubscription) Snapshots() <-chan *model.WallboardSnapshot {
+	return s.c
+}
+
+// teams of the subscription and of the subscribed queues
+func (s *WallboardSubscription) teams() []int {
+	teams := append([]int{}, s.teamIds...)
+	for _, q := range s.queues {
+		if teamId := q.TeamId(); teamId != nil {
+			teams = append(teams, *teamId)
+		}
+	}
+
+	return teams
+}
+
+// due the snapshot is sent each interval or on the change of the queue or the team of the subscription
+func (s *WallboardSubscription) due(now time.Time, queues, teams map[int]struct{}) bool {
+	if now.Sub(s.sentAt) >= s.interval {
+		return true
+	}
+
+	for _, id := range s.queueIds {
+		if _, ok := queues[id]; ok {
+			return true
+		}
+	}
+
+	for _, id := range s.teams() {
+		if _, ok := teams[id]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Wallboard streams the state of the queues and the agents, the state is read from the database so all nodes
+// are counted, one read per domain is shared by the due subscribers of the tick,
+// the attempt and the agent events of this node only wake up the subscribers of the changed queue or team
+type Wallboard struct {
+	qm            *Manager
+	changedQueues sync.Map
+	changedTeams  sync.Map
+	subscribers   map[*WallboardSubscription]struct{}
+	watcher       *utils.Watcher
+	sync.Mutex
+}
+
+func NewWallboard(qm *Manager) *Wallboard {
+	return &Wallboard{
+		qm:          qm,
+		subscribers: make(map[*WallboardSubscription]struct{}),
+	}
+}
+
+func (w *Wallboard) Start() {
+	w.watcher = utils.MakeWatcher("Wallboard", wallboardPollingInterval, w.publish)
+	go w.watcher.Start()
+}
+
+func (w *Wallboard) Stop() {
+	if w.watcher != nil {
+		w.watcher.Stop()
+	}
+}
+
+// AttemptState calls from Attempt.SetState
+func (w *Wallboard) AttemptState(attempt *Attempt, state string) {
+	queue := attempt.queue
+	if queue == nil {
+		return
+	}
+
+	switch state {
+	case model.MemberStateWaitAgent, model.MemberStateBridged, HookLeaving:
+		w.changedQueues.Store(queue.Id(), struct{}{})
+	}
+}
+
+// AgentStatus calls from agent manager hook
+func (w *Wallboard) AgentStatus(agentId int, teamId int, status string) {
+	w.changedTeams.Store(teamId, struct{}{})
+}
+
+func (w *Wallboard) Subscribe(domainId int64, queueIds []int, teamIds []int, interval time.Duration) (*WallboardSubscription, *model.AppError) {
+	if len(queueIds) == 0 && len(teamIds) == 0 {
+		return nil, model.NewAppError("Wallboard.Subscribe", "queue.wallboard.subscribe.valid", nil,
+			"queue_ids or team_ids is required", http.StatusBadRequest)
+	}
+
+	if interval <= 0 {
+		interval = wallboardDefaultInterval
+	}
+
+	queues := make([]QueueObject, 0, len(queueIds))
+	for _, id := range queueIds {
+		queue, err := w.qm.GetQueue(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		if queue.DomainId() != domainId {
+			return nil, model.NewAppError("Wallboard.Subscribe", "queue.wallboard.subscribe.not_found", nil,
+				fmt.Sprintf("queue %d not found", id), http.StatusNotFound)
+		}
+		queues = append(queues, queue)
+	}
+
+	s := &WallboardSubscription{
+		domainId: domainId,
+		queues:   queues,
+		queueIds: queueIds,
+		teamIds:  teamIds,
+		interval: interval,
+		c:        make(chan *model.WallboardSnapshot, wallboardSubscriptionQueue),
+	}
+
+	w.Lock()
+	w.subscribers[s] = struct{}{}
+	w.Unlock()
+
+	return s, nil
+}
+
+func (w *Wallboard) Unsubscribe(s *WallboardSubscription) {
+	w.Lock()
+	delete(w.subscribers, s)
+	w.Unlock()
+}
+
+func drainChanged(m *sync.Map) map[int]struct{} {
+	res := make(map[int]struct{})
+	m.Range(func(key, _ interface{}) bool {
+		m.Delete(key)
+		res[key.(int)] = struct{}{}
+		return true
+	})
+
+	return res
+}
+
+func agentsByTeam(agents []*model.WallboardAgent) map[int]map[string]int {
+	res := make(map[int]map[string]int)
+	for _, a := range agents {
+		if _, ok := res[a.TeamId]; !ok {
+			res[a.TeamId] = make(map[string]int)
+		}
+		res[a.TeamId][a.Status]++
+	}
+
+	return res
+}
+
+func dayStart(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// wallboardData the state of the domain read once per tick for all the due subscribers of the domain
+type wallboardData struct {
+	queues map[int]*model.WallboardQueue
+	sla    map[int][]model.QueueSla
+	agents map[int]map[string]int
+}
+
+func (w *Wallboard) load(domainId int64, subscribers []*WallboardSubscription, now time.Time) (*wallboardData, *model.AppError) {
+	data := &wallboardData{
+		queues: make(map[int]*model.WallboardQueue),
+	}
+
+	queueIds := make([]int, 0, len(subscribers))
+	queues := make([]QueueObject, 0, len(subscribers))
+	teamIds := make([]int, 0, len(subscribers))
+	seenQueues := make(map[int]struct{})
+	seenTeams := make(map[int]struct{})
+	for _, s := range subscribers {
+		for _, q := range s.queues {
+			if _, ok := seenQueues[q.Id()]; !ok {
+				seenQueues[q.Id()] = struct{}{}
+				queueIds = append(queueIds, q.Id())
+				queues = append(queues, q)
+			}
+		}
+		for _, id := range s.teams() {
+			if _, ok := seenTeams[id]; !ok {
+				seenTeams[id] = struct{}{}
+				teamIds = append(teamIds, id)
+			}
+		}
+	}
+
+	if len(teamIds) > 0 {
+		list, err := w.qm.store.Team().AgentsStatus(domainId, teamIds)
+		if err != nil {
+			return nil, err
+		}
+		data.agents = agentsByTeam(list)
+	}
+
+	if len(queueIds) > 0 {
+		list, err := w.qm.store.Statistic().WallboardQueues(domainId, queueIds, dayStart(now).UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		for _, wq := range list {
+			data.queues[wq.QueueId] = wq
+		}
+
+		if data.sla, err = w.qm.slaManager.QueuesSla(queues); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// snapshot of the subscription from the data of the domain
+func (s *WallboardSubscription) snapshot(data *wallboardData, now time.Time) *model.WallboardSnapshot {
+	snapshot := &model.WallboardSnapshot{
+		Timestamp: now.UnixMilli(),
+		Queues:    make([]*model.WallboardQueue, 0, len(s.queueIds)),
+		Teams:     make([]*model.WallboardTeam, 0, len(s.teamIds)),
+	}
+
+	for _, q := range s.queues {
+		src, ok := data.queues[q.Id()]
+		if !ok {
+			continue
+		}
+		wq := *src
+		wq.Sla = data.sla[q.Id()]
+		if teamId := q.TeamId(); teamId != nil {
+			wq.Agents = data.agents[*teamId]
+		}
+		snapshot.Queues = append(snapshot.Queues, &wq)
+	}
+
+	for _, id := range s.teamIds {
+		a := data.agents[id]
+		if a == nil {
+			a = make(map[string]int)
+		}
+		snapshot.Teams = append(snapshot.Teams, &model.WallboardTeam{
+			TeamId: id,
+			Agents: a,
+		})
+	}
+
+	return snapshot
+}
+
+// publish reads the state once per domain of the due subscribers
+func (w *Wallboard) publish() {
+	now := time.Now()
+	queues := drainChanged(&w.changedQueues)
+	teams := drainChanged(&w.changedTeams)
+
+	domains := make(map[int64][]*WallboardSubscription)
+	w.Lock()
+	for s := range w.subscribers {
+		if s.due(now, queues, teams) {
+			domains[s.domainId] = append(domains[s.domainId], s)
+		}
+	}
+	w.Unlock()
+
+	for domainId, subscribers := range domains {
+		data, err := w.load(domainId, subscribers, now)
+		if err != nil {
+			w.qm.log.Error(err.Error(),
+				wlog.Err(err),
+				wlog.Int64("domain_id", domainId),
+			)
+			continue
+		}
+
+		for _, s := range subscribers {
+			select {
+			case s.c <- s.snapshot(data, now):
+				s.sentAt = now
+			default:
+				// slow subscriber, try next tick
+			}
+		}
+	}
+}
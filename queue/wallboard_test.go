@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func TestWallboardChanged(t *testing.T) {
+	t.Log("WallboardChanged")
+
+	w := NewWallboard(nil)
+	queue := &InboundQueue{CallingQueue: CallingQueue{BaseQueue: BaseQueue{id: 1}}}
+	attempt := NewAttempt(context.Background(), &model.MemberAttempt{Id: 1, QueueId: 1}, wlog.NewLogger(&wlog.LoggerConfiguration{}))
+	attempt.queue = queue
+
+	w.AttemptState(attempt, model.MemberStateJoined)
+	if changed := drainChanged(&w.changedQueues); len(changed) != 0 {
+		t.Errorf("joined state changed the queue: %v", changed)
+	}
+
+	w.AttemptState(attempt, model.MemberStateWaitAgent)
+	w.AttemptState(attempt, HookLeaving)
+	w.AgentStatus(10, 2, model.AgentStatusOnline)
+
+	queues := drainChanged(&w.changedQueues)
+	if _, ok := queues[1]; !ok || len(queues) != 1 {
+		t.Errorf("queues: got %v", queues)
+	}
+	if queues = drainChanged(&w.changedQueues); len(queues) != 0 {
+		t.Errorf("changes are not drained: %v", queues)
+	}
+
+	teams := drainChanged(&w.changedTeams)
+	if _, ok := teams[2]; !ok {
+		t.Errorf("teams: got %v", teams)
+	}
+}
+
+func TestWallboardSubscriptionDue(t *testing.T) {
+	t.Log("WallboardSubscriptionDue")
+
+	teamId := 5
+	now := time.Now()
+	s := &WallboardSubscription{
+		queues:   []QueueObject{&InboundQueue{CallingQueue: CallingQueue{BaseQueue: BaseQueue{id: 1, teamId: &teamId}}}},
+		queueIds: []int{1},
+		teamIds:  []int{2},
+		interval: 10 * time.Second,
+		sentAt:   now,
+	}
+
+	cases := []struct {
+		now    time.Time
+		queues map[int]struct{}
+		teams  map[int]struct{}
+		due    bool
+	}{
+		{now, nil, nil, false},
+		{now.Add(10 * time.Second), nil, nil, true},
+		{now, map[int]struct{}{3: {}}, map[int]struct{}{3: {}}, false},
+		{now, map[int]struct{}{1: {}}, nil, true},
+		{now, nil, map[int]struct{}{2: {}}, true},
+		{now, nil, map[int]struct{}{5: {}}, true},
+	}
+
+	for i, c := range cases {
+		if due := s.due(c.now, c.queues, c.teams); due != c.due {
+			t.Errorf("case %d: expected %v, got %v", i, c.due, due)
+		}
+	}
+}
+
+func TestWallboardAgentsByTeam(t *testing.T) {
+	t.Log("WallboardAgentsByTeam")
+
+	res := agentsByTeam([]*model.WallboardAgent{
+		{Id: 1, TeamId: 1, Status: model.AgentStatusOnline},
+		{Id: 2, TeamId: 1, Status: model.AgentStatusOnline},
+		{Id: 3, TeamId: 1, Status: model.AgentStatusPause},
+		{Id: 4, TeamId: 2, Status: model.AgentStatusOffline},
+	})
+
+	if res[1][model.AgentStatusOnline] != 2 || res[1][model.AgentStatusPause] != 1 || res[2][model.AgentStatusOffline] != 1 {
+		t.Errorf("got %v", res)
+	}
+
+	if d := dayStart(time.Date(2025, 3, 4, 15, 16, 17, 0, time.UTC)); !d.Equal(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day start: got %s", d)
+	}
+}
+
+func TestWallboardSnapshot(t *testing.T) {
+	t.Log("WallboardSnapshot")
+
+	teamId := 5
+	now := time.Now()
+	data := &wallboardData{
+		queues: map[int]*model.WallboardQueue{
+			1: {QueueId: 1, Waiting: 2, Handled: 10},
+			2: {QueueId: 2, Waiting: 1},
+		},
+		sla: map[int][]model.QueueSla{
+			1: {{Window: model.SlaWindow15m, Offered: 4, Level: 75}},
+		},
+		agents: map[int]map[string]int{
+			5: {model.AgentStatusOnline: 3},
+		},
+	}
+
+	a := &WallboardSubscription{
+		queues:   []QueueObject{&InboundQueue{CallingQueue: CallingQueue{BaseQueue: BaseQueue{id: 1, teamId: &teamId}}}},
+		queueIds: []int{1},
+		teamIds:  []int{7},
+	}
+	b := &WallboardSubscription{
+		queues:   []QueueObject{&InboundQueue{CallingQueue: CallingQueue{BaseQueue: BaseQueue{id: 2}}}},
+		queueIds: []int{2},
+	}
+
+	sa := a.snapshot(data, now)
+	if len(sa.Queues) != 1 || sa.Queues[0].Handled != 10 || len(sa.Queues[0].Sla) != 1 || sa.Queues[0].Agents[model.AgentStatusOnline] != 3 {
+		t.Errorf("queue 1: got %+v", sa.Queues)
+	}
+	if len(sa.Teams) != 1 || sa.Teams[0].TeamId != 7 || sa.Teams[0].Agents == nil {
+		t.Errorf("teams: got %+v", sa.Teams)
+	}
+
+	sb := b.snapshot(data, now)
+	if len(sb.Queues) != 1 || sb.Queues[0].QueueId != 2 || sb.Queues[0].Sla != nil || len(sb.Teams) != 0 {
+		t.Errorf("queue 2: got %+v", sb.Queues)
+	}
+	if data.queues[1].Sla != nil {
+		t.Errorf("the shared data is changed by the snapshot")
+	}
+}
@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/wlog"
+)
+
+func (queue *BaseQueue) WrapUp() *model.QueueWrapUp {
+	return queue.wrapUp
+}
+
+// WrapUpTime the wrap up time of the disposition or the queue, false when the time of the team is used
+func (queue *BaseQueue) WrapUpTime(result string) (uint32, bool) {
+	if queue.dispositions != nil {
+		if d, _ := queue.dispositions.Get(result); d != nil && d.WrapUpTime != nil {
+			return *d.WrapUpTime, true
+		}
+	}
+
+	if queue.wrapUp != nil && queue.wrapUp.Time != nil {
+		return *queue.wrapUp.Time, true
+	}
+
+	return 0, false
+}
+
+// renewalWrapUp the error of the processing that was not renewed
+func renewalWrapUp(attemptId int64, data *model.RenewalProcessing) *model.AppError {
+	if !data.Processing {
+		return model.NewAppError("Queue.RenewalAttempt", "queue.wrap_up.renewal.not_processing", nil,
+			fmt.Sprintf("attempt_id=%d the attempt is not in the processing", attemptId), http.StatusNotFound)
+	}
+
+	if !data.Renewed {
+		return model.NewAppError("Queue.RenewalAttempt", "queue.wrap_up.renewal.limit", nil,
+			fmt.Sprintf("attempt_id=%d the limit of the processing renewals is reached", attemptId), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// setWrapUpTime overrides the wrap time of the team set on the reporting by the queue or the disposition
+func (qm *Manager) setWrapUpTime(attempt *Attempt, res *model.AttemptReportingResult, result string) {
+	if attempt == nil || attempt.queue == nil || res.AgentId == nil || res.Channel == nil {
+		return
+	}
+
+	sec, ok := attempt.queue.WrapUpTime(result)
+	if !ok {
+		return
+	}
+
+	timeout, err := qm.store.Agent().SetChannelWrapTime(*res.AgentId, *res.Channel, sec)
+	if err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+		return
+	}
+
+	if timeout != nil {
+		res.AgentTimeout = timeout
+	}
+}
+
+// endWrapUp stores the metric of the after-call work, the wrap time is counted by the store from the start of the processing
+func (qm *Manager) endWrapUp(attemptId int64, res *model.AttemptReportingResult, result *model.AttemptCallback) {
+	if res.DomainId == nil {
+		return
+	}
+
+	w := &model.AttemptWrapUp{
+		AttemptId:   attemptId,
+		DomainId:    *res.DomainId,
+		QueueId:     res.QueueId,
+		AgentId:     res.AgentId,
+		Disposition: result.Status,
+		Forced:      result.Variables[model.QueueWrapUpForcedVariable] == "true",
+	}
+
+	if err := qm.store.Member().EndWrapUp(w); err != nil {
+		qm.log.Error(err.Error(),
+			wlog.Err(err),
+			wlog.Int64("attempt_id", attemptId),
+		)
+	}
+}
+
+// storeProcessingFields saves the fields of the processing form that was not reported
+func (qm *Manager) storeProcessingFields(attempt *Attempt) {
+	fields := attempt.ProcessingFields()
+	if len(fields) == 0 {
+		return
+	}
+
+	if err := qm.store.Member().StoreFormFields(attempt.Id(), fields); err != nil {
+		attempt.log.Error(err.Error(),
+			wlog.Err(err),
+		)
+	}
+}
+
+// forceWrapUpEnd ends the timed out processing with the default disposition of the queue
+func (qm *Manager) forceWrapUpEnd(v *model.WrapUpExpired) {
+	result := model.AttemptCallback{
+		Status: v.Disposition,
+		Variables: map[string]string{
+			model.QueueWrapUpForcedVariable: "true",
+		},
+	}
+
+	if attempt, ok := qm.GetAttempt(v.AttemptId); ok {
+		qm.storeProcessingFields(attempt)
+		attempt.Log(fmt.Sprintf("processing timeout, default disposition \"%s\"", v.Disposition))
+		attempt.Journal(model.AttemptJournalWrapUp, map[string]interface{}{
+			"forced":      true,
+			"disposition": v.Disposition,
+		})
+	}
+
+	if err := qm.ReportingAttempt(v.AttemptId, result, true); err != nil {
+		qm.log.Error(err.Error(),
+			wlog.Err(err),
+			wlog.Int64("attempt_id", v.AttemptId),
+		)
+		// the attempt is returned to the timeout without the default disposition
+		if err = qm.store.Member().RestoreWrapUpTimeout(v.AttemptId); err != nil {
+			qm.log.Error(err.Error(),
+				wlog.Err(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func TestQueueWrapUpTime(t *testing.T) {
+	t.Log("QueueWrapUpTime")
+
+	queue := &BaseQueue{}
+	if _, ok := queue.WrapUpTime("success"); ok {
+		t.Errorf("wrap up is not set")
+	}
+
+	queue.wrapUp = model.QueueWrapUpFromBytes([]byte(`{"wrap_up": {"time": 30, "max_renewals": 2}}`))
+	queue.dispositions = model.QueueDispositionsFromBytes([]byte(`{"dispositions": {"items": [
+		{"code": "sale", "category": "success", "wrap_up_time": 120},
+		{"code": "busy", "category": "retry"}
+	]}}`))
+
+	tests := map[string]uint32{
+		"sale":    120,
+		"busy":    30,
+		"unknown": 30,
+	}
+	for result, exp := range tests {
+		if sec, ok := queue.WrapUpTime(result); !ok || sec != exp {
+			t.Errorf("%s: got %d %v, expected %d", result, sec, ok, exp)
+		}
+	}
+
+	queue.wrapUp.Time = nil
+	if _, ok := queue.WrapUpTime("busy"); ok {
+		t.Errorf("busy: expected the time of the team")
+	}
+}
+
+func TestRenewalWrapUp(t *testing.T) {
+	t.Log("RenewalWrapUp")
+
+	tests := []struct {
+		data *model.RenewalProcessing
+		id   string
+	}{
+		{&model.RenewalProcessing{}, "queue.wrap_up.renewal.not_processing"},
+		{&model.RenewalProcessing{Processing: true}, "queue.wrap_up.renewal.limit"},
+		{&model.RenewalProcessing{Processing: true, Renewed: true}, ""},
+	}
+	for _, tt := range tests {
+		err := renewalWrapUp(1, tt.data)
+		if tt.id == "" && err != nil {
+			t.Errorf("unexpected error %s", err.Id)
+		} else if tt.id != "" && (err == nil || err.Id != tt.id) {
+			t.Errorf("expected the error %s, got %v", tt.id, err)
+		}
+	}
+}
@@ -58,6 +58,18 @@ func (s *LayeredStore) Call() CallStore {
 	return s.DatabaseLayer.Call()
 }
 
+func (s *LayeredStore) ChatTranscript() ChatTranscriptStore {
+	return s.DatabaseLayer.ChatTranscript()
+}
+
+func (s *LayeredStore) Amd() AmdStore {
+	return s.DatabaseLayer.Amd()
+}
+
+func (s *LayeredStore) Lead() LeadStore {
+	return s.DatabaseLayer.Lead()
+}
+
 func (s *LayeredStore) Statistic() StatisticStore {
 	return s.DatabaseLayer.Statistic()
 }
@@ -427,3 +427,75 @@ where tr.id = :Id and u.dc = :DomainId and u.id = :UserId`, map[string]interface
 
 	return &tr, nil
 }
+
+// LastHandled the last agent who talked to the member or to the destination in any channel of the domain
+func (s *SqlAgentStore) LastHandled(domainId int64, memberId *int64, destination string, lookbackDays int) (int, *model.AppError) {
+	agentId, err := s.GetReplica().SelectInt(`select x.agent_id
+from (
+    (select h.agent_id, h.leaving_at
+     from call_center.cc_member_attempt_history h
+     where h.member_id = :MemberId::int8
+       and h.domain_id = :DomainId
+       and h.agent_id notnull
+       and h.bridged_at notnull
+       and h.leaving_at > now() - (:Days::int || ' day')::interval
+     order by h.leaving_at desc
+     limit 1)
+    union all
+    (select h.agent_id, h.leaving_at
+     from call_center.cc_member_attempt_history h
+     where h.domain_id = :DomainId
+       and (h.destination ->> 'destination') = nullif(:Destination::varchar, '')
+       and h.agent_id notnull
+       and h.bridged_at notnull
+       and h.leaving_at > now() - (:Days::int || ' day')::interval
+     order by h.leaving_at desc
+     limit 1)
+) x
+order by x.leaving_at desc
+limit 1`, map[string]interface{}{
+		"DomainId":    domainId,
+		"MemberId":    memberId,
+		"Destination": destination,
+		"Days":        lookbackDays,
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlAgentStore.LastHandled", "store.sql_agent.last_handled.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return int(agentId), nil
+}
+
+// SetChannelWrapTime overrides the wrap time of the team, the channel of the on demand agent or with the other active attempts is skipped
+func (s *SqlAgentStore) SetChannelWrapTime(agentId int, channel string, wrapTimeSec uint32) (*int64, *model.AppError) {
+	timeout, err := s.GetMaster().SelectNullInt(`update call_center.cc_agent_channel c
+set state = case when :Sec::int > 0 then 'wrap_time' else 'waiting' end,
+    timeout = case when :Sec::int > 0 then c.joined_at + (:Sec::int || ' sec')::interval end
+where c.agent_id = :AgentId::int
+    and c.channel = :Channel::varchar
+    and c.state in ('wrap_time', 'waiting')
+    and not exists(select 1 from call_center.cc_agent a where a.id = c.agent_id and a.on_demand)
+    and not exists(select 1
+                   from call_center.cc_member_attempt at
+                   where at.agent_id = c.agent_id
+                     and at.channel = c.channel
+                     and at.state != 'leaving')
+returning coalesce(call_center.cc_view_timestamp(c.timeout), 0)`, map[string]interface{}{
+		"AgentId": agentId,
+		"Channel": channel,
+		"Sec":     wrapTimeSec,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlAgentStore.SetChannelWrapTime", "store.sql_agent.set_channel_wrap_time.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	if !timeout.Valid {
+		return nil, nil
+	}
+
+	return &timeout.Int64, nil
+}
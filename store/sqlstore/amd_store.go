@@ -0,0 +1,121 @@
+package sqlstore
+
+import (
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/store"
+	"net/http"
+)
+
+type SqlAmdStore struct {
+	SqlStore
+}
+
+func NewSqlAmdStore(sqlStore SqlStore) store.AmdStore {
+	us := &SqlAmdStore{sqlStore}
+	return us
+}
+
+func (s SqlAmdStore) Save(o *model.AmdOutcome) *model.AppError {
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_amd_outcome (attempt_id, domain_id, queue_id, settings_version, settings,
+                                         ai, result, amd_result, amd_cause, ai_result, ai_error, human,
+                                         duration_ms, created_at)
+values (:AttemptId, :DomainId, :QueueId, :Version, :Settings::jsonb, :Ai, :Result, nullif(:AmdResult, ''), nullif(:AmdCause, ''),
+        nullif(:AiResult, ''), nullif(:AiError, ''), :Human, :Duration, to_timestamp(:CreatedAt::float8 / 1000))
+on conflict (attempt_id) do update set result      = excluded.result,
+                                       amd_result  = excluded.amd_result,
+                                       amd_cause   = excluded.amd_cause,
+                                       ai_result   = excluded.ai_result,
+                                       ai_error    = excluded.ai_error,
+                                       human       = excluded.human,
+                                       duration_ms = excluded.duration_ms`, map[string]interface{}{
+		"AttemptId": o.AttemptId,
+		"DomainId":  o.DomainId,
+		"QueueId":   o.QueueId,
+		"Version":   o.SettingsVersion,
+		"Settings":  string(o.Settings),
+		"Ai":        o.Ai,
+		"Result":    o.Result,
+		"AmdResult": o.AmdResult,
+		"AmdCause":  o.AmdCause,
+		"AiResult":  o.AiResult,
+		"AiError":   o.AiError,
+		"Human":     o.Human,
+		"Duration":  o.DurationMs,
+		"CreatedAt": o.CreatedAt,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlAmdStore.Save", "store.sql_amd.save.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s SqlAmdStore) Correct(c *model.AmdCorrection) *model.AppError {
+	res, err := s.GetMaster().Exec(`update call_center.cc_amd_outcome
+set correction = :Result,
+    corrected_by = :UserId,
+    corrected_at = now()
+where attempt_id = :AttemptId
+  and domain_id = :DomainId::int8`, map[string]interface{}{
+		"DomainId":  c.DomainId,
+		"AttemptId": c.AttemptId,
+		"Result":    c.Result,
+		"UserId":    c.UserId,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlAmdStore.Correct", "store.sql_amd.correct.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	if cnt, _ := res.RowsAffected(); cnt == 0 {
+		return model.NewAppError("SqlAmdStore.Correct", "store.sql_amd.correct.not_found", nil,
+			"not found amd outcome of the attempt", http.StatusNotFound)
+	}
+
+	return nil
+}
+
+func (s SqlAmdStore) Report(search *model.SearchAmdReport) ([]*model.AmdReport, *model.AppError) {
+	var res []*model.AmdReport
+	_, err := s.GetReplica().Select(&res, `select o.queue_id,
+       o.settings_version,
+       (array_agg(o.settings::text order by o.created_at desc))[1]                                  as settings,
+       count(*)                                                                                    as total,
+       count(*) filter ( where o.result = 'human' )                                                as human,
+       count(*) filter ( where o.result = 'machine' )                                              as machine,
+       count(*) filter ( where o.result = 'not_sure' )                                             as not_sure,
+       count(*) filter ( where o.correction notnull )                                              as corrected,
+       count(*) filter ( where not o.human and o.correction = 'human' )                            as false_positive,
+       count(*) filter ( where o.human and o.correction = 'machine' )                              as false_negative,
+       coalesce(round((count(*) filter ( where not o.human and o.correction = 'human' ))::numeric * 100 /
+                      nullif(count(*) filter ( where not o.human and o.correction notnull ), 0), 2), 0) as false_positive_rate,
+       coalesce(round((count(*) filter ( where o.human and o.correction = 'machine' ))::numeric * 100 /
+                      nullif(count(*) filter ( where o.human and o.correction notnull ), 0), 2), 0)     as false_negative_rate,
+       coalesce(avg(o.duration_ms) filter ( where o.duration_ms > 0 ), 0)::int8                    as avg_duration_ms,
+       call_center.cc_view_timestamp(min(o.created_at))                                            as first_at,
+       call_center.cc_view_timestamp(max(o.created_at))                                            as last_at
+from call_center.cc_amd_outcome o
+where o.domain_id = :DomainId::int8
+  and (:QueueId::int4 isnull or o.queue_id = :QueueId::int4)
+  and (:Version::varchar = '' or o.settings_version = :Version::varchar)
+  and (:Since::int8 = 0 or o.created_at >= to_timestamp(:Since::float8 / 1000))
+  and (:Until::int8 = 0 or o.created_at <= to_timestamp(:Until::float8 / 1000))
+group by o.queue_id, o.settings_version
+order by o.queue_id, max(o.created_at) desc`, map[string]interface{}{
+		"DomainId": search.DomainId,
+		"QueueId":  search.QueueId,
+		"Version":  search.Version,
+		"Since":    search.Since,
+		"Until":    search.Until,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlAmdStore.Report", "store.sql_amd.report.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"github.com/webitel/call_center/model"
+)
+
+const journalDefaultLimit = 50
+
+func (s *SqlMemberStore) SaveJournal(journal *model.AttemptJournal) *model.AppError {
+	events, _ := json.Marshal(journal.Events)
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_attempt_journal (attempt_id, domain_id, queue_id, member_id, channel,
+                                         result, joined_at, leaving_at, events)
+values (:AttemptId, :DomainId, :QueueId, :MemberId, :Channel, :Result,
+        to_timestamp(:JoinedAt::float8 / 1000), to_timestamp(:LeavingAt::float8 / 1000), :Events::jsonb)
+on conflict (attempt_id) do update
+    set result     = excluded.result,
+        leaving_at = coalesce(cc_attempt_journal.leaving_at, excluded.leaving_at),
+        events     = cc_attempt_journal.events || excluded.events`, map[string]interface{}{
+		"AttemptId": journal.AttemptId,
+		"DomainId":  journal.DomainId,
+		"QueueId":   journal.QueueId,
+		"MemberId":  journal.MemberId,
+		"Channel":   journal.Channel,
+		"Result":    journal.Result,
+		"JoinedAt":  journal.JoinedAt,
+		"LeavingAt": journal.LeavingAt,
+		"Events":    string(events),
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.SaveJournal", "store.sql_member.save_journal.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s *SqlMemberStore) SearchJournal(search *model.SearchAttemptJournal) ([]*model.AttemptJournal, *model.AppError) {
+	if err := search.IsValid(); err != nil {
+		return nil, err
+	}
+
+	limit := search.Limit
+	if limit <= 0 {
+		limit = journalDefaultLimit
+	}
+
+	var rows []struct {
+		model.AttemptJournal
+		EventsData []byte `db:"events_data"`
+	}
+
+	_, err := s.GetReplica().Select(&rows, `select j.attempt_id, j.domain_id, j.queue_id, j.member_id, j.channel,
+       coalesce(j.result, '') as result,
+       call_center.cc_view_timestamp(j.joined_at) as joined_at,
+       call_center.cc_view_timestamp(j.leaving_at) as leaving_at,
+       j.events as events_data
+from call_center.cc_attempt_journal j
+where j.domain_id = :DomainId
+  and (:AttemptId::int8 isnull or j.attempt_id = :AttemptId::int8)
+  and (:MemberId::int8 isnull or j.member_id = :MemberId::int8)
+order by j.joined_at desc
+limit :Limit`, map[string]interface{}{
+		"DomainId":  search.DomainId,
+		"AttemptId": search.AttemptId,
+		"MemberId":  search.MemberId,
+		"Limit":     limit,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.SearchJournal", "store.sql_member.search_journal.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	res := make([]*model.AttemptJournal, 0, len(rows))
+	for i := range rows {
+		j := rows[i].AttemptJournal
+		json.Unmarshal(rows[i].EventsData, &j.Events)
+		res = append(res, &j)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/store"
+	"net/http"
+)
+
+type SqlChatTranscriptStore struct {
+	SqlStore
+}
+
+func NewSqlChatTranscriptStore(sqlStore SqlStore) store.ChatTranscriptStore {
+	us := &SqlChatTranscriptStore{sqlStore}
+	return us
+}
+
+func (s SqlChatTranscriptStore) Save(messages []*model.ChatTranscriptMessage) *model.AppError {
+	data, _ := json.Marshal(messages)
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_chat_transcript (domain_id, attempt_id, queue_id, conversation_id,
+                                       channel_id, sender, user_id, type, text, created_at)
+select m.domain_id, m.attempt_id, m.queue_id, m.conversation_id, m.channel_id, m.sender, nullif(m.user_id, 0), m.type, m.text,
+       to_timestamp(m.created_at::float8 / 1000)
+from jsonb_to_recordset(:Messages::jsonb) as m(domain_id int8, attempt_id int8, queue_id int4, conversation_id varchar,
+                                               channel_id varchar, sender varchar, user_id int8, type varchar, text text,
+                                               created_at int8)`, map[string]interface{}{
+		"Messages": string(data),
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlChatTranscriptStore.Save", "store.sql_chat_transcript.save.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s SqlChatTranscriptStore) List(domainId int64, attemptId int64) ([]*model.ChatTranscriptMessage, *model.AppError) {
+	var res []*model.ChatTranscriptMessage
+	_, err := s.GetReplica().Select(&res, `select t.id, t.domain_id, t.attempt_id, t.queue_id, t.conversation_id, t.channel_id,
+       t.sender, coalesce(t.user_id, 0) as user_id, t.type, t.text, call_center.cc_view_timestamp(t.created_at) as created_at
+from call_center.cc_chat_transcript t
+where t.domain_id = :DomainId
+  and t.attempt_id = :AttemptId
+order by t.created_at, t.id`, map[string]interface{}{
+		"DomainId":  domainId,
+		"AttemptId": attemptId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlChatTranscriptStore.List", "store.sql_chat_transcript.list.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	if len(res) == 0 {
+		return nil, model.NewAppError("SqlChatTranscriptStore.List", "store.sql_chat_transcript.list.not_found", nil,
+			"not found transcript", http.StatusNotFound)
+	}
+
+	return res, nil
+}
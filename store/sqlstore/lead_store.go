@@ -0,0 +1,167 @@
+package sqlstore
+
+import (
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/webitel/call_center/model"
+	"github.com/webitel/call_center/store"
+)
+
+type SqlLeadStore struct {
+	SqlStore
+}
+
+func NewSqlLeadStore(sqlStore SqlStore) store.LeadStore {
+	us := &SqlLeadStore{sqlStore}
+	return us
+}
+
+// ClaimQueues the outbound queues with the lead strategy whose interval of the scoring has passed, the queue is claimed by one node
+func (s SqlLeadStore) ClaimQueues() ([]*model.LeadStrategyQueue, *model.AppError) {
+	var res []*model.LeadStrategyQueue
+	_, err := s.GetMaster().Select(&res, `with q as (
+    select q.id,
+           q.domain_id,
+           q.payload -> 'lead_strategy' as strategy,
+           coalesce(nullif((q.payload -> 'lead_strategy' ->> 'interval')::int, 0), 60) as interval_sec
+    from call_center.cc_queue q
+    where q.enabled
+      and q.type = any (:Types::int[])
+      and q.payload -> 'lead_strategy' notnull
+),
+claimed as (
+    insert into call_center.cc_queue_lead_scoring as ls (queue_id, scored_at)
+    select q.id, now()
+    from q
+    on conflict (queue_id) do update set scored_at = excluded.scored_at
+        where ls.scored_at < now() - ((select q.interval_sec from q where q.id = ls.queue_id) || ' sec')::interval
+    returning ls.queue_id
+)
+select q.id as queue_id, q.domain_id, q.strategy::text as strategy
+from q
+    inner join claimed c on c.queue_id = q.id`, map[string]interface{}{
+		"Types": pq.Array([]int{model.QueueTypePreviewCall, model.QueueTypeProgressiveCall, model.QueueTypePredictCall}),
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlLeadStore.ClaimQueues", "store.sql_lead.claim_queues.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// Members the waiting members of the queue, the members without the score and with the oldest score are the first,
+// so the queue larger than the limit is scored in turn
+func (s SqlLeadStore) Members(queueId int, limit int) ([]*model.LeadMember, *model.AppError) {
+	var res []*model.LeadMember
+	_, err := s.GetReplica().Select(&res, `select m.id,
+       m.bucket_id,
+       m.priority,
+       m.attempts,
+       m.name,
+       coalesce((select jsonb_object_agg(v.key, v.value) from jsonb_each_text(m.variables) v), '{}')::text as variables,
+       (extract(epoch from m.created_at) * 1000)::int8 as created_at,
+       m.last_hangup_at
+from call_center.cc_member m
+    left join call_center.cc_member_lead_score ls on ls.member_id = m.id and ls.queue_id = m.queue_id
+where m.queue_id = :QueueId
+  and m.stop_at isnull
+  and not exists(select 1 from call_center.cc_member_attempt a where a.member_id = m.id)
+order by ls.updated_at nulls first, m.id
+limit :Limit`, map[string]interface{}{
+		"QueueId": queueId,
+		"Limit":   limit,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlLeadStore.Members", "store.sql_lead.members.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// SetScores the distributor orders the members of the same priority by the priority of the score,
+// the position of the member in the order of the scorer, the priority of the member is not changed
+func (s SqlLeadStore) SetScores(queueId int, scores []*model.LeadScore) *model.AppError {
+	data, _ := json.Marshal(scores)
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_member_lead_score as ls (member_id, queue_id, base_priority, tier, score, priority, explanation, updated_at)
+select x.member_id, :QueueId, x.base_priority, x.tier, x.score, x.priority,
+       array(select jsonb_array_elements_text(x.explanation)), now()
+from jsonb_to_recordset(:Scores::jsonb) as x (member_id int8, base_priority int4, tier int2, score float8, priority int4, explanation jsonb)
+on conflict (member_id) do update set queue_id      = excluded.queue_id,
+                                      base_priority = excluded.base_priority,
+                                      tier          = excluded.tier,
+                                      score         = excluded.score,
+                                      priority      = excluded.priority,
+                                      explanation   = excluded.explanation,
+                                      updated_at    = excluded.updated_at`, map[string]interface{}{
+		"QueueId": queueId,
+		"Scores":  string(data),
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlLeadStore.SetScores", "store.sql_lead.set_scores.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// Recycle returns the stopped members with the disposition to the queue, the final disposition stops the member
+// with the code as the stop cause, the count of the recycles is in the member variables
+func (s SqlLeadStore) Recycle(queueId int, rule *model.LeadRecycle) (int64, *model.AppError) {
+	res, err := s.GetMaster().Exec(`update call_center.cc_member m
+set stop_at    = null,
+    stop_cause = null,
+    ready_at   = now(),
+    attempts   = 0,
+    variables  = coalesce(m.variables, '{}') ||
+                 jsonb_build_object(:Var::varchar, (coalesce((m.variables ->> :Var::varchar)::int, 0) + 1)::text)
+where m.queue_id = :QueueId
+  and m.stop_cause = :Disposition
+  and m.stop_at < now() - (:After::int || ' sec')::interval
+  and (:Max::int = 0 or coalesce((m.variables ->> :Var::varchar)::int, 0) < :Max::int)`, map[string]interface{}{
+		"QueueId":     queueId,
+		"Disposition": rule.Disposition,
+		"After":       rule.After,
+		"Max":         rule.MaxRecycles,
+		"Var":         model.LeadRecycledVariable,
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlLeadStore.Recycle", "store.sql_lead.recycle.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, _ := res.RowsAffected()
+	return cnt, nil
+}
+
+func (s SqlLeadStore) Score(domainId int64, memberId int64) (*model.LeadScore, *model.AppError) {
+	var res *model.LeadScore
+	err := s.GetReplica().SelectOne(&res, `select ls.member_id,
+       ls.queue_id,
+       ls.base_priority,
+       ls.tier,
+       ls.score,
+       ls.priority,
+       ls.explanation,
+       (extract(epoch from ls.updated_at) * 1000)::int8 as updated_at
+from call_center.cc_member_lead_score ls
+    inner join call_center.cc_member m on m.id = ls.member_id
+where ls.member_id = :MemberId
+  and m.domain_id = :DomainId`, map[string]interface{}{
+		"DomainId": domainId,
+		"MemberId": memberId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlLeadStore.Score", "store.sql_lead.score.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
@@ -2,6 +2,7 @@ package sqlstore
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -442,6 +443,41 @@ where x.last_state_change notnull `, map[string]interface{}{
 	return res, nil
 }
 
+// ExcludeAttemptAgent the distributor does not reserve the agent for the attempt again
+func (s *SqlMemberStore) ExcludeAttemptAgent(attemptId int64, agentId int) *model.AppError {
+	_, err := s.GetMaster().Exec(`update call_center.cc_member_attempt
+set excluded_agents = array_append(coalesce(excluded_agents, '{}'), :AgentId::int4)
+where id = :AttemptId
+  and not :AgentId::int4 = any(coalesce(excluded_agents, '{}'))`, map[string]interface{}{
+		"AttemptId": attemptId,
+		"AgentId":   agentId,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.ExcludeAttemptAgent", "store.sql_member.exclude_attempt_agent.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// SetAttemptAffinity the distributor reserves the agents by the affinity of the attempt
+func (s *SqlMemberStore) SetAttemptAffinity(attemptId int64, affinity []byte) *model.AppError {
+	_, err := s.GetMaster().Exec(`update call_center.cc_member_attempt
+set affinity = :Affinity::jsonb
+where id = :AttemptId`, map[string]interface{}{
+		"AttemptId": attemptId,
+		"Affinity":  string(affinity),
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.SetAttemptAffinity", "store.sql_member.set_attempt_affinity.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
 func (s *SqlMemberStore) SetAttemptWaitingAgent(attemptId int64, agentHoldSec int) *model.AppError {
 	_, err := s.GetMaster().SelectNullInt(`select 1 as ok
 from call_center.cc_attempt_waiting_agent(:AttemptId, :AgentHoldSec)
@@ -459,6 +495,22 @@ where x.last_state_change notnull `, map[string]interface{}{
 	return nil
 }
 
+func (s *SqlMemberStore) AddAttemptWeight(attemptId int64, weight int) *model.AppError {
+	_, err := s.GetMaster().Exec(`update call_center.cc_member_attempt
+set weight = coalesce(weight, 0) + :Weight
+where id = :AttemptId`, map[string]interface{}{
+		"AttemptId": attemptId,
+		"Weight":    weight,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.AddAttemptWeight", "store.sql_member.add_attempt_weight.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
 func (s *SqlMemberStore) SetAttemptReporting(attemptId int64, deadlineSec uint32) (int64, *model.AppError) {
 	timestamp, err := s.GetMaster().SelectInt(`with att as (
     update call_center.cc_member_attempt
@@ -488,28 +540,53 @@ returning call_center.cc_view_timestamp(c.joined_at) as timestamp`, map[string]i
 	return timestamp, nil
 }
 
-// RenewalProcessing fixme queue_id
+// RenewalProcessing extends the processing of the attempt within the renewals limit of the queue,
+// the limit is checked and the renewal is counted in the same statement as the timeout is extended
 func (s *SqlMemberStore) RenewalProcessing(domainId, attId int64, renewalSec uint32) (*model.RenewalProcessing, *model.AppError) {
 	var res *model.RenewalProcessing
-	err := s.GetMaster().SelectOne(&res, `update call_center.cc_member_attempt a
- set timeout = now() + (:Renewal::int || ' sec')::interval
-from call_center.cc_member_attempt a2
-    inner join call_center.cc_agent ca on ca.id = a2.agent_id
-    left join call_center.cc_queue cq on cq.id = a2.queue_id
-where a2.id = :Id::int8
-	and a2.id = a.id
-	and (cq.id isnull or cq.processing_renewal_sec > 0)
-    and ca.domain_id = :DomainId::int8
-	and a2.state = 'processing'
-returning
-    a.id attempt_id,
-	coalesce(a.queue_id,0) as queue_id,
-    call_center.cc_view_timestamp(a.timeout) timeout,
+	err := s.GetMaster().SelectOne(&res, `with q as (
+    select a.id, a.domain_id, a.queue_id, a.agent_id,
+           coalesce((cq.payload->'wrap_up'->>'max_renewals')::int, 0) max_renewals,
+           coalesce(cq.processing_renewal_sec, (:Renewal::int / 2)::int) as renewal_sec,
+           (cq.id isnull or cq.processing_renewal_sec > 0) as renewal
+    from call_center.cc_member_attempt a
+        inner join call_center.cc_agent ca on ca.id = a.agent_id
+        left join call_center.cc_queue cq on cq.id = a.queue_id
+    where a.id = :Id::int8
+        and ca.domain_id = :DomainId::int8
+        and a.state = 'processing'
+),
+w as (
+    insert into call_center.cc_attempt_wrap_up as w (attempt_id, domain_id, queue_id, agent_id, processing_started_at, renewals)
+    select q.id, q.domain_id, q.queue_id, q.agent_id, now(), 1
+    from q
+    where q.renewal
+    on conflict (attempt_id) do update
+        set renewals = w.renewals + 1
+        where (select q.max_renewals from q) <= 0 or w.renewals < (select q.max_renewals from q)
+    returning w.attempt_id
+),
+u as (
+    update call_center.cc_member_attempt a
+    set timeout = now() + (:Renewal::int || ' sec')::interval
+    from w
+    where a.id = w.attempt_id
+    returning a.id, a.queue_id, a.timeout, a.channel, a.agent_id
+)
+select q.id notnull as processing,
+    u.id notnull as renewed,
+    coalesce(u.id, 0) attempt_id,
+    coalesce(u.queue_id, 0) as queue_id,
+    coalesce(call_center.cc_view_timestamp(u.timeout), 0) timeout,
     call_center.cc_view_timestamp(now()) "timestamp",
-	coalesce(cq.processing_renewal_sec, (:Renewal::int / 2)::int) as renewal_sec,
-    a.channel,
-    ca.user_id,
-    ca.domain_id`, map[string]interface{}{
+    coalesce(q.renewal_sec, 0) as renewal_sec,
+    coalesce(u.channel, '') channel,
+    coalesce(ca.user_id, 0) user_id,
+    coalesce(ca.domain_id, 0) domain_id
+from (select 1) x
+    left join q on true
+    left join u on true
+    left join call_center.cc_agent ca on ca.id = u.agent_id`, map[string]interface{}{
 		"DomainId": domainId,
 		"Id":       attId,
 		"Renewal":  renewalSec,
@@ -523,6 +600,99 @@ returning
 	return res, nil
 }
 
+func (s *SqlMemberStore) StartWrapUp(attemptId int64) *model.AppError {
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_attempt_wrap_up (attempt_id, domain_id, queue_id, agent_id, processing_started_at)
+select a.id, a.domain_id, a.queue_id, a.agent_id, now()
+from call_center.cc_member_attempt a
+where a.id = :Id
+on conflict (attempt_id) do update set processing_started_at = excluded.processing_started_at`, map[string]interface{}{
+		"Id": attemptId,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.StartWrapUp", "store.sql_member.start_wrap_up.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s *SqlMemberStore) EndWrapUp(wrapUp *model.AttemptWrapUp) *model.AppError {
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_attempt_wrap_up (attempt_id, domain_id, queue_id, agent_id, ended_at, 
+	disposition, forced, wrap_time_sec)
+values (:AttemptId, :DomainId, :QueueId, :AgentId, now(), :Disposition, :Forced, :WrapTimeSec)
+on conflict (attempt_id) do update
+    set ended_at = excluded.ended_at,
+        disposition = excluded.disposition,
+        forced = excluded.forced,
+        wrap_time_sec = coalesce(extract(epoch from excluded.ended_at - cc_attempt_wrap_up.processing_started_at)::int4,
+            excluded.wrap_time_sec)`, map[string]interface{}{
+		"AttemptId":   wrapUp.AttemptId,
+		"DomainId":    wrapUp.DomainId,
+		"QueueId":     wrapUp.QueueId,
+		"AgentId":     wrapUp.AgentId,
+		"Disposition": wrapUp.Disposition,
+		"Forced":      wrapUp.Forced,
+		"WrapTimeSec": wrapUp.WrapTimeSec,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.EndWrapUp", "store.sql_member.end_wrap_up.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", wrapUp.AttemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// ExpiredWrapUp takes the timed out processing of the node with the default disposition of the queue,
+// the timeout is cleared so that the attempt is skipped by GetTimeouts
+func (s *SqlMemberStore) ExpiredWrapUp(nodeId string) ([]*model.WrapUpExpired, *model.AppError) {
+	var res []*model.WrapUpExpired
+	_, err := s.GetMaster().Select(&res, `update call_center.cc_member_attempt a
+set timeout = null
+from call_center.cc_queue cq
+where cq.id = a.queue_id
+    and a.node_id = :NodeId
+    and a.state = 'processing'
+    and a.timeout < now()
+    and not a.schema_processing is true
+    and coalesce(cq.payload->'wrap_up'->>'default_disposition', '') != ''
+    and not exists(select 1 from call_center.cc_attempt_wrap_up w where w.attempt_id = a.id and w.ended_at notnull)
+returning a.id attempt_id, cq.payload->'wrap_up'->>'default_disposition' disposition`, map[string]interface{}{
+		"NodeId": nodeId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ExpiredWrapUp", "store.sql_member.expired_wrap_up.app_error", nil,
+			err.Error(), http.StatusInternalServerError)
+	}
+
+	return res, nil
+}
+
+// RestoreWrapUpTimeout the failed default disposition, the attempt is ended by the timeout of the processing
+func (s *SqlMemberStore) RestoreWrapUpTimeout(attemptId int64) *model.AppError {
+	_, err := s.GetMaster().Exec(`with w as (
+    insert into call_center.cc_attempt_wrap_up (attempt_id, domain_id, queue_id, agent_id, ended_at, forced)
+    select a.id, a.domain_id, a.queue_id, a.agent_id, now(), true
+    from call_center.cc_member_attempt a
+    where a.id = :Id
+    on conflict (attempt_id) do update set ended_at = excluded.ended_at, forced = true
+)
+update call_center.cc_member_attempt
+set timeout = now()
+where id = :Id and state = 'processing'`, map[string]interface{}{
+		"Id": attemptId,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.RestoreWrapUpTimeout", "store.sql_member.restore_wrap_up_timeout.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
 func (s *SqlMemberStore) SetAttemptMissed(id int64, agentHoldTime int, maxAttempts uint, waitBetween uint64, perNum bool) (*model.MissedAgent, *model.AppError) {
 	var missed *model.MissedAgent
 	err := s.GetMaster().SelectOne(&missed, `select call_center.cc_view_timestamp(x.last_state_change)::int8 as "timestamp", no_answers, member_stop_cause 
@@ -982,6 +1152,34 @@ where id = :Id`, map[string]interface{}{
 	return nil
 }
 
+// DeferAttempt the attempt leaves without the result, the member is ready after the delay and the attempts are not counted
+func (s *SqlMemberStore) DeferAttempt(attemptId int64, delaySec uint32) *model.AppError {
+	_, err := s.GetMaster().Exec(`with a as (
+    update call_center.cc_member_attempt
+    set leaving_at = now(),
+        last_state_change = now(),
+        result = :Result,
+        state = 'leaving'
+    where id = :Id::int8
+    returning member_id
+)
+update call_center.cc_member m
+set ready_at = now() + (:Sec::int || ' sec')::interval
+from a
+where m.id = a.member_id`, map[string]interface{}{
+		"Id":     attemptId,
+		"Sec":    delaySec,
+		"Result": model.MemberResultDeferred,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.DeferAttempt", "store.sql_member.defer_attempt.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
 func (s *SqlMemberStore) CleanAttempts(nodeId string) *model.AppError {
 	_, err := s.GetMaster().Exec(`with u as (
     update call_center.cc_member_attempt a
@@ -1031,6 +1229,126 @@ from call_center.cc_attempt_flip_next_resource(:AttemptId::int8, :SkippResources
 	return res, nil
 }
 
+// SetAttemptResource sets the resource selected by the strategy when it is under the limit of the calls,
+// newCall generates the new id of the member call
+func (s *SqlMemberStore) SetAttemptResource(attemptId int64, resourceId int64, newCall bool) (*model.AttemptFlipResource, *model.AppError) {
+	var res *model.AttemptFlipResource
+	err := s.GetMaster().SelectOne(&res, `with r as (
+    select r.id,
+           r.updated_at,
+           gw.updated_at as gateway_updated_at,
+           r."limit" > (select count(*)
+                        from call_center.cc_member_attempt c
+                        where c.resource_id = r.id
+                          and c.id != :AttemptId::int8
+                          and c.state not in ('leaving', 'processing')) as allow_call
+    from call_center.cc_outbound_resource r
+             inner join directory.sip_gateway gw on gw.id = r.gateway_id
+    where r.id = :ResourceId::int8
+),
+u as (
+    update call_center.cc_member_attempt a
+    set resource_id = r.id,
+        member_call_id = case when :NewCall::bool or a.member_call_id isnull then uuid_generate_v4()::varchar else a.member_call_id end
+    from r
+    where a.id = :AttemptId::int8
+      and r.allow_call
+    returning a.member_call_id
+)
+select r.id as resource_id,
+    r.updated_at as resource_updated_at,
+    call_center.cc_view_timestamp(r.gateway_updated_at) as gateway_updated_at,
+    r.allow_call,
+    coalesce((select u.member_call_id from u),
+             (select a.member_call_id from call_center.cc_member_attempt a where a.id = :AttemptId::int8)) as call_id
+from r`, map[string]interface{}{
+		"AttemptId":  attemptId,
+		"ResourceId": resourceId,
+		"NewCall":    newCall,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.SetAttemptResource", "store.sql_member.set_attempt_resource.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// LastDisplay the display of the last attempt of the member from the numbers
+func (s *SqlMemberStore) LastDisplay(memberId int64, numbers []string) (string, *model.AppError) {
+	display, err := s.GetReplica().SelectNullStr(`select h.display
+from call_center.cc_member_attempt_history h
+where h.member_id = :MemberId::int8
+  and h.display = any(:Numbers::varchar[])
+order by h.joined_at desc
+limit 1`, map[string]interface{}{
+		"MemberId": memberId,
+		"Numbers":  pq.Array(numbers),
+	})
+
+	if err != nil {
+		return "", model.NewAppError("SqlMemberStore.LastDisplay", "store.sql_member.last_display.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return display.String, nil
+}
+
+// HandoverAgent the agent of the user of the domain of the attempt becomes the agent of the attempt,
+// the channel of the previous agent goes to the wrap time
+func (s *SqlMemberStore) HandoverAgent(attemptId int64, userId int64, wrapTimeSec uint32) (*model.AgentsForAttempt, *model.AppError) {
+	var res *model.AgentsForAttempt
+	err := s.GetMaster().SelectOne(&res, `with prev as (
+    select a.id, a.agent_id, a.channel, a.domain_id
+    from call_center.cc_member_attempt a
+    where a.id = :Id::int8
+        and a.agent_id notnull
+        and a.leaving_at isnull
+    for update
+),
+ag as (
+    select ag.id, ag.team_id,
+           (ag.updated_at - extract(epoch from u.updated_at))::int8 as agent_updated_at,
+           t.updated_at as team_updated_at
+    from call_center.cc_agent ag
+        inner join prev on prev.domain_id = ag.domain_id and prev.agent_id <> ag.id
+        inner join directory.wbt_user u on u.id = ag.user_id
+        inner join call_center.cc_team t on t.id = ag.team_id
+    where ag.user_id = :UserId
+),
+att as (
+    update call_center.cc_member_attempt a
+    set agent_id = ag.id,
+        team_id = ag.team_id
+    from ag
+    where a.id = :Id::int8
+    returning a.id as attempt_id, ag.id as agent_id, ag.agent_updated_at, ag.team_id, ag.team_updated_at
+),
+ch as (
+    update call_center.cc_agent_channel c
+    set state = case when :Sec::int > 0 then 'wrap_time' else 'waiting' end,
+        joined_at = now(),
+        timeout = case when :Sec::int > 0 then now() + (:Sec::int || ' sec')::interval end
+    from prev
+    where (c.agent_id, c.channel) = (prev.agent_id, prev.channel)
+        and exists(select 1 from att)
+)
+select *
+from att`, map[string]interface{}{
+		"Id":     attemptId,
+		"UserId": userId,
+		"Sec":    wrapTimeSec,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.HandoverAgent", "store.sql_member.handover_agent.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
 func (s *SqlMemberStore) Intercept(ctx context.Context, domainId int64, attemptId int64, agentId int32) (int, *model.AppError) {
 	var queueId int
 	err := s.GetMaster().WithContext(ctx).SelectOne(&queueId, `update call_center.cc_member_attempt a
@@ -1056,6 +1374,366 @@ returning a.queue_id`, map[string]interface{}{
 	return queueId, nil
 }
 
+// ManualAttempts the waiting attempts of the manual distribution queues by the skills and the team of the agent,
+// the position is by the weight and the join time of the attempt
+func (s *SqlMemberStore) ManualAttempts(ctx context.Context, search *model.SearchManualAttempt) ([]*model.ManualAttempt, *model.AppError) {
+	var res []*model.ManualAttempt
+	_, err := s.GetReplica().WithContext(ctx).Select(&res, `with ag as (
+    select a.id, a.team_id
+    from call_center.cc_agent a
+    where a.id = :AgentId
+      and a.domain_id = :DomainId
+      and a.status = 'online'
+),
+att as (
+    select a.id,
+           a.queue_id,
+           a.bucket_id,
+           a.channel,
+           a.destination,
+           a.member_call_id,
+           a.joined_at,
+           a.sticky_agent_id,
+           row_number() over (partition by a.queue_id, a.bucket_id order by a.weight desc nulls last, a.joined_at) as position
+    from call_center.cc_member_attempt a
+    where a.domain_id = :DomainId
+      and a.state = 'wait_agent'
+      and a.agent_id isnull
+)
+select att.id as attempt_id,
+       call_center.cc_get_lookup(q.id::int8, q.name) as queue,
+       case when b.id notnull then call_center.cc_get_lookup(b.id, b.name::varchar) end as bucket,
+       att.channel,
+       coalesce(att.destination ->> 'destination', '') as destination,
+       coalesce(att.destination ->> 'name', '') as name,
+       coalesce(att.member_call_id, '') as session_id,
+       att.position,
+       extract(epoch from now() - att.joined_at)::int as wait,
+       case when coalesce((q.payload ->> 'max_wait_time')::int, 0) > 0
+           then (extract(epoch from now() - att.joined_at) / (q.payload ->> 'max_wait_time')::int * 100)::int
+           else 0 end as deadline,
+       (extract(epoch from att.joined_at) * 1000)::int8 as joined_at
+from att
+    inner join call_center.cc_queue q on q.id = att.queue_id
+    inner join ag on true
+    left join call_center.cc_bucket b on b.id = att.bucket_id
+where q.enabled
+  and case when jsonb_typeof(q.payload -> 'manual_distribution') = 'boolean' then (q.payload -> 'manual_distribution')::bool else false end
+  and (q.team_id isnull or q.team_id = ag.team_id)
+  and (att.sticky_agent_id isnull or att.sticky_agent_id = ag.id
+      or att.joined_at < now() - (coalesce((q.payload ->> 'sticky_agent_sec')::int, 0) || ' sec')::interval)
+  and exists(select 1
+             from call_center.cc_queue_skill qs
+                 inner join call_center.cc_skill_in_agent sa on sa.skill_id = qs.skill_id
+             where qs.queue_id = q.id
+               and qs.enabled
+               and sa.enabled
+               and sa.agent_id = ag.id
+               and sa.capacity between qs.min_capacity and qs.max_capacity
+               and (qs.bucket_ids isnull or att.bucket_id = any (qs.bucket_ids)))
+  and (:Channel::varchar = '' or att.channel = :Channel::varchar)
+  and (coalesce(array_length(:QueueIds::int[], 1), 0) = 0 or q.id = any (:QueueIds::int[]))
+  and (coalesce(array_length(:BucketIds::int[], 1), 0) = 0 or att.bucket_id = any (:BucketIds::int[]))
+  and (:Q::varchar = '' or att.destination ->> 'destination' ilike :Q::varchar or att.destination ->> 'name' ilike :Q::varchar)
+order by att.joined_at
+limit :Limit`, map[string]interface{}{
+		"DomainId":  search.DomainId,
+		"AgentId":   search.AgentId,
+		"Channel":   search.Channel,
+		"QueueIds":  pq.Array(search.QueueIds),
+		"BucketIds": pq.Array(search.BucketIds),
+		"Q":         search.QueryLike(),
+		"Limit":     search.GetLimit(),
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ManualAttempts", "store.sql_member.manual_attempts.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// ManualReserve sets the agent to the waiting attempt, the attempt is locked by the update so only one agent reserves it
+func (s *SqlMemberStore) ManualReserve(ctx context.Context, r *model.ManualReservation) (*model.ManualReserved, *model.AppError) {
+	var res *model.ManualReserved
+	err := s.GetMaster().WithContext(ctx).SelectOne(&res, `update call_center.cc_member_attempt a
+set agent_id = ag.id,
+    team_id  = ag.team_id
+from call_center.cc_agent ag,
+     call_center.cc_queue q
+where a.id = :AttemptId::int8
+  and a.domain_id = :DomainId
+  and a.agent_id isnull
+  and a.state = 'wait_agent'
+  and ag.id = :AgentId
+  and ag.domain_id = a.domain_id
+  and ag.status = 'online'
+  and q.id = a.queue_id
+  and case when jsonb_typeof(q.payload -> 'manual_distribution') = 'boolean' then (q.payload -> 'manual_distribution')::bool else false end
+  and (q.team_id isnull or q.team_id = ag.team_id)
+  and exists(select 1
+             from call_center.cc_queue_skill qs
+                 inner join call_center.cc_skill_in_agent sa on sa.skill_id = qs.skill_id
+             where qs.queue_id = q.id
+               and qs.enabled
+               and sa.enabled
+               and sa.agent_id = ag.id
+               and sa.capacity between qs.min_capacity and qs.max_capacity
+               and (qs.bucket_ids isnull or a.bucket_id = any (qs.bucket_ids)))
+  and (a.sticky_agent_id isnull or a.sticky_agent_id = ag.id
+      or a.joined_at < now() - (coalesce((q.payload ->> 'sticky_agent_sec')::int, 0) || ' sec')::interval)
+  and exists(select 1 from call_center.cc_agent_channel c where c.agent_id = ag.id and c.channel = a.channel and c.state = 'waiting')
+  and not exists(select 1 from call_center.cc_member_attempt x where x.agent_id = ag.id and x.state = 'wait_agent')
+returning a.id as attempt_id, a.queue_id, a.agent_id, a.channel, coalesce(a.node_id, '') as node_id`, map[string]interface{}{
+		"DomainId":  r.DomainId,
+		"AgentId":   r.AgentId,
+		"AttemptId": r.AttemptId,
+	})
+
+	if err == sql.ErrNoRows {
+		return nil, model.NewAppError("SqlMemberStore.ManualReserve", "store.sql_member.manual_reserve.conflict", nil,
+			fmt.Sprintf("attempt %d is reserved or not available for the agent %d", r.AttemptId, r.AgentId), http.StatusConflict)
+	} else if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ManualReserve", "store.sql_member.manual_reserve.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// ManualRelease removes the agent from the attempt not yet offered, the join time is kept so the attempt keeps the position
+func (s *SqlMemberStore) ManualRelease(ctx context.Context, r *model.ManualReservation) (bool, *model.AppError) {
+	res, err := s.GetMaster().WithContext(ctx).Exec(`update call_center.cc_member_attempt a
+set agent_id = null,
+    team_id  = null
+where a.id = :AttemptId::int8
+  and a.domain_id = :DomainId
+  and a.agent_id = :AgentId
+  and a.state = 'wait_agent'`, map[string]interface{}{
+		"DomainId":  r.DomainId,
+		"AgentId":   r.AgentId,
+		"AttemptId": r.AttemptId,
+	})
+
+	if err != nil {
+		return false, model.NewAppError("SqlMemberStore.ManualRelease", "store.sql_member.manual_release.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, _ := res.RowsAffected()
+	return cnt > 0, nil
+}
+
+// ManualReleaseOffer requests the node of the attempt to cancel the offer of the agent
+func (s *SqlMemberStore) ManualReleaseOffer(ctx context.Context, r *model.ManualReservation) (bool, *model.AppError) {
+	res, err := s.GetMaster().WithContext(ctx).Exec(`update call_center.cc_member_attempt a
+set manual_release = true
+where a.id = :AttemptId::int8
+  and a.domain_id = :DomainId
+  and a.agent_id = :AgentId
+  and a.channel = 'call'
+  and a.state in ('active', 'offering')
+  and a.bridged_at isnull`, map[string]interface{}{
+		"DomainId":  r.DomainId,
+		"AgentId":   r.AgentId,
+		"AttemptId": r.AttemptId,
+	})
+
+	if err != nil {
+		return false, model.NewAppError("SqlMemberStore.ManualReleaseOffer", "store.sql_member.manual_release_offer.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, _ := res.RowsAffected()
+	return cnt > 0, nil
+}
+
+// ManualReleasedByNode the offers of the node released by the agents
+func (s *SqlMemberStore) ManualReleasedByNode(node string) ([]*model.ManualReservation, *model.AppError) {
+	var res []*model.ManualReservation
+	_, err := s.GetMaster().Select(&res, `update call_center.cc_member_attempt a
+set manual_release = false
+where a.manual_release
+  and a.node_id = :Node
+returning a.id as attempt_id, a.domain_id, coalesce(a.agent_id, 0) as agent_id`, map[string]interface{}{
+		"Node": node,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ManualReleasedByNode", "store.sql_member.manual_released_by_node.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// CreateCallbackMember creates member in the queue with the join time of the attempt, so the member keeps the position
+func (s *SqlMemberStore) CreateCallbackMember(attemptId int64, queueId int, name, destination string, priority int, vars map[string]string) (int64, *model.AppError) {
+	var memberId int64
+	err := s.GetMaster().SelectOne(&memberId, `insert into call_center.cc_member (queue_id, domain_id, name, priority, ready_at, variables, communications)
+select q.id,
+       a.domain_id,
+       :Name::varchar,
+       :Priority::int,
+       a.joined_at,
+       coalesce(a.variables, '{}'::jsonb) || :Vars::jsonb,
+       jsonb_build_array(jsonb_build_object(
+               'destination', :Destination::varchar,
+               'priority', 0,
+               'type', jsonb_build_object('id', (select c.id
+                                                 from call_center.cc_communication c
+                                                 where c.domain_id = a.domain_id
+                                                   and c.channel = 'call'
+                                                 order by c."default" desc nulls last, c.id
+                                                 limit 1))
+           ))
+from call_center.cc_member_attempt a
+         inner join call_center.cc_queue q on q.id = :QueueId::int and q.domain_id = a.domain_id
+where a.id = :AttemptId::int8
+returning id`, map[string]interface{}{
+		"AttemptId":   attemptId,
+		"QueueId":     queueId,
+		"Name":        name,
+		"Priority":    priority,
+		"Destination": destination,
+		"Vars":        mapToJson(vars),
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlMemberStore.CreateCallbackMember", "store.sql_member.create_callback.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return memberId, nil
+}
+
+func (s *SqlMemberStore) WaitingPosition(attemptId int64) (int, *model.AppError) {
+	pos, err := s.GetReplica().SelectInt(`select count(*) + 1
+from call_center.cc_member_attempt a
+         inner join call_center.cc_member_attempt w on w.queue_id = a.queue_id
+    and w.id != a.id
+    and w.state = 'wait_agent'
+    and (coalesce(w.weight, 0) > coalesce(a.weight, 0)
+        or (coalesce(w.weight, 0) = coalesce(a.weight, 0) and w.joined_at < a.joined_at))
+where a.id = :AttemptId::int8`, map[string]interface{}{
+		"AttemptId": attemptId,
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlMemberStore.WaitingPosition", "store.sql_member.waiting_position.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return int(pos), nil
+}
+
+// WaitingAhead count of the waiting attempts of the queue that are ahead of the new attempt with the priority
+func (s *SqlMemberStore) WaitingAhead(queueId int, priority int) (int, *model.AppError) {
+	cnt, err := s.GetReplica().SelectInt(`select count(*)
+from call_center.cc_member_attempt w
+where w.queue_id = :QueueId::int
+    and w.state = 'wait_agent'
+    and coalesce(w.weight, 0) >= :Priority::int`, map[string]interface{}{
+		"QueueId":  queueId,
+		"Priority": priority,
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlMemberStore.WaitingAhead", "store.sql_member.waiting_ahead.app_error", nil,
+			fmt.Sprintf("QueueId=%v %s", queueId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return int(cnt), nil
+}
+
+// OverflowAttempt moves waiting attempt to the queue and/or changes the weight, joined_at is not changed
+func (s *SqlMemberStore) OverflowAttempt(attemptId int64, queueId *int, priority int) (*model.AttemptOverflow, *model.AppError) {
+	var res *model.AttemptOverflow
+	err := s.GetMaster().SelectOne(&res, `update call_center.cc_member_attempt a
+set queue_id = q.id,
+    weight = coalesce(a.weight, 0) + :Priority::int
+from call_center.cc_queue q
+where a.id = :Id::int8
+    and q.id = coalesce(:QueueId::int, a.queue_id)
+    and q.domain_id = a.domain_id
+    and a.agent_id isnull
+    and a.state = 'wait_agent'
+returning q.id as queue_id, q.updated_at as queue_updated_at, a.weight`, map[string]interface{}{
+		"Id":       attemptId,
+		"QueueId":  queueId,
+		"Priority": priority,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.OverflowAttempt", "store.sql_member.overflow.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// OverflowTeam moves the waiting attempt to the team and reserves the free agent of the team the same way as
+// the distributor does: the agent has the enabled skill of the queue with the capacity in the range of the queue skill
+// and the bucket of the attempt, is not excluded and passes the affinity of the attempt,
+// the agent is offered by the dialing loop (ReservedForAttemptByNode)
+func (s *SqlMemberStore) OverflowTeam(attemptId int64, teamId int) (*int32, *model.AppError) {
+	agentId, err := s.GetMaster().SelectNullInt(`with att as (
+    select a.id, a.queue_id, a.bucket_id, a.domain_id, a.excluded_agents, a.affinity
+    from call_center.cc_member_attempt a
+    where a.id = :Id::int8
+        and a.agent_id isnull
+        and a.state = 'wait_agent'
+),
+ag as (
+    select a.id, a.team_id
+    from att
+        inner join call_center.cc_agent a on a.team_id = :TeamId::int and a.domain_id = att.domain_id
+        inner join call_center.cc_agent_channel ch on ch.agent_id = a.id and ch.channel = 'call'
+        inner join lateral (
+            select max(qs.lvl) as lvl, max(sa.capacity) as capacity
+            from call_center.cc_skill_in_agent sa
+                inner join call_center.cc_queue_skill qs on qs.skill_id = sa.skill_id and qs.queue_id = att.queue_id
+            where sa.agent_id = a.id
+                and sa.enabled
+                and qs.enabled
+                and sa.capacity between qs.min_capacity and qs.max_capacity
+                and (qs.bucket_ids isnull or att.bucket_id = any (qs.bucket_ids))
+        ) sk on sk.lvl notnull
+    where a.status = 'online'
+        and ch.state = 'waiting'
+        and not a.id = any (coalesce(att.excluded_agents, '{}'))
+        and call_center.cc_attempt_affinity_allow(att.affinity, a.id, a.team_id)
+        and not exists(select 1 from call_center.cc_member_attempt x where x.agent_id = a.id)
+        and not exists(select 1 from call_center.cc_calls cc where cc.user_id = a.user_id and cc.hangup_at isnull)
+    order by sk.lvl desc, sk.capacity desc, ch.joined_at
+    limit 1
+    for update of a skip locked
+)
+update call_center.cc_member_attempt a
+set agent_id = ag.id,
+    team_id = ag.team_id
+from ag, att
+where a.id = att.id
+    and a.agent_id isnull
+    and a.state = 'wait_agent'
+returning ag.id`, map[string]interface{}{
+		"Id":     attemptId,
+		"TeamId": teamId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.OverflowTeam", "store.sql_member.overflow_team.app_error", nil,
+			fmt.Sprintf("AttemptId=%v %s", attemptId, err.Error()), extractCodeFromErr(err))
+	}
+
+	if !agentId.Valid {
+		return nil, nil
+	}
+
+	return model.NewInt32(int32(agentId.Int64)), nil
+}
+
 func (s *SqlMemberStore) addCommunications(memberId int64, comm []model.MemberCommunication) error {
 	data, err := json.Marshal(comm)
 	if err != nil {
@@ -1087,3 +1765,151 @@ from call_center.cc_manual_queue_list`)
 
 	return list, nil
 }
+
+// ImportExisting the destinations of the members of the queue
+func (s *SqlMemberStore) ImportExisting(domainId int64, queueId int, destinations []string) ([]string, *model.AppError) {
+	var res []string
+	_, err := s.GetReplica().Select(&res, `select distinct d
+from call_center.cc_member m,
+     unnest(m.search_destinations) d
+where m.domain_id = :DomainId::int8
+    and m.queue_id = :QueueId::int
+    and m.search_destinations && :Destinations::varchar[]
+    and d = any(:Destinations::varchar[])`, map[string]interface{}{
+		"DomainId":     domainId,
+		"QueueId":      queueId,
+		"Destinations": pq.Array(destinations),
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ImportExisting", "store.sql_member.import_existing.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// ImportDnc the destinations in the dnc list of the queue, numbers - the destinations in the form of model.NormalizeNumber,
+// the numbers of the list are compared in the same form
+func (s *SqlMemberStore) ImportDnc(queueId int, destinations, numbers []string) ([]string, *model.AppError) {
+	var res []string
+	_, err := s.GetReplica().Select(&res, `select distinct d.destination
+from unnest(:Destinations::varchar[], :Numbers::varchar[]) d (destination, number)
+    inner join call_center.cc_queue q on q.id = :QueueId::int
+    inner join call_center.cc_list_communications clc on clc.list_id = q.dnc_list_id
+        and ltrim(regexp_replace(clc.number, '[^0-9]', '', 'g'), '0') = d.number
+where clc.expire_at isnull or clc.expire_at > now()`, map[string]interface{}{
+		"QueueId":      queueId,
+		"Destinations": pq.Array(destinations),
+		"Numbers":      pq.Array(numbers),
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.ImportDnc", "store.sql_member.import_dnc.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// AddDnc the number in the dnc list of the queue, skipped when the queue has no dnc list
+func (s *SqlMemberStore) AddDnc(queueId int, number, description string) *model.AppError {
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_list_communications (list_id, number, description)
+select q.dnc_list_id, :Number::varchar, :Description::text
+from call_center.cc_queue q
+where q.id = :QueueId::int and q.dnc_list_id notnull
+on conflict (list_id, number) do nothing`, map[string]interface{}{
+		"QueueId":     queueId,
+		"Number":      number,
+		"Description": description,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.AddDnc", "store.sql_member.add_dnc.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// StopWithCause the member is stopped with the cause, overrides the cause of the reporting
+func (s *SqlMemberStore) StopWithCause(memberId int64, cause string) *model.AppError {
+	_, err := s.GetMaster().Exec(`update call_center.cc_member
+set stop_at = coalesce(stop_at, now()),
+    stop_cause = :Cause::varchar
+where id = :MemberId::int8`, map[string]interface{}{
+		"MemberId": memberId,
+		"Cause":    cause,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlMemberStore.StopWithCause", "store.sql_member.stop_with_cause.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+func (s *SqlMemberStore) Import(domainId int64, queueId int, rows []*model.MemberImportRow) (int64, *model.AppError) {
+	data, _ := json.Marshal(rows)
+	res, err := s.GetMaster().Exec(`insert into call_center.cc_member (domain_id, queue_id, name, priority, variables, communications, import_id)
+select q.domain_id, q.id, coalesce(x.name, ''), coalesce(x.priority, 0), coalesce(x.variables, '{}'), x.communications, x.import_id
+from jsonb_to_recordset(:Rows::jsonb) x (name varchar, priority int, variables jsonb, communications jsonb, import_id varchar)
+    inner join call_center.cc_queue q on q.id = :QueueId::int and q.domain_id = :DomainId::int8`, map[string]interface{}{
+		"DomainId": domainId,
+		"QueueId":  queueId,
+		"Rows":     data,
+	})
+
+	if err != nil {
+		return 0, model.NewAppError("SqlMemberStore.Import", "store.sql_member.import.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	cnt, _ := res.RowsAffected()
+
+	return cnt, nil
+}
+
+// Export the members of the queue with the last attempt, ordered by id after the afterId
+func (s *SqlMemberStore) Export(ctx context.Context, domainId int64, queueId int, afterId int64, limit int) ([]*model.MemberExport, *model.AppError) {
+	var res []*model.MemberExport
+	_, err := s.GetReplica().WithContext(ctx).Select(&res, `select m.id,
+       m.name,
+       m.import_id,
+       m.priority,
+       m.attempts,
+       m.stop_cause,
+       h.result last_result,
+       h.agent_id last_agent_id,
+       h.leaving_at last_call_at,
+       m.created_at,
+       m.stop_at,
+       coalesce(m.variables, '{}') variables,
+       m.communications
+from call_center.cc_member m
+    left join lateral (
+        select h.result, h.agent_id, h.leaving_at
+        from call_center.cc_member_attempt_history h
+        where h.member_id = m.id
+        order by h.leaving_at desc
+        limit 1
+    ) h on true
+where m.domain_id = :DomainId::int8
+    and m.queue_id = :QueueId::int
+    and m.id > :AfterId::int8
+order by m.id
+limit :Limit::int`, map[string]interface{}{
+		"DomainId": domainId,
+		"QueueId":  queueId,
+		"AfterId":  afterId,
+		"Limit":    limit,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlMemberStore.Export", "store.sql_member.export.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,371 @@
+--
+-- Name: cc_inbound_handle_stats; Type: MATERIALIZED VIEW; Schema: call_center; Owner: -
+--
+
+create materialized view if not exists call_center.cc_inbound_handle_stats as
+select h.queue_id,
+       count(*) filter ( where h.bridged_at notnull ) as handled,
+       coalesce(avg(extract(epoch from coalesce(h.reporting_at, h.leaving_at) - h.bridged_at))
+                filter ( where h.bridged_at notnull ), 0)::float8 as avg_handle_sec,
+       coalesce(avg(extract(epoch from coalesce(h.bridged_at, h.leaving_at) - h.joined_at)), 0)::float8 as avg_wait_sec
+from call_center.cc_member_attempt_history h
+where h.joined_at > now() - interval '1 hour'
+  and h.channel = 'call'
+  and h.member_id isnull
+group by h.queue_id
+with no data;
+
+create unique index if not exists cc_inbound_handle_stats_uidx on call_center.cc_inbound_handle_stats using btree(queue_id);
+
+refresh materialized view call_center.cc_inbound_handle_stats;
+
+--
+-- Name: cc_chat_transcript; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_chat_transcript
+(
+    id              bigserial primary key,
+    domain_id       int8                     not null,
+    attempt_id      int8                     not null,
+    queue_id        int4,
+    conversation_id varchar                  not null,
+    channel_id      varchar,
+    sender          varchar                  not null,
+    user_id         int8,
+    type            varchar,
+    text            text,
+    created_at      timestamp with time zone not null default now()
+);
+
+create index if not exists cc_chat_transcript_attempt_id_index
+    on call_center.cc_chat_transcript using btree (domain_id, attempt_id, created_at);
+
+--
+-- Name: cc_attempt_journal; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_attempt_journal
+(
+    attempt_id int8 primary key,
+    domain_id  int8                     not null,
+    queue_id   int4,
+    member_id  int8,
+    channel    varchar,
+    result     varchar,
+    joined_at  timestamp with time zone not null,
+    leaving_at timestamp with time zone,
+    events     jsonb                    not null default '[]'::jsonb
+);
+
+create index if not exists cc_attempt_journal_member_id_index
+    on call_center.cc_attempt_journal using btree (domain_id, member_id, joined_at desc) where member_id notnull;
+
+--
+-- Name: cc_display_number_usage; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_display_number_usage
+(
+    domain_id int8    not null,
+    number    varchar not null,
+    day       date    not null default current_date,
+    count     int4    not null default 0,
+    constraint cc_display_number_usage_pk primary key (domain_id, number, day)
+);
+
+--
+-- Name: cc_amd_outcome; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_amd_outcome
+(
+    attempt_id       int8 primary key,
+    domain_id        int8                     not null,
+    queue_id         int4                     not null,
+    settings_version varchar                  not null,
+    settings         jsonb,
+    ai               bool                     not null default false,
+    result           varchar                  not null,
+    amd_result       varchar,
+    amd_cause        varchar,
+    ai_result        varchar,
+    ai_error         varchar,
+    human            bool                     not null,
+    duration_ms      int8                     not null default 0,
+    created_at       timestamp with time zone not null default now(),
+    correction       varchar,
+    corrected_by     int8,
+    corrected_at     timestamp with time zone
+);
+
+create index if not exists cc_amd_outcome_domain_id_queue_id_index
+    on call_center.cc_amd_outcome using btree (domain_id, queue_id, settings_version, created_at desc);
+
+--
+-- Name: cc_queue_lead_scoring; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_queue_lead_scoring
+(
+    queue_id  int4 primary key,
+    scored_at timestamp with time zone not null default now()
+);
+
+--
+-- Name: cc_member_lead_score; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_member_lead_score
+(
+    member_id     int8 primary key,
+    queue_id      int4                     not null,
+    base_priority int4                     not null default 0,
+    tier          int2                     not null default 0,
+    score         float8                   not null default 0,
+    priority      int4                     not null default 0,
+    explanation   varchar[],
+    updated_at    timestamp with time zone not null default now()
+);
+
+create index if not exists cc_member_lead_score_queue_id_index
+    on call_center.cc_member_lead_score using btree (queue_id, updated_at);
+
+--
+-- Name: cc_distribute_members_list(integer, integer, smallint, boolean, smallint[], integer, integer, boolean, integer, integer); Type: FUNCTION; Schema: call_center; Owner: -
+--
+
+create or replace function call_center.cc_distribute_members_list(_queue_id integer, _bucket_id integer, strategy smallint, wait_between_retries_desc boolean DEFAULT false, l smallint[] DEFAULT '{}'::smallint[], lim integer DEFAULT 40, offs integer DEFAULT 0, sticky_agent boolean DEFAULT false, sticky_agent_sec integer DEFAULT 0, _agent_id integer DEFAULT 0) RETURNS SETOF bigint
+    LANGUAGE plpgsql STABLE
+AS $_$begin return query
+    select m.id::int8
+    from call_center.cc_member m
+        left join call_center.cc_member_lead_score ls on ls.member_id = m.id and ls.queue_id = m.queue_id
+    where m.queue_id = _queue_id
+      and m.stop_at isnull
+      and m.skill_id isnull
+      and case when _bucket_id isnull then m.bucket_id isnull else m.bucket_id = _bucket_id end
+      and (m.expire_at isnull or m.expire_at > now())
+      and (m.ready_at isnull or m.ready_at < now())
+      and (not sticky_agent or (m.agent_id isnull or m.agent_id = _agent_id))
+      and not m.search_destinations && array(select call_center.cc_call_active_numbers())
+      and m.id not in (select distinct a.member_id from call_center.cc_member_attempt a where a.member_id notnull)
+      and m.sys_offset_id = any($5::int2[])
+    order by m.bucket_id nulls last,
+             m.skill_id,
+             m.agent_id,
+             m.priority desc,
+             ls.priority desc nulls last,
+             case when coalesce(wait_between_retries_desc, false) then m.ready_at end desc nulls last ,
+             case when not coalesce(wait_between_retries_desc, false) then m.ready_at end asc nulls last ,
+
+             case when coalesce(strategy, 0) = 1 then m.id end desc ,
+             case when coalesce(strategy, 0) != 1 then m.id end asc
+    limit lim
+        offset offs
+--     for update of m skip locked
+;
+end
+$_$;
+
+--
+-- Name: cc_attempt_wrap_up; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_attempt_wrap_up
+(
+    attempt_id            int8 primary key,
+    domain_id             int8                     not null,
+    queue_id              int4,
+    agent_id              int4,
+    processing_started_at timestamp with time zone,
+    ended_at              timestamp with time zone,
+    renewals              int4                     not null default 0,
+    forced                bool                     not null default false,
+    disposition           varchar,
+    wrap_time_sec         int4                     not null default 0
+);
+
+create index if not exists cc_attempt_wrap_up_domain_id_queue_id_index
+    on call_center.cc_attempt_wrap_up using btree (domain_id, queue_id, ended_at desc);
+
+--
+-- Name: cc_member_attempt_history_queue_id_leaving_at_index; Type: INDEX; Schema: call_center; Owner: -
+--
+
+create index if not exists cc_member_attempt_history_queue_id_leaving_at_index
+    on call_center.cc_member_attempt_history using btree (queue_id, leaving_at) include (bridged_at);
+
+--
+-- Name: cc_inbound_chat_handle_stats; Type: MATERIALIZED VIEW; Schema: call_center; Owner: -
+--
+
+create materialized view if not exists call_center.cc_inbound_chat_handle_stats as
+select h.queue_id,
+       count(*) filter ( where h.bridged_at notnull ) as handled,
+       coalesce(avg(extract(epoch from coalesce(h.reporting_at, h.leaving_at) - h.bridged_at))
+                filter ( where h.bridged_at notnull ), 0)::float8 as avg_handle_sec,
+       coalesce(avg(extract(epoch from coalesce(h.bridged_at, h.leaving_at) - h.joined_at)), 0)::float8 as avg_wait_sec
+from call_center.cc_member_attempt_history h
+where h.joined_at > now() - interval '1 hour'
+  and h.channel = 'chat'
+  and h.member_id isnull
+group by h.queue_id
+with no data;
+
+create unique index if not exists cc_inbound_chat_handle_stats_uidx on call_center.cc_inbound_chat_handle_stats using btree(queue_id);
+
+refresh materialized view call_center.cc_inbound_chat_handle_stats;
+
+--
+-- Name: cc_member_attempt excluded_agents; Type: COLUMN; Schema: call_center; Owner: -
+--
+
+alter table call_center.cc_member_attempt add column if not exists excluded_agents int4[];
+
+--
+-- Name: cc_member_attempt affinity; Type: COLUMN; Schema: call_center; Owner: -
+--
+
+alter table call_center.cc_member_attempt add column if not exists affinity jsonb;
+
+--
+-- Name: cc_attempt_affinity_allow(jsonb, integer, integer); Type: FUNCTION; Schema: call_center; Owner: -
+--
+
+create or replace function call_center.cc_attempt_affinity_allow(affinity_ jsonb, agent_id_ integer, team_id_ integer) returns boolean
+    language plpgsql stable
+as $$
+declare
+    weight_   float8;
+    required_ float8;
+begin
+    if affinity_ isnull then
+        return true;
+    end if;
+
+    weight_ = coalesce((affinity_ -> 'agents' ->> agent_id_::text)::float8,
+                       case when (affinity_ ->> 'team_id')::int4 = team_id_ then (affinity_ ->> 'team_weight')::float8 end,
+                       0);
+    required_ = (affinity_ ->> 'best')::float8 *
+                power(0.5, extract(epoch from now() - to_timestamp((affinity_ ->> 'since')::float8 / 1000)) /
+                           greatest((affinity_ ->> 'half_life')::float8, 1));
+
+    return required_ < (affinity_ ->> 'min')::float8 or weight_ >= required_;
+end;
+$$;
+
+--
+-- Name: cc_member_attempt_history_member_id_leaving_at_index; Type: INDEX; Schema: call_center; Owner: -
+--
+
+create index if not exists cc_member_attempt_history_member_id_leaving_at_index
+    on call_center.cc_member_attempt_history using btree (member_id, leaving_at desc)
+    where agent_id notnull and bridged_at notnull;
+
+--
+-- Name: cc_member_attempt_history_domain_id_destination_index; Type: INDEX; Schema: call_center; Owner: -
+--
+
+create index if not exists cc_member_attempt_history_domain_id_destination_index
+    on call_center.cc_member_attempt_history using btree (domain_id, (destination ->> 'destination'), leaving_at desc)
+    where agent_id notnull and bridged_at notnull;
+
+--
+-- Name: cc_distribute(boolean); Type: PROCEDURE; Schema: call_center; Owner: -
+--
+
+CREATE OR REPLACE PROCEDURE call_center.cc_distribute(IN disable_omnichannel boolean)
+    LANGUAGE plpgsql
+AS $$begin
+    if NOT pg_try_advisory_xact_lock(132132117) then
+        raise exception 'LOCK';
+    end if;
+
+    with dis as MATERIALIZED (
+        select x.*, a.team_id
+        from call_center.cc_sys_distribute(disable_omnichannel) x (agent_id int, queue_id int, bucket_id int, ins bool, id int8, resource_id int,
+                                                                   resource_group_id int, comm_idx int)
+                 left join call_center.cc_agent a on a.id= x.agent_id
+    )
+       , ins as (
+        insert into call_center.cc_member_attempt (channel, member_id, queue_id, resource_id, agent_id, bucket_id, destination,
+                                                   communication_idx, member_call_id, team_id, resource_group_id, domain_id, import_id, sticky_agent_id, queue_params, queue_type)
+            select case when q.type = 7 then 'task' else 'call' end, --todo
+                   dis.id,
+                   dis.queue_id,
+                   dis.resource_id,
+                   dis.agent_id,
+                   dis.bucket_id,
+                   x,
+                   dis.comm_idx,
+                   uuid_generate_v4(),
+                   dis.team_id,
+                   dis.resource_group_id,
+                   q.domain_id,
+                   m.import_id,
+                   case when q.type = 5 and q.sticky_agent then dis.agent_id end,
+                   call_center.cc_queue_params(q),
+                   q.type
+            from dis
+                     inner join call_center.cc_queue q on q.id = dis.queue_id
+                     inner join call_center.cc_member m on m.id = dis.id
+                     inner join lateral jsonb_extract_path(m.communications, (dis.comm_idx)::text) x on true
+            where dis.ins
+    )
+    update call_center.cc_member_attempt a
+    set agent_id = t.agent_id,
+        team_id = t.team_id
+    from (
+             select dis.id, dis.agent_id, dis.team_id
+             from dis
+                      inner join call_center.cc_agent a on a.id = dis.agent_id
+                      left join call_center.cc_queue q on q.id = dis.queue_id
+             where not dis.ins is true
+               and (q.type is null or q.type in (6, 7) or not exists(select 1 from call_center.cc_calls cc where cc.user_id = a.user_id and cc.hangup_at isnull ))
+         ) t
+    where t.id = a.id
+      and a.agent_id isnull
+      and not t.agent_id = any(coalesce(a.excluded_agents, '{}'))
+      and call_center.cc_attempt_affinity_allow(a.affinity, t.agent_id, t.team_id);
+
+end;
+$$;
+
+--
+-- Name: cc_member_attempt manual_release; Type: COLUMN; Schema: call_center; Owner: -
+--
+
+alter table call_center.cc_member_attempt add column if not exists manual_release boolean not null default false;
+
+create index if not exists cc_member_attempt_manual_release_index
+    on call_center.cc_member_attempt using btree (node_id) where manual_release;
+
+--
+-- Name: cc_queue_sla_status; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_queue_sla_status
+(
+    queue_id   int4 primary key,
+    status     varchar                  not null,
+    updated_at timestamp with time zone not null default now()
+);
+
+--
+-- Name: cc_outbound_resource_health; Type: TABLE; Schema: call_center; Owner: -
+--
+
+create table if not exists call_center.cc_outbound_resource_health
+(
+    resource_id int4 primary key
+        references call_center.cc_outbound_resource (id) on delete cascade,
+    domain_id   int8                     not null,
+    state       varchar                  not null,
+    cause       varchar                  not null default '',
+    node_id     varchar                  not null,
+    changed_at  timestamp with time zone not null default now()
+);
+
+create index if not exists cc_outbound_resource_health_changed_at_index
+    on call_center.cc_outbound_resource_health using btree (changed_at);
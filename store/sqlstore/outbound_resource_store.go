@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/webitel/call_center/model"
 	"github.com/webitel/call_center/store"
 	"net/http"
@@ -79,3 +80,143 @@ func (s SqlOutboundResourceStore) SetSuccessivelyErrorsById(id int64, successive
 	}
 	return nil
 }
+
+// QueueCandidates the enabled resources of the groups of the queue with the calls in progress
+func (s SqlOutboundResourceStore) QueueCandidates(queueId int) ([]*model.ResourceCandidate, *model.AppError) {
+	var res []*model.ResourceCandidate
+	_, err := s.GetReplica().Select(&res, `select r.id                                         as resource_id,
+       GREATEST(r.updated_at, call_center.cc_view_timestamp(gw.updated_at at time zone 'utc')) as resource_updated_at,
+       call_center.cc_view_timestamp(gw.updated_at) as gateway_updated_at,
+       rq.id                                        as group_id,
+       rq.strategy                                  as group_strategy,
+       rq.communication_id,
+       coalesce(r.patterns::varchar[], '{}')        as patterns,
+       rig.priority,
+       r."limit",
+       coalesce(used.cnt, 0)                        as used,
+       r."limit" - coalesce(used.cnt, 0) > 0        as allow_call
+from call_center.cc_queue_resource qr
+         inner join call_center.cc_outbound_resource_group rq on rq.id = qr.resource_group_id
+         inner join call_center.cc_outbound_resource_in_group rig on rig.group_id = rq.id
+         inner join call_center.cc_outbound_resource r on r.id = rig.resource_id
+         inner join directory.sip_gateway gw on gw.id = r.gateway_id
+         left join lateral (select count(*) as cnt
+                            from call_center.cc_member_attempt c
+                            where c.resource_id = r.id
+                              and c.state not in ('leaving', 'processing')) used on true
+where qr.queue_id = :QueueId::int
+  and r.enabled
+order by rig.priority desc, r.id`, map[string]interface{}{
+		"QueueId": queueId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlOutboundResourceStore.QueueCandidates", "store.sql_outbound_resource.candidates.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// DisplayUsage calls of the display numbers today, the day is in the timezone of the caller id settings
+func (s SqlOutboundResourceStore) DisplayUsage(domainId int64, numbers []string, timezone string) (map[string]int, *model.AppError) {
+	var rows []struct {
+		Number string `db:"number"`
+		Count  int    `db:"count"`
+	}
+
+	_, err := s.GetReplica().Select(&rows, `select u.number, u.count
+from call_center.cc_display_number_usage u
+where u.domain_id = :DomainId::int8
+  and u.day = (now() at time zone :Tz::varchar)::date
+  and u.number = any(:Numbers::varchar[])`, map[string]interface{}{
+		"DomainId": domainId,
+		"Numbers":  pq.Array(numbers),
+		"Tz":       timezone,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlOutboundResourceStore.DisplayUsage", "store.sql_outbound_resource.display_usage.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	res := make(map[string]int, len(rows))
+	for _, r := range rows {
+		res[r.Number] = r.Count
+	}
+
+	return res, nil
+}
+
+// CountDisplay counts the placed call of the display number
+func (s SqlOutboundResourceStore) CountDisplay(domainId int64, number string, timezone string) *model.AppError {
+	_, err := s.GetMaster().Exec(`insert into call_center.cc_display_number_usage as u (domain_id, number, day, count)
+values (:DomainId::int8, :Number::varchar, (now() at time zone :Tz::varchar)::date, 1)
+on conflict (domain_id, number, day) do update set count = u.count + 1`, map[string]interface{}{
+		"DomainId": domainId,
+		"Number":   number,
+		"Tz":       timezone,
+	})
+
+	if err != nil {
+		return model.NewAppError("SqlOutboundResourceStore.CountDisplay", "store.sql_outbound_resource.count_display.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return nil
+}
+
+// SetHealthState stores the state of the breaker when it is newer than the stored one,
+// returns false when the resource of the domain is not found or the state is older
+func (s SqlOutboundResourceStore) SetHealthState(state *model.ResourceHealthState) (bool, *model.AppError) {
+	id, err := s.GetMaster().SelectNullInt(`insert into call_center.cc_outbound_resource_health as h (resource_id, domain_id, state, cause, node_id, changed_at)
+select r.id, r.domain_id, :State::varchar, :Cause::varchar, :NodeId::varchar, to_timestamp(:ChangedAt::float8 / 1000)
+from call_center.cc_outbound_resource r
+where r.id = :ResourceId::int
+  and r.domain_id = :DomainId::int8
+on conflict (resource_id) do update set state      = excluded.state,
+                                        cause      = excluded.cause,
+                                        node_id    = excluded.node_id,
+                                        changed_at = excluded.changed_at
+    where h.changed_at < excluded.changed_at
+returning h.resource_id`, map[string]interface{}{
+		"ResourceId": state.ResourceId,
+		"DomainId":   state.DomainId,
+		"State":      state.State,
+		"Cause":      state.Cause,
+		"NodeId":     state.NodeId,
+		"ChangedAt":  state.ChangedAt,
+	})
+
+	if err != nil {
+		return false, model.NewAppError("SqlOutboundResourceStore.SetHealthState", "store.sql_outbound_resource.set_health_state.app_error", nil,
+			fmt.Sprintf("Id=%v, %s", state.ResourceId, err.Error()), extractCodeFromErr(err))
+	}
+
+	return id.Valid, nil
+}
+
+// HealthStates the states of the breakers changed by the other nodes after changedAt
+func (s SqlOutboundResourceStore) HealthStates(nodeId string, changedAt int64) ([]*model.ResourceHealthState, *model.AppError) {
+	var res []*model.ResourceHealthState
+	_, err := s.GetReplica().Select(&res, `select h.resource_id,
+       h.domain_id,
+       h.state,
+       h.cause,
+       h.node_id,
+       call_center.cc_view_timestamp(h.changed_at) changed_at
+from call_center.cc_outbound_resource_health h
+where h.changed_at > to_timestamp(:ChangedAt::float8 / 1000)
+  and h.node_id != :NodeId::varchar
+order by h.changed_at`, map[string]interface{}{
+		"NodeId":    nodeId,
+		"ChangedAt": changedAt,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlOutboundResourceStore.HealthStates", "store.sql_outbound_resource.health_states.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
@@ -99,3 +99,47 @@ where q.id = :QueueId
 
 	return model.Int64Array(res), nil
 }
+
+// BestSkilledAgentIds the agents of the queue by the max capacity of the queue skills
+func (s SqlQueueStore) BestSkilledAgentIds(queueId int, limit int) (model.Int64Array, *model.AppError) {
+	var res model.Int64Array
+	_, err := s.GetReplica().Select(&res, `select a.id
+from call_center.cc_queue q
+    inner join call_center.cc_agent a on a.domain_id = q.domain_id
+    inner join call_center.cc_queue_skill qs on qs.queue_id = q.id and qs.enabled
+    inner join call_center.cc_skill_in_agent sia on sia.agent_id = a.id and sia.enabled
+where q.id = :QueueId
+    and (q.team_id isnull or a.team_id = q.team_id)
+    and qs.skill_id = sia.skill_id and sia.capacity between qs.min_capacity and qs.max_capacity
+group by a.id
+order by max(sia.capacity) desc, a.id
+limit :Limit`, map[string]interface{}{
+		"QueueId": queueId,
+		"Limit":   limit,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlQueueStore.BestSkilledAgentIds", "store.sql_queue.best_skilled.app_error", nil,
+			fmt.Sprintf("queue_id=%v, %s", queueId, err.Error()), http.StatusInternalServerError)
+	}
+
+	return res, nil
+}
+
+func (s SqlQueueStore) SupervisorIds(queueId int) (model.Int64Array, *model.AppError) {
+	var res model.Int64Array
+	_, err := s.GetReplica().Select(&res, `select distinct a.user_id
+from call_center.cc_queue q
+    inner join call_center.cc_agent a on a.domain_id = q.domain_id and a.team_id = q.team_id
+where q.id = :QueueId
+    and a.supervisor`, map[string]interface{}{
+		"QueueId": queueId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlQueueStore.SupervisorIds", "store.sql_queue.supervisors.app_error", nil,
+			fmt.Sprintf("queue_id=%v, %s", queueId, err.Error()), http.StatusInternalServerError)
+	}
+
+	return model.Int64Array(res), nil
+}
@@ -1,6 +1,9 @@
 package sqlstore
 
 import (
+	"encoding/json"
+
+	"github.com/lib/pq"
 	"github.com/webitel/call_center/model"
 	"github.com/webitel/call_center/store"
 	"net/http"
@@ -16,7 +19,9 @@ func NewSqlStatisticStore(sqlStore SqlStore) store.StatisticStore {
 }
 
 func (s SqlStatisticStore) RefreshInbound1H() *model.AppError {
-	_, err := s.GetMaster().Exec(`refresh materialized view CONCURRENTLY call_center.cc_inbound_stats`)
+	_, err := s.GetMaster().Exec(`refresh materialized view CONCURRENTLY call_center.cc_inbound_stats;
+refresh materialized view CONCURRENTLY call_center.cc_inbound_handle_stats;
+refresh materialized view CONCURRENTLY call_center.cc_inbound_chat_handle_stats`)
 
 	if err != nil {
 		return model.NewAppError("SqlAgentStore.RefreshInbound1H", "store.sql_agent.refresh_inbound_stats.app_error", nil,
@@ -36,3 +41,161 @@ func (s *SqlStatisticStore) LibVersion() (string, *model.AppError) {
 
 	return str, nil
 }
+
+// InboundQueueStats last hour handle time of the queue with available agents of the queue team: online and waiting
+// on the channel of the queue, the chat queues use the chat stats
+func (s *SqlStatisticStore) InboundQueueStats(queueId int) (*model.InboundQueueStats, *model.AppError) {
+	var stats *model.InboundQueueStats
+	err := s.GetReplica().SelectOne(&stats, `select q.id as queue_id,
+       coalesce(st.handled, cst.handled, 0) as handled,
+       coalesce(st.avg_handle_sec, cst.avg_handle_sec, 0) as avg_handle_sec,
+       coalesce(st.avg_wait_sec, cst.avg_wait_sec, 0) as avg_wait_sec,
+       (select count(*)
+        from call_center.cc_agent a
+        where a.team_id = q.team_id
+          and a.domain_id = q.domain_id
+          and a.status = 'online'
+          and exists(select 1
+                     from call_center.cc_agent_channel c
+                     where c.agent_id = a.id
+                       and c.channel = case when q.type = 6 then 'chat' else 'call' end
+                       and c.state = 'waiting')) as agents
+from call_center.cc_queue q
+         left join call_center.cc_inbound_handle_stats st on st.queue_id = q.id and q.type <> 6
+         left join call_center.cc_inbound_chat_handle_stats cst on cst.queue_id = q.id and q.type = 6
+where q.id = :QueueId`, map[string]interface{}{
+		"QueueId": queueId,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlStatisticStore.InboundQueueStats", "store.sql_statistic.inbound_queue_stats.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return stats, nil
+}
+
+// WallboardQueues live state of the queues of all nodes, handled (finished after the bridge) and abandoned since the dayStart (ms)
+func (s *SqlStatisticStore) WallboardQueues(domainId int64, queueIds []int, dayStart int64) ([]*model.WallboardQueue, *model.AppError) {
+	var queues []*model.WallboardQueue
+	_, err := s.GetReplica().Select(&queues, `select q.id as queue_id,
+       coalesce(a.waiting, 0) as waiting,
+       coalesce(a.longest_wait_sec, 0) as longest_wait_sec,
+       coalesce(a.active, 0) as active,
+       coalesce(h.handled, 0) + coalesce(a.finished, 0) as handled,
+       coalesce(h.abandoned, 0) as abandoned
+from call_center.cc_queue q
+         left join lateral (
+    select count(*) filter ( where a.state = 'wait_agent' ) as waiting,
+           coalesce(extract(epoch from now() - min(a.joined_at) filter ( where a.state = 'wait_agent' )), 0)::int as longest_wait_sec,
+           count(*) filter ( where a.bridged_at notnull and a.leaving_at isnull ) as active,
+           count(*) filter ( where a.bridged_at notnull and a.leaving_at >= to_timestamp(:DayStart::int8 / 1000.0) ) as finished
+    from call_center.cc_member_attempt a
+    where a.queue_id = q.id
+    ) a on true
+         left join lateral (
+    select count(*) filter ( where h.bridged_at notnull ) as handled,
+           count(*) filter ( where h.bridged_at isnull and q.type in (1, 6) ) as abandoned
+    from call_center.cc_member_attempt_history h
+    where h.queue_id = q.id
+      and h.leaving_at >= to_timestamp(:DayStart::int8 / 1000.0)
+    ) h on true
+where q.domain_id = :DomainId::int8
+  and q.id = any(:QueueIds::int[])
+order by q.id`, map[string]interface{}{
+		"DomainId": domainId,
+		"QueueIds": pq.Array(queueIds),
+		"DayStart": dayStart,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlStatisticStore.WallboardQueues", "store.sql_statistic.wallboard_queues.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return queues, nil
+}
+
+// QueuesSla the 15m, 1h and day windows of the queues from the attempts of all nodes, the day is in the timezone
+// of the calendar of the queue
+func (s *SqlStatisticStore) QueuesSla(search []*model.SearchQueueSla) ([]*model.QueueSla, *model.AppError) {
+	var res []*model.QueueSla
+	data, _ := json.Marshal(search)
+	_, err := s.GetReplica().Select(&res, `select q.id as queue_id,
+       w.name as "window",
+       count(x.joined_at) as offered,
+       count(x.bridged_at) as answered,
+       count(x.bridged_at) filter ( where x.bridged_at - x.joined_at <= (s.threshold || ' sec')::interval ) as answered_in_threshold,
+       count(x.joined_at) filter ( where x.bridged_at isnull
+           and not (s.short_abandon > 0 and x.leaving_at - x.joined_at <= (s.short_abandon || ' sec')::interval) ) as abandoned,
+       count(x.joined_at) filter ( where x.bridged_at isnull
+           and s.short_abandon > 0 and x.leaving_at - x.joined_at <= (s.short_abandon || ' sec')::interval ) as short_abandoned
+from jsonb_to_recordset(:Search::jsonb) s (queue_id int, threshold int, short_abandon int)
+    inner join call_center.cc_queue q on q.id = s.queue_id
+    left join flow.calendar c on c.id = q.calendar_id
+    left join flow.calendar_timezones tz on tz.id = c.timezone_id
+    cross join lateral (
+        values (:Window15m::varchar, now() - interval '15 min'),
+               (:Window1h::varchar, now() - interval '1 hour'),
+               (:WindowDay::varchar, date_trunc('day', now() at time zone coalesce(tz.sys_name, 'UTC')) at time zone coalesce(tz.sys_name, 'UTC'))
+    ) w (name, since)
+    left join lateral (
+        select h.joined_at, h.bridged_at, h.leaving_at
+        from call_center.cc_member_attempt_history h
+        where h.queue_id = q.id
+          and h.leaving_at >= w.since
+        union all
+        select a.joined_at, a.bridged_at, a.leaving_at
+        from call_center.cc_member_attempt a
+        where a.queue_id = q.id
+          and a.leaving_at >= w.since
+    ) x on true
+group by q.id, w.name`, map[string]interface{}{
+		"Search":    string(data),
+		"Window15m": model.SlaWindow15m,
+		"Window1h":  model.SlaWindow1h,
+		"WindowDay": model.SlaWindowDay,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlStatisticStore.QueuesSla", "store.sql_statistic.queues_sla.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return res, nil
+}
+
+// SetQueueSlaStatus the status of the queue is shared by the nodes, returns the previous status when the status is
+// changed by this call, so only one node sends the event of the change
+func (s *SqlStatisticStore) SetQueueSlaStatus(queueId int, status string) (*string, *model.AppError) {
+	previous, err := s.GetMaster().SelectNullStr(`with prev as (
+    select s.status
+    from call_center.cc_queue_sla_status s
+    where s.queue_id = :QueueId::int
+),
+upd as (
+    insert into call_center.cc_queue_sla_status as s (queue_id, status, updated_at)
+    values (:QueueId::int, :Status::varchar, now())
+    on conflict (queue_id) do update set status     = excluded.status,
+                                         updated_at = excluded.updated_at
+        where s.status != excluded.status
+    returning s.queue_id
+)
+select coalesce((select p.status from prev p), :Default::varchar)
+from upd`, map[string]interface{}{
+		"QueueId": queueId,
+		"Status":  status,
+		"Default": model.SlaStatusOk,
+	})
+
+	if err != nil {
+		return nil, model.NewAppError("SqlStatisticStore.SetQueueSlaStatus", "store.sql_statistic.set_queue_sla_status.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	if !previous.Valid || previous.String == status {
+		return nil, nil
+	}
+
+	return &previous.String, nil
+}
@@ -40,6 +40,9 @@ type SqlSupplierOldStores struct {
 	call             store.CallStore
 	statistic        store.StatisticStore
 	trigger          store.TriggerStore
+	chatTranscript   store.ChatTranscriptStore
+	amd              store.AmdStore
+	lead             store.LeadStore
 }
 
 type SqlSupplier struct {
@@ -74,6 +77,9 @@ func NewSqlSupplier(settings model.SqlSettings) *SqlSupplier {
 	supplier.oldStores.call = NewSqlCallStore(supplier)
 	supplier.oldStores.statistic = NewSqlStatisticStore(supplier)
 	supplier.oldStores.trigger = NewSqlTriggerStore(supplier)
+	supplier.oldStores.chatTranscript = NewSqlChatTranscriptStore(supplier)
+	supplier.oldStores.amd = NewSqlAmdStore(supplier)
+	supplier.oldStores.lead = NewSqlLeadStore(supplier)
 
 	err := supplier.GetMaster().CreateTablesIfNotExists()
 	if err != nil {
@@ -246,6 +252,18 @@ func (ss *SqlSupplier) Trigger() store.TriggerStore {
 	return ss.oldStores.trigger
 }
 
+func (ss *SqlSupplier) ChatTranscript() store.ChatTranscriptStore {
+	return ss.oldStores.chatTranscript
+}
+
+func (ss *SqlSupplier) Amd() store.AmdStore {
+	return ss.oldStores.amd
+}
+
+func (ss *SqlSupplier) Lead() store.LeadStore {
+	return ss.oldStores.lead
+}
+
 type typeConverter struct{}
 
 func (me typeConverter) ToDb(val interface{}) (interface{}, error) {
@@ -269,7 +287,8 @@ func (me typeConverter) ToDb(val interface{}) (interface{}, error) {
 func (me typeConverter) FromDb(target interface{}) (gorp.CustomScanner, bool) {
 	switch target.(type) {
 	case *model.OutboundResourceParameters,
-		*[]*model.MemberWaiting:
+		*[]*model.MemberWaiting,
+		*[]model.MemberCommunication:
 		binder := func(holder, target interface{}) error {
 			s, ok := holder.(*[]byte)
 			if !ok {
@@ -296,6 +315,7 @@ func (me typeConverter) FromDb(target interface{}) (gorp.CustomScanner, bool) {
 		return gorp.CustomScanner{Holder: new(string), Target: target, Binder: binder}, true
 
 	case **model.RingtoneFile,
+		**model.Lookup,
 		*model.TriggerJobParameter,
 		*[]*model.QueueHook:
 		binder := func(holder, target interface{}) error {
@@ -3,6 +3,7 @@ package sqlstore
 import (
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/webitel/call_center/model"
 	"github.com/webitel/call_center/store"
 	"net/http"
@@ -52,3 +53,19 @@ func (s SqlTeamStore) Get(id int) (*model.Team, *model.AppError) {
 		return team, nil
 	}
 }
+
+func (s SqlTeamStore) AgentsStatus(domainId int64, teamIds []int) ([]*model.WallboardAgent, *model.AppError) {
+	var agents []*model.WallboardAgent
+	if _, err := s.GetReplica().Select(&agents, `select a.id, a.team_id, a.status
+from call_center.cc_agent a
+where a.domain_id = :DomainId
+	and a.team_id = any(:TeamIds::int[])`, map[string]interface{}{
+		"DomainId": domainId,
+		"TeamIds":  pq.Array(teamIds),
+	}); err != nil {
+		return nil, model.NewAppError("SqlTeamStore.AgentsStatus", "store.sql_team.agents_status.app_error", nil,
+			err.Error(), extractCodeFromErr(err))
+	}
+
+	return agents, nil
+}
@@ -17,6 +17,9 @@ type Store interface {
 	Call() CallStore
 	Statistic() StatisticStore
 	Trigger() TriggerStore
+	ChatTranscript() ChatTranscriptStore
+	Amd() AmdStore
+	Lead() LeadStore
 }
 
 type CallStore interface {
@@ -33,15 +36,26 @@ type OutboundResourceStore interface {
 	GetById(id int64) (*model.OutboundResource, *model.AppError)
 	SetError(id int64, queueId int64, errorId string, strategy model.OutboundResourceUnReserveStrategy) (*model.OutboundResourceErrorResult, *model.AppError)
 	SetSuccessivelyErrorsById(id int64, successivelyErrors uint16) *model.AppError
+	QueueCandidates(queueId int) ([]*model.ResourceCandidate, *model.AppError)
+	DisplayUsage(domainId int64, numbers []string, timezone string) (map[string]int, *model.AppError)
+	CountDisplay(domainId int64, number string, timezone string) *model.AppError
+
+	SetHealthState(state *model.ResourceHealthState) (bool, *model.AppError)
+	HealthStates(nodeId string, changedAt int64) ([]*model.ResourceHealthState, *model.AppError)
 }
 
 type QueueStore interface {
 	GetById(id int64) (*model.Queue, *model.AppError)
 	UserIds(queueId int, skipAgentId int) (model.Int64Array, *model.AppError)
+	SupervisorIds(queueId int) (model.Int64Array, *model.AppError)
+	BestSkilledAgentIds(queueId int, limit int) (model.Int64Array, *model.AppError)
 }
 
 type MemberStore interface {
 	ReserveMembersByNode(nodeId string, enableOmnichannel bool) (int64, *model.AppError)
+
+	SaveJournal(journal *model.AttemptJournal) *model.AppError
+	SearchJournal(search *model.SearchAttemptJournal) ([]*model.AttemptJournal, *model.AppError)
 	UnReserveMembersByNode(nodeId, cause string) (int64, *model.AppError)
 
 	GetActiveMembersAttempt(nodeId string) ([]*model.MemberAttempt, *model.AppError)
@@ -72,6 +86,9 @@ type MemberStore interface {
 		excludeNum bool, redial bool, desc *string, stickyAgentId *int32) (*model.AttemptLeaving, *model.AppError)
 
 	SetAttemptWaitingAgent(attemptId int64, agentHoldSec int) *model.AppError
+	ExcludeAttemptAgent(attemptId int64, agentId int) *model.AppError
+	SetAttemptAffinity(attemptId int64, affinity []byte) *model.AppError
+	AddAttemptWeight(attemptId int64, weight int) *model.AppError
 	SetAttemptMissedAgent(attemptId int64, agentHoldSec int) (*model.MissedAgent, *model.AppError)
 	SetAttemptMissed(id int64, agentHoldTime int, maxAttempts uint, waitBetween uint64, perNum bool) (*model.MissedAgent, *model.AppError)
 	SetAttemptResult(id int64, result string, channelState string, agentHoldTime int, vars map[string]string,
@@ -83,6 +100,11 @@ type MemberStore interface {
 	SetTimeoutError(id int64) *model.AppError
 	RenewalProcessing(domainId, attId int64, renewalSec uint32) (*model.RenewalProcessing, *model.AppError)
 
+	StartWrapUp(attemptId int64) *model.AppError
+	EndWrapUp(wrapUp *model.AttemptWrapUp) *model.AppError
+	ExpiredWrapUp(nodeId string) ([]*model.WrapUpExpired, *model.AppError)
+	RestoreWrapUpTimeout(attemptId int64) *model.AppError
+
 	// CHAT TODO
 	CreateConversationChannel(parentChannelId, name string, attemptId int64) (string, *model.AppError)
 
@@ -90,17 +112,39 @@ type MemberStore interface {
 
 	TransferredTo(id, toId int64) *model.AppError
 	TransferredFrom(id, toId int64, toAgentId int, toAgentSessId string) *model.AppError
+	HandoverAgent(attemptId int64, userId int64, wrapTimeSec uint32) (*model.AgentsForAttempt, *model.AppError)
 	CancelAgentDistribute(agentId int32) ([]int64, *model.AppError)
 	SetExpired(limit int) ([]*model.ExpiredMember, *model.AppError)
 
 	StoreForm(attemptId int64, form []byte, fields map[string]string) *model.AppError
 	StoreFormFields(attemptId int64, fields map[string]string) *model.AppError
 
+	ImportExisting(domainId int64, queueId int, destinations []string) ([]string, *model.AppError)
+	ImportDnc(queueId int, destinations, numbers []string) ([]string, *model.AppError)
+	AddDnc(queueId int, number, description string) *model.AppError
+	StopWithCause(memberId int64, cause string) *model.AppError
+	Import(domainId int64, queueId int, rows []*model.MemberImportRow) (int64, *model.AppError)
+	Export(ctx context.Context, domainId int64, queueId int, afterId int64, limit int) ([]*model.MemberExport, *model.AppError)
+
 	CleanAttempts(nodeId string) *model.AppError
+	DeferAttempt(attemptId int64, delaySec uint32) *model.AppError
 	FlipResource(attemptId int64, skippResources []int) (*model.AttemptFlipResource, *model.AppError)
+	SetAttemptResource(attemptId int64, resourceId int64, newCall bool) (*model.AttemptFlipResource, *model.AppError)
+	LastDisplay(memberId int64, numbers []string) (string, *model.AppError)
 
 	Intercept(ctx context.Context, domainId int64, attemptId int64, agentId int32) (int, *model.AppError)
+	ManualAttempts(ctx context.Context, search *model.SearchManualAttempt) ([]*model.ManualAttempt, *model.AppError)
+	ManualReserve(ctx context.Context, r *model.ManualReservation) (*model.ManualReserved, *model.AppError)
+	ManualRelease(ctx context.Context, r *model.ManualReservation) (bool, *model.AppError)
+	ManualReleaseOffer(ctx context.Context, r *model.ManualReservation) (bool, *model.AppError)
+	ManualReleasedByNode(node string) ([]*model.ManualReservation, *model.AppError)
 	WaitingList() ([]*model.MemberWaitingByUsers, *model.AppError)
+
+	CreateCallbackMember(attemptId int64, queueId int, name, destination string, priority int, vars map[string]string) (int64, *model.AppError)
+	WaitingPosition(attemptId int64) (int, *model.AppError)
+	WaitingAhead(queueId int, priority int) (int, *model.AppError)
+	OverflowAttempt(attemptId int64, queueId *int, priority int) (*model.AttemptOverflow, *model.AppError)
+	OverflowTeam(attemptId int64, teamId int) (*int32, *model.AppError)
 }
 
 type AgentStore interface {
@@ -130,10 +174,13 @@ type AgentStore interface {
 	LosePredictAttempt(id int) *model.AppError
 	CheckAllowPause(domainId int64, agentId int) (bool, *model.AppError)
 	AgentTriggerJob(ctx context.Context, domainId int64, userId int64, triggerId int32) (*model.AgentTriggerJob, *model.AppError)
+	LastHandled(domainId int64, memberId *int64, destination string, lookbackDays int) (int, *model.AppError)
+	SetChannelWrapTime(agentId int, channel string, wrapTimeSec uint32) (*int64, *model.AppError)
 }
 
 type TeamStore interface {
 	Get(id int) (*model.Team, *model.AppError)
+	AgentsStatus(domainId int64, teamIds []int) ([]*model.WallboardAgent, *model.AppError)
 }
 
 type GatewayStore interface {
@@ -143,6 +190,10 @@ type GatewayStore interface {
 type StatisticStore interface {
 	RefreshInbound1H() *model.AppError
 	LibVersion() (string, *model.AppError)
+	InboundQueueStats(queueId int) (*model.InboundQueueStats, *model.AppError)
+	WallboardQueues(domainId int64, queueIds []int, dayStart int64) ([]*model.WallboardQueue, *model.AppError)
+	QueuesSla(search []*model.SearchQueueSla) ([]*model.QueueSla, *model.AppError)
+	SetQueueSlaStatus(queueId int, status string) (*string, *model.AppError)
 }
 
 type TriggerStore interface {
@@ -152,3 +203,22 @@ type TriggerStore interface {
 	SetResult(job *model.TriggerJob) *model.AppError
 	CleanActive(nodeId string) *model.AppError
 }
+
+type ChatTranscriptStore interface {
+	Save(messages []*model.ChatTranscriptMessage) *model.AppError
+	List(domainId int64, attemptId int64) ([]*model.ChatTranscriptMessage, *model.AppError)
+}
+
+type AmdStore interface {
+	Save(outcome *model.AmdOutcome) *model.AppError
+	Correct(correction *model.AmdCorrection) *model.AppError
+	Report(search *model.SearchAmdReport) ([]*model.AmdReport, *model.AppError)
+}
+
+type LeadStore interface {
+	ClaimQueues() ([]*model.LeadStrategyQueue, *model.AppError)
+	Members(queueId int, limit int) ([]*model.LeadMember, *model.AppError)
+	SetScores(queueId int, scores []*model.LeadScore) *model.AppError
+	Recycle(queueId int, rule *model.LeadRecycle) (int64, *model.AppError)
+	Score(domainId int64, memberId int64) (*model.LeadScore, *model.AppError)
+}
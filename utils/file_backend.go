@@ -4,16 +4,37 @@ import (
 	"github.com/webitel/call_center/model"
 	"io"
 	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	FileDriverLocal = "local"
+	FileDriverS3    = "s3"
 )
 
 type FileBackend interface {
 	TestConnection() *model.AppError
 	WriteFile(fr io.Reader, path string) (int64, *model.AppError)
+	ReadFile(path string) ([]byte, *model.AppError)
+	Reader(path string) (io.ReadCloser, *model.AppError)
 	RemoveFile(path string) *model.AppError
 	GetLocation(name string) string
 }
 
-func NewFileBackend(backendType string) (FileBackend, *model.AppError) {
-	return nil, model.NewAppError("NewFileBackend", "api.file.no_driver.app_error", nil, "",
+// FileKey the key of the file in the storage, relative to the root of the storage, the same for all drivers
+func FileKey(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func NewFileBackend(settings *model.FileSettings) (FileBackend, *model.AppError) {
+	switch settings.Driver {
+	case FileDriverLocal:
+		return NewLocalFileBackend(settings.Directory), nil
+	case FileDriverS3:
+		return NewS3FileBackend(settings)
+	}
+
+	return nil, model.NewAppError("NewFileBackend", "api.file.no_driver.app_error", nil, settings.Driver,
 		http.StatusInternalServerError)
 }
@@ -9,38 +9,100 @@ import (
 )
 
 type LocalFileBackend struct {
-	pathPattern string
-	rootPath    string
-	name        string
+	rootPath string
+}
+
+func NewLocalFileBackend(rootPath string) *LocalFileBackend {
+	return &LocalFileBackend{
+		rootPath: rootPath,
+	}
 }
 
 func (self *LocalFileBackend) GetLocation(name string) string {
-	return ""
+	return filepath.Join(self.rootPath, filepath.Clean("/"+name))
 }
 
 func (self *LocalFileBackend) TestConnection() *model.AppError {
+	f, err := os.CreateTemp(self.rootPath, ".test_*")
+	if err != nil {
+		if os.IsNotExist(err) {
+			if err = os.MkdirAll(self.rootPath, 0774); err == nil {
+				return self.TestConnection()
+			}
+		}
+		return model.NewAppError("TestFileConnection", "utils.file.locally.test_connection.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
 	return nil
 }
 
+// WriteFile writes to the temporary file of the same directory and renames it, the reader never sees a partial file
 func (self *LocalFileBackend) WriteFile(src io.Reader, path string) (int64, *model.AppError) {
-	if err := os.MkdirAll(filepath.Dir(path), 0774); err != nil {
-		directory, _ := filepath.Abs(filepath.Dir(path))
+	path = self.GetLocation(path)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0774); err != nil {
+		directory, _ := filepath.Abs(dir)
 		return 0, model.NewAppError("WriteFile", "utils.file.locally.create_dir.app_error", nil, "directory="+directory+", err="+err.Error(), http.StatusInternalServerError)
 	}
-	fw, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	fw, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
 	if err != nil {
 		return 0, model.NewAppError("WriteFile", "utils.file.locally.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
 	}
-	defer fw.Close()
+	tmp := fw.Name()
+	defer os.Remove(tmp)
+
 	written, err := io.Copy(fw, src)
+	if err == nil {
+		err = fw.Sync()
+	}
+	if cerr := fw.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+
 	if err != nil {
 		return written, model.NewAppError("WriteFile", "utils.file.locally.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
 	}
+
 	return written, nil
 }
 
+func (self *LocalFileBackend) ReadFile(path string) ([]byte, *model.AppError) {
+	data, err := os.ReadFile(self.GetLocation(path))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if os.IsNotExist(err) {
+			code = http.StatusNotFound
+		}
+		return nil, model.NewAppError("ReadFile", "utils.file.locally.reading.app_error", nil, err.Error(), code)
+	}
+
+	return data, nil
+}
+
+func (self *LocalFileBackend) Reader(path string) (io.ReadCloser, *model.AppError) {
+	f, err := os.Open(self.GetLocation(path))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if os.IsNotExist(err) {
+			code = http.StatusNotFound
+		}
+		return nil, model.NewAppError("Reader", "utils.file.locally.reading.app_error", nil, err.Error(), code)
+	}
+
+	return f, nil
+}
+
 func (self *LocalFileBackend) RemoveFile(path string) *model.AppError {
-	if err := os.Remove(path); err != nil {
+	if err := os.Remove(self.GetLocation(path)); err != nil {
 		return model.NewAppError("RemoveFile", "utils.file.locally.removing.app_error", nil, err.Error(), http.StatusInternalServerError)
 	}
 	return nil
@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/webitel/call_center/model"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm   = "AWS4-HMAC-SHA256"
+	s3Service     = "s3"
+	s3Timeout     = 30 * time.Second
+	s3DefaultZone = "us-east-1"
+	s3PartSize    = 8 * 1024 * 1024 // the minimum part of the multipart upload is 5MB
+)
+
+// S3FileBackend S3-compatible storage (AWS, MinIO, Ceph) with the path-style requests signed by the signature v4
+type S3FileBackend struct {
+	endpoint  string
+	scheme    string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	prefix    string
+	partSize  int
+	client    *http.Client
+}
+
+func NewS3FileBackend(settings *model.FileSettings) (*S3FileBackend, *model.AppError) {
+	if settings.S3Endpoint == "" || settings.S3Bucket == "" {
+		return nil, model.NewAppError("NewS3FileBackend", "utils.file.s3.valid.app_error", nil,
+			"endpoint and bucket are required", http.StatusBadRequest)
+	}
+
+	b := &S3FileBackend{
+		endpoint:  strings.TrimSuffix(settings.S3Endpoint, "/"),
+		scheme:    "https",
+		bucket:    settings.S3Bucket,
+		region:    settings.S3Region,
+		accessKey: settings.S3AccessKey,
+		secretKey: settings.S3SecretKey,
+		prefix:    strings.Trim(settings.S3Prefix, "/"),
+		partSize:  s3PartSize,
+		client:    &http.Client{Timeout: s3Timeout},
+	}
+
+	if settings.S3Insecure {
+		b.scheme = "http"
+	}
+	if b.region == "" {
+		b.region = s3DefaultZone
+	}
+
+	return b, nil
+}
+
+func (b *S3FileBackend) GetLocation(name string) string {
+	return fmt.Sprintf("%s://%s%s", b.scheme, b.endpoint, b.objectPath(name))
+}
+
+func (b *S3FileBackend) TestConnection() *model.AppError {
+	res, err := b.do(http.MethodHead, "/"+b.bucket, nil, nil)
+	if err != nil {
+		return model.NewAppError("TestFileConnection", "utils.file.s3.test_connection.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return model.NewAppError("TestFileConnection", "utils.file.s3.test_connection.app_error", nil,
+			fmt.Sprintf("bucket %s status %d", b.bucket, res.StatusCode), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// WriteFile the source larger than the part is written by the multipart upload, the part is kept in the memory only
+func (b *S3FileBackend) WriteFile(src io.Reader, name string) (int64, *model.AppError) {
+	part := make([]byte, b.partSize)
+	n, err := io.ReadFull(src, part)
+	switch err {
+	case nil:
+		return b.writeParts(src, name, part)
+	case io.EOF, io.ErrUnexpectedEOF:
+	default:
+		return 0, model.NewAppError("WriteFile", "utils.file.s3.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	res, err := b.do(http.MethodPut, b.objectPath(name), nil, part[:n])
+	if err != nil {
+		return 0, model.NewAppError("WriteFile", "utils.file.s3.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, model.NewAppError("WriteFile", "utils.file.s3.writing.app_error", nil, s3ErrorBody(res), http.StatusInternalServerError)
+	}
+
+	return int64(n), nil
+}
+
+type s3CompletePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// writeParts the multipart upload, the first part is read, the upload is aborted on the error
+func (b *S3FileBackend) writeParts(src io.Reader, name string, part []byte) (int64, *model.AppError) {
+	uploadId, err := b.createUpload(name)
+	if err != nil {
+		return 0, model.NewAppError("WriteFile", "utils.file.s3.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	var parts []s3CompletePart
+	var written int64
+	n := len(part)
+	for n > 0 {
+		var etag string
+		if etag, err = b.uploadPart(name, uploadId, len(parts)+1, part[:n]); err != nil {
+			break
+		}
+		parts = append(parts, s3CompletePart{PartNumber: len(parts) + 1, ETag: etag})
+		written += int64(n)
+
+		n, err = io.ReadFull(src, part)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = b.completeUpload(name, uploadId, parts)
+	}
+
+	if err != nil {
+		if res, abortErr := b.do(http.MethodDelete, b.objectPath(name), url.Values{"uploadId": {uploadId}}, nil); abortErr == nil {
+			res.Body.Close()
+		}
+		return written, model.NewAppError("WriteFile", "utils.file.s3.writing.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return written, nil
+}
+
+func (b *S3FileBackend) createUpload(name string) (string, error) {
+	res, err := b.do(http.MethodPost, b.objectPath(name), url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New(s3ErrorBody(res))
+	}
+
+	var result struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err = xml.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.UploadId, nil
+}
+
+func (b *S3FileBackend) uploadPart(name, uploadId string, number int, data []byte) (string, error) {
+	res, err := b.do(http.MethodPut, b.objectPath(name), url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {uploadId},
+	}, data)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New(s3ErrorBody(res))
+	}
+
+	return res.Header.Get("ETag"), nil
+}
+
+func (b *S3FileBackend) completeUpload(name, uploadId string, parts []s3CompletePart) error {
+	data, err := xml.Marshal(struct {
+		XMLName xml.Name         `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletePart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	res, err := b.do(http.MethodPost, b.objectPath(name), url.Values{"uploadId": {uploadId}}, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// the error of the completion may be sent with the status 200
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK || bytes.Contains(body, []byte("<Error>")) {
+		return fmt.Errorf("status %d: %s", res.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) ReadFile(name string) ([]byte, *model.AppError) {
+	r, appErr := b.Reader(name)
+	if appErr != nil {
+		return nil, appErr
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, model.NewAppError("ReadFile", "utils.file.s3.reading.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return data, nil
+}
+
+// Reader the body of the object, the caller closes it
+func (b *S3FileBackend) Reader(name string) (io.ReadCloser, *model.AppError) {
+	res, err := b.do(http.MethodGet, b.objectPath(name), nil, nil)
+	if err != nil {
+		return nil, model.NewAppError("Reader", "utils.file.s3.reading.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, model.NewAppError("Reader", "utils.file.s3.reading.app_error", nil, "not found "+name, http.StatusNotFound)
+	default:
+		defer res.Body.Close()
+		return nil, model.NewAppError("Reader", "utils.file.s3.reading.app_error", nil, s3ErrorBody(res), http.StatusInternalServerError)
+	}
+}
+
+func (b *S3FileBackend) RemoveFile(name string) *model.AppError {
+	res, err := b.do(http.MethodDelete, b.objectPath(name), nil, nil)
+	if err != nil {
+		return model.NewAppError("RemoveFile", "utils.file.s3.removing.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return model.NewAppError("RemoveFile", "utils.file.s3.removing.app_error", nil, s3ErrorBody(res), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) objectPath(name string) string {
+	key := FileKey(name)
+	if b.prefix != "" {
+		key = b.prefix + "/" + key
+	}
+
+	return "/" + b.bucket + "/" + key
+}
+
+// do the signed request, the query is encoded sorted by the key as the canonical query of the signature
+func (b *S3FileBackend) do(method, uri string, query url.Values, body []byte) (*http.Response, error) {
+	u := &url.URL{
+		Scheme:   b.scheme,
+		Host:     b.endpoint,
+		Path:     uri,
+		RawQuery: query.Encode(),
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	b.sign(req, body, time.Now().UTC())
+
+	return b.client.Do(req)
+}
+
+func (b *S3FileBackend) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.region + "/" + s3Service + "/aws4_request"
+	stringToSign := s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSha256([]byte("AWS4"+b.secretKey), date)
+	key = hmacSha256(key, b.region)
+	key = hmacSha256(key, s3Service)
+	key = hmacSha256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, b.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSha256(key, stringToSign))))
+}
+
+func s3ErrorBody(res *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Sprintf("status %d: %s", res.StatusCode, string(data))
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/webitel/call_center/model"
+)
+
+func testFileBackend(t *testing.T, b FileBackend) {
+	if err := b.TestConnection(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := b.WriteFile(strings.NewReader("hello"), "a/b/test.txt"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	data, err := b.ReadFile("a/b/test.txt")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(data) != "hello" {
+		t.Errorf("bad data: %s", data)
+	}
+
+	r, err := b.Reader("a/b/test.txt")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, _ = io.ReadAll(r)
+	r.Close()
+	if string(data) != "hello" {
+		t.Errorf("reader: bad data: %s", data)
+	}
+
+	if err = b.RemoveFile("a/b/test.txt"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = b.ReadFile("a/b/test.txt"); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Errorf("expected not found")
+	}
+}
+
+func TestLocalFileBackend(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewFileBackend(&model.FileSettings{Driver: FileDriverLocal, Directory: dir})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testFileBackend(t, b)
+
+	// no temporary files left
+	files, _ := os.ReadDir(filepath.Join(dir, "a", "b"))
+	if len(files) != 0 {
+		t.Errorf("temporary files left: %d", len(files))
+	}
+
+	if loc := b.GetLocation("../../etc/passwd"); loc != filepath.Join(dir, "etc/passwd") {
+		t.Errorf("path escapes the root: %s", loc)
+	}
+
+	if key := FileKey("/../a/./b.csv"); key != "a/b.csv" {
+		t.Errorf("key: %s", key)
+	}
+}
+
+// fakeS3 in-process path-style object storage
+type fakeS3 struct {
+	objects map[string][]byte
+	parts   map[string]map[int][]byte
+	sync.Mutex
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), s3Algorithm+" Credential=key/") ||
+		r.Header.Get("x-amz-content-sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	switch r.Method {
+	case http.MethodHead:
+		if r.URL.Path != "/bucket" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPost:
+		q := r.URL.Query()
+		if _, ok := q["uploads"]; ok {
+			id := fmt.Sprintf("upload-%d", len(f.parts)+1)
+			f.parts[id] = make(map[int][]byte)
+			fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+			return
+		}
+		parts, ok := f.parts[q.Get("uploadId")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Parts []s3CompletePart `xml:"Part"`
+		}
+		xml.NewDecoder(r.Body).Decode(&req)
+		var data []byte
+		for _, p := range req.Parts {
+			data = append(data, parts[p.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = data
+		delete(f.parts, q.Get("uploadId"))
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if sha256Hex(data) != r.Header.Get("x-amz-content-sha256") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if id := r.URL.Query().Get("uploadId"); id != "" {
+			n, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+			f.parts[id][n] = data
+			w.Header().Set("ETag", fmt.Sprintf("\"%d\"", n))
+			return
+		}
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.Copy(w, bytes.NewReader(data))
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3FileBackend(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte), parts: make(map[string]map[int][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	b, err := NewFileBackend(&model.FileSettings{
+		Driver:      FileDriverS3,
+		S3Endpoint:  strings.TrimPrefix(srv.URL, "http://"),
+		S3Bucket:    "bucket",
+		S3AccessKey: "key",
+		S3SecretKey: "secret",
+		S3Prefix:    "cc",
+		S3Insecure:  true,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testFileBackend(t, b)
+
+	b.WriteFile(strings.NewReader("x"), "test.txt")
+	if _, ok := fake.objects["/bucket/cc/test.txt"]; !ok {
+		t.Errorf("object not stored with prefix")
+	}
+
+	// the multipart upload
+	b.(*S3FileBackend).partSize = 4
+	if n, err := b.WriteFile(strings.NewReader("0123456789"), "parts.txt"); err != nil || n != 10 {
+		t.Fatalf("multipart: written %d %v", n, err)
+	}
+	if data := fake.objects["/bucket/cc/parts.txt"]; string(data) != "0123456789" {
+		t.Errorf("multipart: bad data %s", data)
+	}
+	if len(fake.parts) != 0 {
+		t.Errorf("multipart: upload is not completed")
+	}
+}